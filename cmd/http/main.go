@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	_ "github.com/lolzone13/DeepResearch/docs" // Import swagger docs
 	"github.com/lolzone13/DeepResearch/internal/config"
@@ -28,7 +36,7 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-// @description Bearer token for API authentication (e.g., "Bearer {token}")
+// @description Bearer token or personal API key (e.g., "Bearer {token}" or "ApiKey {key}")
 
 func main() {
 	cfg, err := config.LoadConfig("dev")
@@ -50,17 +58,62 @@ func main() {
 
 	addr := cfg.Server.Host + ":" + strconv.Itoa(cfg.Server.Port)
 
+	// Background workers stop when the process is asked to shut down
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Setup routes with services
-	router, err := handlers.SetupRoutesWithServices(cfg, dbService)
+	router, waitWorkers, err := handlers.SetupRoutesWithServices(ctx, cfg, dbService)
 	if err != nil {
 		log.Fatalf("Failed to setup routes: %v", err)
 	}
 
+	server := &http.Server{
+		Addr:    addr,
+		Handler: router,
+		// Request contexts end at shutdown, so that event streams close instead of holding it up
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
 	// Start server
 	log.Printf("Starting server on %s", addr)
 	log.Printf("Swagger docs available at: http://%s/swagger/index.html", addr)
 
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	case <-ctx.Done():
+	}
+	stop()
+
+	timeout := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Printf("Shutting down...")
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to close open connections: %v", err)
+	}
+
+	// Workers requeue their interrupted jobs before stopping
+	drained := make(chan struct{})
+	go func() {
+		waitWorkers()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-shutdownCtx.Done():
+		log.Printf("Research workers did not stop within %s", timeout)
 	}
 }
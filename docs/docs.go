@@ -47,133 +47,123 @@ const docTemplate = `{
                 }
             }
         },
-        "/auth/login": {
-            "post": {
-                "description": "Authenticate user with email and password",
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Get the public keys access tokens are signed with, so that other services can verify them.\nTokens name their key in the kid header. Empty when tokens are signed with a shared secret.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "auth"
                 ],
-                "summary": "User login",
-                "parameters": [
-                    {
-                        "description": "Login credentials",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/AuthRequest"
-                        }
-                    }
-                ],
+                "summary": "JSON Web Key Set",
                 "responses": {
                     "200": {
-                        "description": "Successful login",
-                        "schema": {
-                            "$ref": "#/definitions/AuthResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request",
-                        "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Invalid credentials",
+                        "description": "Public signing keys",
                         "schema": {
-                            "$ref": "#/definitions/ErrorResponse"
+                            "$ref": "#/definitions/oidc.JWKS"
                         }
                     }
                 }
             }
         },
-        "/auth/register": {
-            "post": {
-                "description": "Register a new user account",
-                "consumes": [
-                    "application/json"
+        "/admin/audit-events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Get a paginated list of audit events, such as sign-in attempts, newest first. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "admin"
                 ],
-                "summary": "User registration",
+                "summary": "List audit events",
                 "parameters": [
                     {
-                        "description": "Registration details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/AuthRequest"
-                        }
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Items per page (max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "login.succeeded",
+                            "login.failed",
+                            "login.locked"
+                        ],
+                        "type": "string",
+                        "description": "Filter by action",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by the email given",
+                        "name": "email",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Successful registration",
+                    "200": {
+                        "description": "List of audit events",
                         "schema": {
-                            "$ref": "#/definitions/AuthResponse"
+                            "$ref": "#/definitions/AuditEventsListResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid filter",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "User already exists",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Check if the API is healthy and running",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "API is healthy",
+                    },
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {
-                            "$ref": "#/definitions/HealthResponse"
+                            "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/research/sessions": {
+        "/admin/sessions": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get a paginated list of research sessions for the authenticated user",
+                "description": "Get a paginated list of the research sessions of every user. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "admin"
                 ],
-                "summary": "List research sessions",
+                "summary": "List all sessions",
                 "parameters": [
                     {
                         "type": "integer",
@@ -184,81 +174,115 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 10,
-                        "description": "Items per page",
+                        "default": 20,
+                        "description": "Items per page (max 100)",
                         "name": "per_page",
                         "in": "query"
                     },
                     {
                         "enum": [
-                            "active",
+                            "pending",
+                            "running",
                             "completed",
-                            "failed"
+                            "failed",
+                            "cancelled"
                         ],
                         "type": "string",
                         "description": "Filter by status",
                         "name": "status",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by owner",
+                        "name": "user_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sessions retrieved successfully",
+                        "description": "List of sessions",
                         "schema": {
                             "$ref": "#/definitions/SessionsListResponse"
                         }
                     },
+                    "400": {
+                        "description": "Invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Not an admin",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/admin/users": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new research session for the authenticated user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get a paginated list of every user. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "admin"
                 ],
-                "summary": "Create research session",
+                "summary": "List users",
                 "parameters": [
                     {
-                        "description": "Session details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/CreateSessionRequest"
-                        }
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Items per page (max 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "admin"
+                        ],
+                        "type": "string",
+                        "description": "Filter by role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search email and name",
+                        "name": "q",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Session created successfully",
+                    "200": {
+                        "description": "List of users",
                         "schema": {
-                            "$ref": "#/definitions/SessionResponse"
+                            "$ref": "#/definitions/AdminUsersListResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid role",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -268,29 +292,35 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/research/sessions/{id}": {
-            "get": {
+        "/admin/users/{id}/disable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Get details of a specific research session",
+                "description": "Stop a user from signing in and revoke all of their tokens. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "admin"
                 ],
-                "summary": "Get research session",
+                "summary": "Disable a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -298,9 +328,15 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Session retrieved successfully",
+                        "description": "Disabled user",
                         "schema": {
-                            "$ref": "#/definitions/SessionResponse"
+                            "$ref": "#/definitions/AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
                         }
                     },
                     "401": {
@@ -309,54 +345,56 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Session not found",
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Last enabled admin",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/users/{id}/enable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update details of a specific research session",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Let a disabled user sign in again. Requires the admin role.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "admin"
                 ],
-                "summary": "Update research session",
+                "summary": "Enable a user",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Update details",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/UpdateSessionRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Session updated successfully",
+                        "description": "Enabled user",
                         "schema": {
-                            "$ref": "#/definitions/SessionResponse"
+                            "$ref": "#/definitions/AdminUserResponse"
                         }
                     },
                     "400": {
@@ -371,40 +409,69 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Session not found",
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{id}/role": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a specific research session",
+                "description": "Promote a user to admin or demote an admin to user. Requires the admin role.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "admin"
                 ],
-                "summary": "Delete research session",
+                "summary": "Change a user's role",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Session ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateRoleRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Session deleted successfully"
+                    "200": {
+                        "description": "Updated user",
+                        "schema": {
+                            "$ref": "#/definitions/AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -412,8 +479,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "Session not found",
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Last enabled admin",
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
@@ -421,35 +500,183 @@ const docTemplate = `{
                 }
             }
         },
-        "/research/stream": {
+        "/auth/api-keys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Stream research progress in real-time using Server-Sent Events",
+                "description": "Get every API key of the current user, newest first, including revoked and expired keys",
                 "produces": [
-                    "text/event-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "research"
+                    "api-keys"
                 ],
-                "summary": "Research streaming",
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "API keys",
+                        "schema": {
+                            "$ref": "#/definitions/APIKeysListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a personal API key for scripts and notebooks. The key is returned once and cannot be shown again;\nsend it as \"Authorization: ApiKey \u003ckey\u003e\". The read scope allows GET requests, write every other request\nand admin the admin routes. API keys cannot manage API keys.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Create API key",
+                "parameters": [
+                    {
+                        "description": "Key details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created key",
+                        "schema": {
+                            "$ref": "#/definitions/APIKeyCreatedResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin scope requires the admin role",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/api-keys/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get one API key of the current user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Get API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Research query",
-                        "name": "query",
-                        "in": "query",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Research progress stream",
+                        "description": "API key",
                         "schema": {
-                            "$ref": "#/definitions/ResearchProgressEvent"
+                            "$ref": "#/definitions/APIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid API key ID",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rename an API key or replace its scopes. Revoked keys cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Update API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Key updates",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated key",
+                        "schema": {
+                            "$ref": "#/definitions/APIKeyResponse"
                         }
                     },
                     "400": {
@@ -463,47 +690,2094 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Admin scope requires the admin role",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "API key revoked",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Permanently disable an API key. It stays in the list with its revocation time.",
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "Revoke API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "API key revoked"
+                    },
+                    "400": {
+                        "description": "Invalid API key ID",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "AuthRequest": {
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate user with email and password. Repeated failures lock out the email and the client IP\nfor exponentially longer periods; locked out attempts get 429 with a Retry-After header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "Login credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/AuthRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful login",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until the next attempt is allowed, when this failure started a lockout"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed attempts",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until the next attempt is allowed"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke the access token of the request and, when given, the refresh token issued with it",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out",
+                "parameters": [
+                    {
+                        "description": "Refresh token to revoke",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/LogoutRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Logged out"
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke every access token and refresh token of the current user. API keys keep working;\nrevoke them with DELETE /auth/api-keys/{id}.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out of all devices",
+                "responses": {
+                    "204": {
+                        "description": "Logged out everywhere"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/providers": {
+            "get": {
+                "description": "Get the names of the OpenID Connect identity providers users can sign in with",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "List identity providers",
+                "responses": {
+                    "200": {
+                        "description": "Identity providers",
+                        "schema": {
+                            "$ref": "#/definitions/OIDCProvidersResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/token": {
+            "post": {
+                "description": "Exchange the single-use login_code the web app received after an identity provider login for tokens.\nCodes expire after a minute.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Redeem an identity provider login",
+                "parameters": [
+                    {
+                        "description": "Login code",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/OIDCTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful login",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid, expired or used login code",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/callback": {
+            "get": {
+                "description": "Finish signing in with the code and state the identity provider redirected the browser back with.\nThe account linked to the identity signs in; otherwise the identity is linked to the account with its\nverified email, or a new account is created when the provider allows it. The browser is sent on to\n\u003capp_url\u003e/oidc/callback with a login_code to redeem at /auth/oidc/token, or with error and\nerror_description when the login failed.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish identity provider login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identity provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the web app"
+                    }
+                }
+            },
+            "post": {
+                "description": "Finish signing in with the code and state the identity provider redirected an app back with,\nfor apps that register their own page as the redirect URL. The binding returned when the login started\nmust be sent along, unless the login cookie is.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Finish identity provider login from an app",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identity provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Code, state and binding",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/OIDCCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful login",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or login state",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Identity provider login failed",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled or not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown identity provider",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/{provider}/login": {
+            "get": {
+                "description": "Start signing in at an identity provider with the authorization code flow and PKCE. Redirects to the\nprovider, or returns its URL when redirect is false. The login is bound to the client that starts it\nwith an HttpOnly cookie, and for apps also with the returned binding, which the callback must present.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start identity provider login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Identity provider name",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "default": true,
+                        "description": "Redirect to the provider",
+                        "name": "redirect",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Provider URL",
+                        "schema": {
+                            "$ref": "#/definitions/OIDCLoginResponse"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the provider"
+                    },
+                    "404": {
+                        "description": "Unknown identity provider",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Identity provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Email a password reset link to the account with this address. The response is the same whether or not the address is registered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Forgot password",
+                "parameters": [
+                    {
+                        "description": "Account email",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Reset email sent if the account exists"
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many reset requests for the email or from the client IP",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        },
+                        "headers": {
+                            "Retry-After": {
+                                "type": "integer",
+                                "description": "Seconds until the next request is allowed"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Email is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Set a new password with the token from the reset email. Each token works once. Every existing\nsession of the account is signed out and every API key of the account is revoked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Reset token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Password changed"
+                    },
+                    "400": {
+                        "description": "Invalid, expired or used token",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and refresh token. Each refresh token works once; presenting a used one again revokes every token issued since the login it came from.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh tokens",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New token pair",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid, expired or reused refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account disabled",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Register a new user account. A link to verify the email address is sent when email is configured.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User registration",
+                "parameters": [
+                    {
+                        "description": "Registration details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/AuthRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successful registration",
+                        "schema": {
+                            "$ref": "#/definitions/AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email": {
+            "post": {
+                "description": "Confirm an email address with the token from the verification email. Each token works once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify email address",
+                "parameters": [
+                    {
+                        "description": "Verification token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/VerifyEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verified user",
+                        "schema": {
+                            "$ref": "#/definitions/UserInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid, expired or used token",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/verify-email/resend": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Send a new email verification link to the current user",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend verification email",
+                "responses": {
+                    "202": {
+                        "description": "Email sent"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email already verified",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Email is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the API is healthy and running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "API is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a paginated list of research sessions for the authenticated user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "List research sessions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Items per page",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "running",
+                            "completed",
+                            "failed",
+                            "cancelled"
+                        ],
+                        "type": "string",
+                        "description": "Filter by status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of sessions",
+                        "schema": {
+                            "$ref": "#/definitions/SessionsListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a new research session. search_depth (shallow, medium, deep) sets the number of query expansion\nand follow-up search rounds and how many sources each round reads; max_sources (1-100) caps the sources of a run.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Create research session",
+                "parameters": [
+                    {
+                        "description": "Session details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Session created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/SessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, max_sources or search_depth",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the number of the authenticated user's research sessions in each status",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Get session statistics",
+                "responses": {
+                    "200": {
+                        "description": "Session counts by status",
+                        "schema": {
+                            "$ref": "#/definitions/SessionStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a specific research session by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Get research session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session details",
+                        "schema": {
+                            "$ref": "#/definitions/SessionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a specific research session",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Update research session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Session updates",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/UpdateSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated session",
+                        "schema": {
+                            "$ref": "#/definitions/SessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a specific research session",
+                "tags": [
+                    "research"
+                ],
+                "summary": "Delete research session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Session deleted successfully"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/bibliography": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download every source of a research session as BibTeX, RIS or CSL-JSON for import into a reference manager such as Zotero. Authors, publication date, DOI and journal are included where the source declared them.",
+                "produces": [
+                    "application/x-bibtex",
+                    "application/x-research-info-systems",
+                    "application/vnd.citationstyles.csl+json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Export the bibliography",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "bibtex",
+                            "ris",
+                            "csl-json"
+                        ],
+                        "type": "string",
+                        "default": "bibtex",
+                        "description": "Bibliography format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Bibliography as an attachment",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stop the session's queued or running research job. A queued job is cancelled at once;\na running job stops at its next checkpoint and the session becomes cancelled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Cancel research",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Cancellation accepted",
+                        "schema": {
+                            "$ref": "#/definitions/JobResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "No research run to cancel",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Download a report of a research session with its title, query, summaries, final answer with numbered citations and a bibliography of its sources",
+                "produces": [
+                    "text/markdown",
+                    "text/html",
+                    "application/pdf",
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Export a session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "md",
+                            "html",
+                            "pdf",
+                            "json"
+                        ],
+                        "type": "string",
+                        "default": "md",
+                        "description": "Report format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report as an attachment",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get a session's messages in chronological order with their thoughts and cited sources.\nPass next_cursor from the previous page as cursor to continue. The last page also\nreturns a cursor; polling with it returns only messages published later, including\nassistant replies, which appear once their research finishes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "List messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Messages per page (max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of messages",
+                        "schema": {
+                            "$ref": "#/definitions/MessagesListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Submit a user turn to a research session. Research on it starts in the background\nand the assistant reply is stored as a new message when it finishes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "Send a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message content",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Message accepted",
+                        "schema": {
+                            "$ref": "#/definitions/MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email not verified",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Research is already running",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/retrieve": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Find the indexed passages of a session's documents most similar to a question",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Retrieve passages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Question",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 8,
+                        "description": "Number of passages (1-50)",
+                        "name": "k",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Closest passages, most similar first",
+                        "schema": {
+                            "$ref": "#/definitions/RetrievalResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Retrieval is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Attach to a session's research runs and stream their typed events using Server-Sent Events.\nEvery event except token deltas carries a monotonic id; reconnect with the Last-Event-ID header\n(or last_event_id query parameter) to replay the events that were missed. The stream stays open\nacross turns.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Session event stream",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the last event received, for clients that cannot set headers",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Research event stream",
+                        "schema": {
+                            "$ref": "#/definitions/ResearchEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/summaries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Get the summaries written for a research session, one per summary type",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summaries"
+                ],
+                "summary": "List summaries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session summaries",
+                        "schema": {
+                            "$ref": "#/definitions/SummariesListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/sessions/{id}/summaries/regenerate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Rewrite one summary of a session from its stored documents, without searching or crawling again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "summaries"
+                ],
+                "summary": "Regenerate a summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "overview",
+                            "detailed",
+                            "key_points",
+                            "conclusion"
+                        ],
+                        "type": "string",
+                        "description": "Summary type",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Regenerated summary",
+                        "schema": {
+                            "$ref": "#/definitions/SummaryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "No documents to summarize",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/research/stream": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Queue research for a session and stream its progress in real-time using Server-Sent Events.\nWhen session_id is omitted a new session is created for the query. The run continues in the\nbackground if the client disconnects.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "research"
+                ],
+                "summary": "Research streaming",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Research session ID",
+                        "name": "session_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Research query (required without session_id)",
+                        "name": "query",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Research progress stream",
+                        "schema": {
+                            "$ref": "#/definitions/ResearchProgressEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email not verified, or an API key without the write scope",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Research is already running",
+                        "schema": {
+                            "$ref": "#/definitions/ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "APIKeyCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "key": {
+                    "description": "Send as \"Authorization: ApiKey \u003ckey\u003e\"; it is not shown again",
+                    "type": "string",
+                    "example": "dr_Q2hhbmdlIHRoaXMgdG8gYSByYW5kb20ga2V5"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-06-08T09:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Analysis notebook"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string",
+                    "example": "dr_Q2hhbmdl"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-06-09T09:00:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2026-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2025-06-08T09:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Analysis notebook"
+                },
+                "prefix": {
+                    "description": "First characters of the key",
+                    "type": "string",
+                    "example": "dr_Q2hhbmdl"
+                },
+                "revoked_at": {
+                    "type": "string",
+                    "example": "2025-06-09T09:00:00Z"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "APIKeysListResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/APIKeyResponse"
+                    }
+                }
+            }
+        },
+        "AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "disabled_at": {
+                    "type": "string",
+                    "example": "2025-06-08T09:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "is_disabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "user"
+                }
+            }
+        },
+        "AdminUsersListResponse": {
+            "type": "object",
+            "properties": {
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AdminUserResponse"
+                    }
+                }
+            }
+        },
+        "AuditEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "login.succeeded",
+                        "login.failed",
+                        "login.locked"
+                    ],
+                    "example": "login.failed"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "invalid credentials"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "method": {
+                    "type": "string",
+                    "example": "password"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                }
+            }
+        },
+        "AuditEventsListResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AuditEventResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "per_page": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "AuthRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "John Doe"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "password123"
+                }
+            }
+        },
+        "AuthResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "When the access token expires",
+                    "type": "string",
+                    "example": "2025-06-07T01:26:28Z"
+                },
+                "refresh_expires_at": {
+                    "type": "string",
+                    "example": "2025-07-07T01:11:28Z"
+                },
+                "refresh_token": {
+                    "description": "Single use; exchange it at /auth/refresh for a new pair",
+                    "type": "string",
+                    "example": "bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/UserInfo"
+                }
+            }
+        },
+        "ChunkResponse": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "Logical error rates fell by a factor of 2.14 when the code distance increased..."
+                },
+                "document_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "end_offset": {
+                    "type": "integer",
+                    "example": 6733
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "score": {
+                    "type": "number",
+                    "example": 0.83
+                },
+                "source_id": {
+                    "type": "string",
+                    "example": "789e0123-e89b-12d3-a456-426614174002"
+                },
+                "start_offset": {
+                    "type": "integer",
+                    "example": 5120
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Quantum Error Correction Below the Surface Code Threshold"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://arxiv.org/abs/2401.00001"
+                }
+            }
+        },
+        "CitationResponse": {
             "type": "object",
-            "required": [
-                "email",
-                "password"
-            ],
             "properties": {
-                "email": {
+                "chunk_id": {
                     "type": "string",
-                    "example": "user@example.com"
+                    "example": "012e3456-e89b-12d3-a456-426614174003"
                 },
-                "name": {
+                "claim": {
                     "type": "string",
-                    "example": "John Doe"
+                    "example": "Surface codes protect logical qubits by spreading them over many physical qubits"
                 },
-                "password": {
+                "document_id": {
                     "type": "string",
-                    "minLength": 6,
-                    "example": "password123"
+                    "example": "789e0123-e89b-12d3-a456-426614174002"
+                },
+                "end_offset": {
+                    "type": "integer",
+                    "example": 5236
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "marker": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "position": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "quote": {
+                    "type": "string",
+                    "example": "A logical qubit is encoded in a lattice of physical qubits."
+                },
+                "source_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "start_offset": {
+                    "type": "integer",
+                    "example": 5120
                 }
             }
         },
-        "AuthResponse": {
+        "CreateAPIKeyRequest": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "expires_at": {
+                    "description": "Never expires when omitted",
                     "type": "string",
-                    "example": "2025-06-08T01:11:28Z"
+                    "example": "2026-01-01T00:00:00Z"
                 },
-                "token": {
+                "name": {
                     "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                    "maxLength": 100,
+                    "example": "Analysis notebook"
                 },
-                "user": {
-                    "$ref": "#/definitions/UserInfo"
+                "scopes": {
+                    "description": "Defaults to read and write",
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "read",
+                            "write",
+                            "admin"
+                        ]
+                    },
+                    "example": [
+                        "read",
+                        "write"
+                    ]
+                }
+            }
+        },
+        "CreateMessageRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "How do error-correcting codes work on quantum hardware?"
                 }
             }
         },
@@ -514,7 +2788,10 @@ const docTemplate = `{
             ],
             "properties": {
                 "max_sources": {
+                    "description": "Defaults to 20",
                     "type": "integer",
+                    "maximum": 100,
+                    "minimum": 1,
                     "example": 10
                 },
                 "query": {
@@ -522,6 +2799,7 @@ const docTemplate = `{
                     "example": "What are the latest developments in AI?"
                 },
                 "search_depth": {
+                    "description": "Defaults to medium",
                     "type": "string",
                     "enum": [
                         "shallow",
@@ -564,6 +2842,18 @@ const docTemplate = `{
                 }
             }
         },
+        "ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
         "HealthResponse": {
             "type": "object",
             "properties": {
@@ -581,6 +2871,317 @@ const docTemplate = `{
                 }
             }
         },
+        "JobResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "cancel_requested": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:12:10Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "Finding relevant sources: no sources found for query"
+                },
+                "max_attempts": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:30Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "queued",
+                        "running",
+                        "succeeded",
+                        "failed",
+                        "cancelled"
+                    ],
+                    "example": "running"
+                }
+            }
+        },
+        "LogoutRequest": {
+            "type": "object",
+            "properties": {
+                "refresh_token": {
+                    "description": "Revoked with the access token when given",
+                    "type": "string",
+                    "example": "bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"
+                }
+            }
+        },
+        "MessageResponse": {
+            "type": "object",
+            "properties": {
+                "citations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/CitationResponse"
+                    }
+                },
+                "content": {
+                    "type": "string",
+                    "example": "Surface codes protect logical qubits by... [1]"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "reply_to_id": {
+                    "description": "User message an assistant reply answers",
+                    "type": "string",
+                    "example": "789e0123-e89b-12d3-a456-426614174002"
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "sources": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SourceResponse"
+                    }
+                },
+                "thoughts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ThoughtResponse"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "assistant",
+                        "system"
+                    ],
+                    "example": "assistant"
+                }
+            }
+        },
+        "MessagesListResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/MessageResponse"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string",
+                    "example": "MjAyNS0wNi0wN1QwMToxMToyOFp8MTIz"
+                }
+            }
+        },
+        "OIDCCallbackRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "binding": {
+                    "description": "Binding returned when the login started; may be left out when the login cookie is sent",
+                    "type": "string",
+                    "example": "Yc2vB1mL0sQh7kXxW3d9n4tR6pZfJ8uA5eGiOqKwN2M"
+                },
+                "code": {
+                    "type": "string",
+                    "example": "SplxlOBeZQQYbYS6WxSbIA"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "af0ifjsldkj"
+                }
+            }
+        },
+        "OIDCLoginResponse": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string",
+                    "example": "https://accounts.example.com/authorize?response_type=code\u0026client_id=deepresearch"
+                },
+                "binding": {
+                    "description": "Secret the app sends with the callback",
+                    "type": "string",
+                    "example": "Yc2vB1mL0sQh7kXxW3d9n4tR6pZfJ8uA5eGiOqKwN2M"
+                }
+            }
+        },
+        "OIDCProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "google",
+                        "okta"
+                    ]
+                }
+            }
+        },
+        "OIDCTokenRequest": {
+            "type": "object",
+            "required": [
+                "login_code"
+            ],
+            "properties": {
+                "login_code": {
+                    "type": "string",
+                    "example": "q3Jm0dH8sLwT5bVx1kC9nE2rY7uPzA4fGiOoKeN6S_w"
+                }
+            }
+        },
+        "RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"
+                }
+            }
+        },
+        "ResearchEvent": {
+            "type": "object",
+            "properties": {
+                "claim": {
+                    "type": "string",
+                    "example": "Surface codes tolerate error rates near 1%"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "Found 8 sources"
+                },
+                "delta": {
+                    "type": "string",
+                    "example": "Surface codes"
+                },
+                "document_id": {
+                    "type": "string",
+                    "example": "def67890-e89b-12d3-a456-426614174004"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "no sources found for query"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "message_id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "progress": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "source_id": {
+                    "type": "string",
+                    "example": "abc12345-e89b-12d3-a456-426614174003"
+                },
+                "thought_id": {
+                    "type": "string",
+                    "example": "789e0123-e89b-12d3-a456-426614174002"
+                },
+                "thought_type": {
+                    "type": "string",
+                    "enum": [
+                        "searching",
+                        "analyzing",
+                        "synthesizing",
+                        "validating",
+                        "completed",
+                        "error"
+                    ],
+                    "example": "searching"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Finding relevant sources"
+                },
+                "type": {
+                    "enum": [
+                        "thought_started",
+                        "thought_progress",
+                        "source_found",
+                        "document_processed",
+                        "token",
+                        "claim_verified",
+                        "answer_revised",
+                        "completed",
+                        "retrying",
+                        "failed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResearchEventType"
+                        }
+                    ],
+                    "example": "thought_started"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://arxiv.org/abs/2401.00001"
+                },
+                "verdict": {
+                    "type": "string",
+                    "enum": [
+                        "supported",
+                        "contradicted",
+                        "unsupported"
+                    ],
+                    "example": "supported"
+                }
+            }
+        },
         "ResearchProgressEvent": {
             "type": "object",
             "properties": {
@@ -608,6 +3209,52 @@ const docTemplate = `{
                 "timestamp": {
                     "type": "string",
                     "example": "2025-06-07T01:11:28Z"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "searching",
+                        "analyzing",
+                        "synthesizing",
+                        "validating",
+                        "completed",
+                        "error"
+                    ],
+                    "example": "searching"
+                }
+            }
+        },
+        "ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 6,
+                    "example": "newpassword123"
+                },
+                "token": {
+                    "description": "From the password reset email",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "RetrievalResponse": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ChunkResponse"
+                    }
+                },
+                "query": {
+                    "type": "string",
+                    "example": "How much did logical error rates improve?"
                 }
             }
         },
@@ -618,10 +3265,18 @@ const docTemplate = `{
                     "type": "string",
                     "example": "2025-06-07T01:11:28Z"
                 },
+                "finished_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:14:10Z"
+                },
                 "id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
                 },
+                "max_sources": {
+                    "type": "integer",
+                    "example": 10
+                },
                 "message_count": {
                     "type": "integer",
                     "example": 5
@@ -630,14 +3285,29 @@ const docTemplate = `{
                     "type": "string",
                     "example": "What are the latest developments in AI?"
                 },
+                "search_depth": {
+                    "type": "string",
+                    "enum": [
+                        "shallow",
+                        "medium",
+                        "deep"
+                    ],
+                    "example": "deep"
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:12:00Z"
+                },
                 "status": {
                     "type": "string",
                     "enum": [
-                        "active",
+                        "pending",
+                        "running",
                         "completed",
-                        "failed"
+                        "failed",
+                        "cancelled"
                     ],
-                    "example": "active"
+                    "example": "running"
                 },
                 "tags": {
                     "type": "array",
@@ -664,6 +3334,40 @@ const docTemplate = `{
                 }
             }
         },
+        "SessionStatsResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "Pending or running",
+                    "type": "integer",
+                    "example": 3
+                },
+                "cancelled": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "completed": {
+                    "type": "integer",
+                    "example": 19
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "pending": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "running": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 25
+                }
+            }
+        },
         "SessionsListResponse": {
             "type": "object",
             "properties": {
@@ -691,6 +3395,237 @@ const docTemplate = `{
                 }
             }
         },
+        "SourceResponse": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Jane Smith",
+                        "Sam Lee"
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "example": "We report an experiment..."
+                },
+                "doi": {
+                    "type": "string",
+                    "example": "10.1038/s41586-024-08449-y"
+                },
+                "domain": {
+                    "type": "string",
+                    "example": "arxiv.org"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "journal": {
+                    "type": "string",
+                    "example": "Nature"
+                },
+                "last_crawled": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:30Z"
+                },
+                "published_at": {
+                    "type": "string",
+                    "example": "2024-12-09T00:00:00Z"
+                },
+                "publisher": {
+                    "type": "string",
+                    "example": "Nature Publishing Group"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Quantum Error Correction Below the Surface Code Threshold"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "website",
+                        "pdf",
+                        "academic_paper",
+                        "news_article"
+                    ],
+                    "example": "academic_paper"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://arxiv.org/abs/2401.00001"
+                }
+            }
+        },
+        "SummariesListResponse": {
+            "type": "object",
+            "properties": {
+                "summaries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/SummaryResponse"
+                    }
+                }
+            }
+        },
+        "SummaryResponse": {
+            "type": "object",
+            "properties": {
+                "confidence_score": {
+                    "type": "number",
+                    "example": 0.82
+                },
+                "content": {
+                    "type": "string",
+                    "example": "- Surface codes tolerate error rates near 1% [1]"
+                },
+                "generated_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:14:10Z"
+                },
+                "generated_by": {
+                    "type": "string",
+                    "enum": [
+                        "ai",
+                        "extractive",
+                        "human"
+                    ],
+                    "example": "ai"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "key_points": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "Surface codes tolerate error rates near 1% [1]"
+                    ]
+                },
+                "session_id": {
+                    "type": "string",
+                    "example": "456e7890-e89b-12d3-a456-426614174001"
+                },
+                "sources_used": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Key points"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "overview",
+                        "detailed",
+                        "key_points",
+                        "conclusion"
+                    ],
+                    "example": "key_points"
+                }
+            }
+        },
+        "ThoughtResponse": {
+            "type": "object",
+            "properties": {
+                "completed_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:30Z"
+                },
+                "content": {
+                    "type": "string",
+                    "example": "Found 8 sources"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "123e4567-e89b-12d3-a456-426614174000"
+                },
+                "metadata": {
+                    "type": "string",
+                    "example": "{\"queries\":[\"quantum error correction\"]}"
+                },
+                "progress": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2025-06-07T01:11:28Z"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "processing",
+                        "completed",
+                        "failed"
+                    ],
+                    "example": "completed"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Finding relevant sources"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "searching",
+                        "analyzing",
+                        "synthesizing",
+                        "validating",
+                        "completed",
+                        "error"
+                    ],
+                    "example": "searching"
+                }
+            }
+        },
+        "UpdateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Nightly report script"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "read",
+                            "write",
+                            "admin"
+                        ]
+                    },
+                    "example": [
+                        "read"
+                    ]
+                }
+            }
+        },
+        "UpdateRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
+                    "example": "admin"
+                }
+            }
+        },
         "UpdateSessionRequest": {
             "type": "object",
             "properties": {
@@ -719,6 +3654,10 @@ const docTemplate = `{
                     "type": "string",
                     "example": "user@example.com"
                 },
+                "email_verified": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "id": {
                     "type": "string",
                     "example": "123e4567-e89b-12d3-a456-426614174000"
@@ -729,14 +3668,106 @@ const docTemplate = `{
                 },
                 "role": {
                     "type": "string",
+                    "enum": [
+                        "user",
+                        "admin"
+                    ],
                     "example": "user"
                 }
             }
+        },
+        "VerifyEmailRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "token": {
+                    "description": "From the verification email",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "models.ResearchEventType": {
+            "type": "string",
+            "enum": [
+                "thought_started",
+                "thought_progress",
+                "source_found",
+                "document_processed",
+                "token",
+                "claim_verified",
+                "answer_revised",
+                "completed",
+                "retrying",
+                "failed"
+            ],
+            "x-enum-comments": {
+                "ResearchEventRetrying": "An attempt failed and the run will be tried again"
+            },
+            "x-enum-varnames": [
+                "ResearchEventThoughtStarted",
+                "ResearchEventThoughtProgress",
+                "ResearchEventSourceFound",
+                "ResearchEventDocumentProcessed",
+                "ResearchEventToken",
+                "ResearchEventClaimVerified",
+                "ResearchEventAnswerRevised",
+                "ResearchEventCompleted",
+                "ResearchEventRetrying",
+                "ResearchEventFailed"
+            ]
+        },
+        "oidc.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "description": "EC and OKP",
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "description": "RSA",
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "oidc.JWKS": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/oidc.JWK"
+                    }
+                }
+            }
         }
     },
     "securityDefinitions": {
         "ApiKeyAuth": {
-            "description": "Bearer token for API authentication (e.g., \"Bearer {token}\")",
+            "description": "Bearer token or personal API key (e.g., \"Bearer {token}\" or \"ApiKey {key}\")",
             "type": "apiKey",
             "name": "Authorization",
             "in": "header"
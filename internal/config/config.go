@@ -11,6 +11,14 @@ type Config struct {
 	Server struct {
 		Port int    `mapstructure:"port"`
 		Host string `mapstructure:"host"`
+
+		// TrustedProxies lists the addresses (IPs or CIDRs) of reverse proxies whose
+		// X-Forwarded-For header gives the client IP. Leave empty when clients connect directly.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+		// ShutdownTimeoutSeconds bounds how long shutdown waits for open requests
+		// and running research jobs; interrupted jobs are queued again
+		ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 	} `mapstructure:"server"`
 
 	Database struct {
@@ -32,16 +40,178 @@ type Config struct {
 	} `mapstructure:"database"`
 
 	Redis struct {
-		Addr     string `mapstructure:"addr"`
+		Addr     string `mapstructure:"addr"` // host:port or a redis:// URL; leave empty to run without Redis
 		Password string `mapstructure:"password"`
 		DB       int    `mapstructure:"db"`
 	} `mapstructure:"redis"`
 
 	JWT struct {
-		Secret      string `mapstructure:"secret"`
-		ExpiryHours int    `mapstructure:"expiry_hours"`
+		// Secret signs access tokens (HS256) when no keys are configured, and emailed
+		// tokens unless accounts.token_secret is set
+		Secret             string `mapstructure:"secret"`
+		ExpiryHours        int    `mapstructure:"expiry_hours"`         // Access token lifetime when access_token_minutes is not set
+		AccessTokenMinutes int    `mapstructure:"access_token_minutes"` // Access tokens are short-lived; clients renew them with a refresh token
+		RefreshTokenDays   int    `mapstructure:"refresh_token_days"`
+
+		// Keys sign access tokens with RS256 (RSA) or EdDSA (Ed25519) and are published at
+		// /.well-known/jwks.json. To rotate, add a new key, switch signing_key_id to it and
+		// keep the old one, public half only, until the tokens it signed have expired.
+		SigningKeyID string `mapstructure:"signing_key_id"`
+		Keys         []struct {
+			ID             string `mapstructure:"id"`
+			PrivateKey     string `mapstructure:"private_key"` // PEM; or set private_key_file
+			PrivateKeyFile string `mapstructure:"private_key_file"`
+			PublicKey      string `mapstructure:"public_key"` // PEM; verification-only keys
+			PublicKeyFile  string `mapstructure:"public_key_file"`
+		} `mapstructure:"keys"`
 	} `mapstructure:"jwt"`
 
+	Accounts struct {
+		RequireVerifiedEmail bool   `mapstructure:"require_verified_email"` // Unverified users cannot start research runs
+		VerifyTokenHours     int    `mapstructure:"verify_token_hours"`
+		ResetTokenMinutes    int    `mapstructure:"reset_token_minutes"`
+		AppURL               string `mapstructure:"app_url"` // Base URL of the web app that emailed links open
+		// TokenSecret signs emailed tokens; at least 32 bytes. Defaults to jwt.secret.
+		TokenSecret    string `mapstructure:"token_secret"`
+		ResetsPerEmail int    `mapstructure:"resets_per_email"` // Password reset requests per address per hour
+		ResetsPerIP    int    `mapstructure:"resets_per_ip"`    // Password reset requests per client IP per hour
+	} `mapstructure:"accounts"`
+
+	Mail struct {
+		// Driver selects how emails are sent: smtp, or memory to capture them in-process.
+		// Leave empty to disable email verification and password reset emails.
+		Driver         string `mapstructure:"driver"`
+		From           string `mapstructure:"from"`
+		Host           string `mapstructure:"host"`
+		Port           int    `mapstructure:"port"` // 587 uses STARTTLS, 465 implicit TLS
+		Username       string `mapstructure:"username"`
+		Password       string `mapstructure:"password"`
+		TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+	} `mapstructure:"mail"`
+
+	OIDC struct {
+		// Providers lists the OpenID Connect identity providers users can sign in with
+		// at /auth/oidc/<name>/login. Register <redirect_url> with the provider. Browser
+		// logins return to <accounts.app_url>/oidc/callback, which must be set.
+		Providers []struct {
+			Name           string   `mapstructure:"name"`
+			Issuer         string   `mapstructure:"issuer"`
+			ClientID       string   `mapstructure:"client_id"`
+			ClientSecret   string   `mapstructure:"client_secret"` // Empty for public clients
+			RedirectURL    string   `mapstructure:"redirect_url"`  // e.g. https://api.example.com/auth/oidc/<name>/callback
+			Scopes         []string `mapstructure:"scopes"`
+			AutoProvision  bool     `mapstructure:"auto_provision"`  // Create accounts for new users
+			AllowedDomains []string `mapstructure:"allowed_domains"` // Empty allows every email domain
+		} `mapstructure:"providers"`
+	} `mapstructure:"oidc"`
+
+	LoginThrottle struct {
+		// Failed password logins lock out the email and the client IP once their free
+		// attempts are used up, for base_lockout_seconds doubled on every further failure.
+		// Counters are shared through Redis when redis.addr is set, otherwise kept in memory.
+		AccountAttempts    int `mapstructure:"account_attempts"`
+		IPAttempts         int `mapstructure:"ip_attempts"`
+		BaseLockoutSeconds int `mapstructure:"base_lockout_seconds"`
+		MaxLockoutSeconds  int `mapstructure:"max_lockout_seconds"`
+		WindowMinutes      int `mapstructure:"window_minutes"` // Failures are forgotten this long after the latest one
+	} `mapstructure:"login_throttle"`
+
+	Admin struct {
+		Emails []string `mapstructure:"emails"` // Existing users with these emails are made admins at startup
+	} `mapstructure:"admin"`
+
+	LLM struct {
+		// Provider selects the client implementation: openai, llamacpp, ollama or vllm
+		// (all OpenAI-compatible). Leave empty to run research without a language model.
+		Provider       string `mapstructure:"provider"`
+		Model          string `mapstructure:"model"`
+		EmbeddingModel string `mapstructure:"embedding_model"`
+		BaseURL        string `mapstructure:"base_url"`
+		APIKey         string `mapstructure:"api_key"`
+		TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+	} `mapstructure:"llm"`
+
+	Search struct {
+		// Providers lists the enabled backends (searxng, brave, fixture); results are merged.
+		// Leave empty to only use URLs mentioned in the query.
+		Providers       []string `mapstructure:"providers"`
+		ResultsPerQuery int      `mapstructure:"results_per_query"`
+		TimeoutSeconds  int      `mapstructure:"timeout_seconds"`
+
+		SearxNG struct {
+			BaseURL string `mapstructure:"base_url"`
+		} `mapstructure:"searxng"`
+
+		Brave struct {
+			BaseURL string `mapstructure:"base_url"`
+			APIKey  string `mapstructure:"api_key"`
+		} `mapstructure:"brave"`
+
+		Fixture struct {
+			Path string `mapstructure:"path"` // JSON file mapping queries to results
+		} `mapstructure:"fixture"`
+	} `mapstructure:"search"`
+
+	Crawler struct {
+		Workers        int    `mapstructure:"workers"`    // Concurrent fetches across all domains
+		PerDomain      int    `mapstructure:"per_domain"` // Concurrent fetches per domain
+		TimeoutSeconds int    `mapstructure:"timeout_seconds"`
+		MaxBodyBytes   int64  `mapstructure:"max_body_bytes"`
+		MaxRedirects   int    `mapstructure:"max_redirects"`
+		UserAgent      string `mapstructure:"user_agent"`
+		IgnoreRobots   bool   `mapstructure:"ignore_robots"` // robots.txt is respected unless set
+	} `mapstructure:"crawler"`
+
+	Summaries struct {
+		// Types lists the summaries written at the end of every run:
+		// overview, detailed, key_points, conclusion
+		Types []string `mapstructure:"types"`
+	} `mapstructure:"summaries"`
+
+	Ranking struct {
+		// Documents whose combined relevance (0-1) is below Threshold are left out of synthesis.
+		// Defaults to 0.2 when unset; a negative value keeps every document.
+		Threshold float64 `mapstructure:"threshold"`
+
+		// Weights of the relevance components; set a weight to 0 to disable its component.
+		// Academic research may favour embedding and domain; news research bm25 and llm.
+		Weights struct {
+			BM25      float64 `mapstructure:"bm25"`
+			Embedding float64 `mapstructure:"embedding"` // Needs an LLM provider
+			LLM       float64 `mapstructure:"llm"`       // Needs an LLM provider; one request per document
+			Domain    float64 `mapstructure:"domain"`
+		} `mapstructure:"weights"`
+
+		// DomainScores overrides the built-in domain priors. A domain also matches its
+		// subdomains, and suffixes such as ".edu" match every domain ending with them.
+		DomainScores []struct {
+			Domain string  `mapstructure:"domain"`
+			Score  float64 `mapstructure:"score"`
+		} `mapstructure:"domain_scores"`
+	} `mapstructure:"ranking"`
+
+	Retrieval struct {
+		// Store selects where chunk embeddings live: memory (default), which every
+		// process keeps for itself, or pgvector, which falls back to memory when the
+		// database lacks the extension. Retrieval needs an LLM provider with an embedding model.
+		Store        string `mapstructure:"store"`
+		ChunkUnit    string `mapstructure:"chunk_unit"` // tokens or chars
+		ChunkSize    int    `mapstructure:"chunk_size"`
+		ChunkOverlap int    `mapstructure:"chunk_overlap"`
+		BatchSize    int    `mapstructure:"batch_size"` // Chunks embedded per request
+		Passages     int    `mapstructure:"passages"`   // Passages given to the synthesizer
+	} `mapstructure:"retrieval"`
+
+	Jobs struct {
+		Workers             int `mapstructure:"workers"` // Research runs executed concurrently by this process
+		PollIntervalSeconds int `mapstructure:"poll_interval_seconds"`
+		MaxAttempts         int `mapstructure:"max_attempts"`
+		RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"` // Doubled on every further attempt
+		MaxBackoffSeconds   int `mapstructure:"max_backoff_seconds"`
+		HeartbeatSeconds    int `mapstructure:"heartbeat_seconds"`
+		StaleAfterSeconds   int `mapstructure:"stale_after_seconds"` // Running jobs without a heartbeat are recovered
+	} `mapstructure:"jobs"`
+
 	GRPC struct {
 		Port int    `mapstructure:"port"`
 		Host string `mapstructure:"host"`
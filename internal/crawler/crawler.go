@@ -0,0 +1,238 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures a crawler
+type Options struct {
+	Workers       int           // Pages fetched concurrently across all domains
+	PerDomain     int           // Pages fetched concurrently from a single domain
+	Timeout       time.Duration // Per-request timeout, including redirects and body
+	MaxBodyBytes  int64         // Larger bodies are truncated
+	MaxRedirects  int
+	UserAgent     string
+	RespectRobots bool
+}
+
+// DefaultOptions returns sensible crawler defaults
+func DefaultOptions() Options {
+	return Options{
+		Workers:       8,
+		PerDomain:     2,
+		Timeout:       20 * time.Second,
+		MaxBodyBytes:  10 << 20,
+		MaxRedirects:  5,
+		UserAgent:     "DeepResearchBot/1.0 (+https://github.com/lolzone13/DeepResearch)",
+		RespectRobots: true,
+	}
+}
+
+// Page is the result of fetching one URL
+type Page struct {
+	URL         string // Requested URL
+	FinalURL    string // URL after redirects
+	StatusCode  int
+	ContentType string // Media type without parameters, e.g. "text/html"
+	Charset     string
+	Body        []byte
+	Truncated   bool // Body was cut at MaxBodyBytes
+	FetchedAt   time.Time
+	Err         error
+}
+
+// ErrDisallowed is returned for URLs that robots.txt forbids crawling
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// ErrTooManyRedirects is returned when a URL redirects more than MaxRedirects times
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// Crawler fetches pages concurrently with per-domain limits and robots.txt compliance
+type Crawler struct {
+	opts   Options
+	client *http.Client
+	robots *robotsCache
+
+	mu      sync.Mutex
+	domains map[string]chan struct{}
+}
+
+// New creates a new crawler, filling unset numeric options with defaults
+func New(opts Options) *Crawler {
+	defaults := DefaultOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.PerDomain <= 0 {
+		opts.PerDomain = defaults.PerDomain
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaults.MaxBodyBytes
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = defaults.MaxRedirects
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaults.UserAgent
+	}
+
+	c := &Crawler{
+		opts:    opts,
+		domains: make(map[string]chan struct{}),
+	}
+	c.client = &http.Client{
+		Timeout: opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirects
+			}
+			if opts.RespectRobots && !c.robots.allowed(req.Context(), req.URL) {
+				return ErrDisallowed
+			}
+			return nil
+		},
+	}
+	c.robots = newRobotsCache(&http.Client{Timeout: opts.Timeout}, opts.UserAgent)
+	return c
+}
+
+// FetchAll fetches every URL with a bounded worker pool.
+// Results are returned in the same order as the input; failures are reported in Page.Err.
+func (c *Crawler) FetchAll(ctx context.Context, urls []string) []Page {
+	pages := make([]Page, len(urls))
+	jobs := make(chan int)
+
+	workers := c.opts.Workers
+	if workers > len(urls) {
+		workers = len(urls)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				page, err := c.Fetch(ctx, urls[i])
+				if page == nil {
+					page = &Page{URL: urls[i], FetchedAt: time.Now()}
+				}
+				page.Err = err
+				pages[i] = *page
+			}
+		}()
+	}
+
+	for i := range urls {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(urls); j++ {
+				pages[j] = Page{URL: urls[j], Err: ctx.Err(), FetchedAt: time.Now()}
+			}
+			close(jobs)
+			wg.Wait()
+			return pages
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return pages
+}
+
+// Fetch downloads a single URL, waiting for a free slot on its domain.
+// For non-2xx responses both the page and an error are returned.
+func (c *Crawler) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q", target.Scheme)
+	}
+
+	release, err := c.acquire(ctx, target.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if c.opts.RespectRobots && !c.robots.allowed(ctx, target) {
+		return nil, ErrDisallowed
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/pdf,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &Page{
+		URL:        rawURL,
+		FinalURL:   resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		FetchedAt:  time.Now(),
+	}
+	if mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		page.ContentType = mediaType
+		page.Charset = strings.ToLower(params["charset"])
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return page, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.opts.MaxBodyBytes+1))
+	if err != nil {
+		return page, err
+	}
+	if int64(len(body)) > c.opts.MaxBodyBytes {
+		body = body[:c.opts.MaxBodyBytes]
+		page.Truncated = true
+	}
+	page.Body = body
+	if page.ContentType == "" {
+		page.ContentType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	return page, nil
+}
+
+// acquire blocks until the domain has a free slot and returns its release function
+func (c *Crawler) acquire(ctx context.Context, host string) (func(), error) {
+	host = strings.ToLower(host)
+
+	c.mu.Lock()
+	slots, ok := c.domains[host]
+	if !ok {
+		slots = make(chan struct{}, c.opts.PerDomain)
+		c.domains[host] = slots
+	}
+	c.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,274 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer serves the pages used by the crawler tests
+func newTestServer(t *testing.T, robots string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		if robots == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, robots)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		fmt.Fprint(w, "<html><body>hello</body></html>")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, strings.Repeat("x", 100))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	// /redirect/n redirects n more times before landing on /page
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/redirect/"))
+		if n <= 0 {
+			http.Redirect(w, r, "/page", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	mux.HandleFunc("/to-private", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/private/page", http.StatusFound)
+	})
+	mux.HandleFunc("/private/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "secret")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetch(t *testing.T) {
+	robots := "User-agent: *\nDisallow: /private/\n"
+
+	tests := []struct {
+		name          string
+		path          string
+		opts          Options
+		wantErr       error // Checked with errors.Is when set
+		wantAnyErr    bool
+		wantStatus    int
+		wantBody      string
+		wantTruncated bool
+		wantFinalPath string
+	}{
+		{
+			name:       "page",
+			path:       "/page",
+			wantStatus: http.StatusOK,
+			wantBody:   "<html><body>hello</body></html>",
+		},
+		{
+			name:          "body over the limit is truncated",
+			path:          "/large",
+			opts:          Options{MaxBodyBytes: 10},
+			wantStatus:    http.StatusOK,
+			wantBody:      "xxxxxxxxxx",
+			wantTruncated: true,
+		},
+		{
+			name:       "non-2xx status is an error with a page",
+			path:       "/missing",
+			wantAnyErr: true,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:          "redirects within the limit are followed",
+			path:          "/redirect/2",
+			opts:          Options{MaxRedirects: 5},
+			wantStatus:    http.StatusOK,
+			wantBody:      "<html><body>hello</body></html>",
+			wantFinalPath: "/page",
+		},
+		{
+			name:    "too many redirects",
+			path:    "/redirect/5",
+			opts:    Options{MaxRedirects: 2},
+			wantErr: ErrTooManyRedirects,
+		},
+		{
+			name:    "robots.txt disallows the page",
+			path:    "/private/page",
+			opts:    Options{RespectRobots: true},
+			wantErr: ErrDisallowed,
+		},
+		{
+			name:    "robots.txt disallows the redirect target",
+			path:    "/to-private",
+			opts:    Options{RespectRobots: true},
+			wantErr: ErrDisallowed,
+		},
+		{
+			name:       "robots.txt is ignored when not respected",
+			path:       "/private/page",
+			wantStatus: http.StatusOK,
+			wantBody:   "secret",
+		},
+		{
+			name:       "slow page times out",
+			path:       "/slow",
+			opts:       Options{Timeout: 50 * time.Millisecond},
+			wantAnyErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, robots)
+			page, err := New(tt.opts).Fetch(context.Background(), server.URL+tt.path)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Fetch error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantAnyErr:
+				if err == nil {
+					t.Fatal("Fetch succeeded, want an error")
+				}
+			case err != nil:
+				t.Fatalf("Fetch: %v", err)
+			}
+
+			if tt.wantStatus != 0 && (page == nil || page.StatusCode != tt.wantStatus) {
+				t.Fatalf("page = %+v, want status %d", page, tt.wantStatus)
+			}
+			if page == nil {
+				return
+			}
+			if tt.wantBody != "" && string(page.Body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", page.Body, tt.wantBody)
+			}
+			if page.Truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", page.Truncated, tt.wantTruncated)
+			}
+			if tt.wantFinalPath != "" && page.FinalURL != server.URL+tt.wantFinalPath {
+				t.Errorf("final URL = %s, want %s", page.FinalURL, server.URL+tt.wantFinalPath)
+			}
+		})
+	}
+}
+
+func TestFetchContentType(t *testing.T) {
+	server := newTestServer(t, "")
+	page, err := New(Options{}).Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if page.ContentType != "text/html" || page.Charset != "iso-8859-1" {
+		t.Errorf("content type = %q charset %q, want text/html and iso-8859-1", page.ContentType, page.Charset)
+	}
+}
+
+func TestFetchAllPerDomainLimit(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, r.URL.Path)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	urls := make([]string, 8)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/%d", server.URL, i)
+	}
+	pages := New(Options{Workers: 8, PerDomain: 2}).FetchAll(context.Background(), urls)
+
+	for i, page := range pages {
+		if page.Err != nil {
+			t.Fatalf("page %d: %v", i, page.Err)
+		}
+		if want := fmt.Sprintf("/%d", i); string(page.Body) != want {
+			t.Errorf("page %d body = %q, want %q", i, page.Body, want)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("%d requests ran at once against one domain, want at most 2", peak)
+	}
+}
+
+func TestFetchAllCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pages := New(Options{}).FetchAll(ctx, []string{"http://example.invalid/a", "http://example.invalid/b"})
+	for i, page := range pages {
+		if !errors.Is(page.Err, context.Canceled) {
+			t.Errorf("page %d error = %v, want context.Canceled", i, page.Err)
+		}
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	const userAgent = "DeepResearchBot/1.0"
+	robots := `
+User-agent: *
+Disallow: /
+
+User-agent: deepresearchbot
+Disallow: /private
+Allow: /private/public
+Disallow: /*.pdf$
+`
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "/", want: true},
+		{path: "/articles/go", want: true},
+		{path: "/private", want: false},
+		{path: "/private/notes", want: false},
+		{path: "/private/public/notes", want: true},
+		{path: "/papers/paper.pdf", want: false},
+		{path: "/papers/paper.pdf?download=1", want: true},
+	}
+
+	rules := parseRobots(strings.NewReader(robots), userAgent)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := rules.allows(tt.path); got != tt.want {
+				t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if parseRobots(strings.NewReader(robots), "OtherBot/2.0").allows("/articles/go") {
+		t.Error("other agents should fall back to the wildcard group")
+	}
+}
@@ -0,0 +1,208 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// robotsTTL is how long a host's robots.txt stays cached
+const robotsTTL = time.Hour
+
+// robotsRules holds the rules of the robots.txt group that applies to us
+type robotsRules struct {
+	allowAll    bool
+	disallowAll bool
+	rules       []robotsRule
+	fetchedAt   time.Time
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsCache fetches and caches robots.txt per host
+type robotsCache struct {
+	client    *http.Client
+	userAgent string
+
+	mu    sync.Mutex
+	hosts map[string]*robotsRules
+}
+
+func newRobotsCache(client *http.Client, userAgent string) *robotsCache {
+	return &robotsCache{
+		client:    client,
+		userAgent: userAgent,
+		hosts:     make(map[string]*robotsRules),
+	}
+}
+
+// allowed reports whether the URL may be crawled
+func (r *robotsCache) allowed(ctx context.Context, target *url.URL) bool {
+	key := target.Scheme + "://" + strings.ToLower(target.Host)
+
+	r.mu.Lock()
+	rules, ok := r.hosts[key]
+	r.mu.Unlock()
+
+	if !ok || time.Since(rules.fetchedAt) > robotsTTL {
+		rules = r.fetch(ctx, key)
+		r.mu.Lock()
+		r.hosts[key] = rules
+		r.mu.Unlock()
+	}
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	return rules.allows(path)
+}
+
+// fetch downloads and parses robots.txt for a scheme://host origin.
+// Following the robots.txt spec, a missing file allows everything and
+// a server error disallows everything until the cache expires.
+func (r *robotsCache) fetch(ctx context.Context, origin string) *robotsRules {
+	now := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, origin+"/robots.txt", nil)
+	if err != nil {
+		return &robotsRules{disallowAll: true, fetchedAt: now}
+	}
+	req.Header.Set("User-Agent", r.userAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return &robotsRules{disallowAll: true, fetchedAt: now}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true, fetchedAt: now}
+	case resp.StatusCode >= 400:
+		return &robotsRules{allowAll: true, fetchedAt: now}
+	}
+
+	rules := parseRobots(io.LimitReader(resp.Body, 512*1024), r.userAgent)
+	rules.fetchedAt = now
+	return rules
+}
+
+// parseRobots returns the rules of the most specific group matching the user agent
+func parseRobots(body io.Reader, userAgent string) *robotsRules {
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i > 0 {
+		agent = agent[:i]
+	}
+
+	var specific, wildcard []robotsRule
+	var foundSpecific bool
+	var groupAgents []string
+	inRules := false
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if key == "disallow" && value == "" {
+				continue // An empty Disallow allows everything
+			}
+			rule := robotsRule{allow: key == "allow", pattern: value}
+			for _, groupAgent := range groupAgents {
+				switch {
+				case groupAgent == "*":
+					wildcard = append(wildcard, rule)
+				case agent != "" && strings.Contains(agent, groupAgent):
+					specific = append(specific, rule)
+					foundSpecific = true
+				}
+			}
+		}
+	}
+
+	if foundSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// allows applies the longest matching rule; Allow wins ties
+func (r *robotsRules) allows(path string) bool {
+	if r.allowAll {
+		return true
+	}
+	if r.disallowAll {
+		return false
+	}
+
+	best := -1
+	allowed := true
+	for _, rule := range r.rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		length := len(rule.pattern)
+		if length > best || (length == best && rule.allow) {
+			best = length
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// matchRobotsPattern matches a path against a robots.txt pattern supporting * and $
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for _, part := range parts[1:] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+
+	if anchored {
+		if len(parts) > 1 && parts[len(parts)-1] != "" {
+			return strings.HasSuffix(path, parts[len(parts)-1])
+		}
+		return pos == len(path)
+	}
+	return true
+}
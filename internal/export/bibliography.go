@@ -0,0 +1,378 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Bibliography formats
+const (
+	FormatBibTeX  = "bibtex"
+	FormatRIS     = "ris"
+	FormatCSLJSON = "csl-json"
+)
+
+// BibliographyFormats lists every bibliography format
+var BibliographyFormats = []string{FormatBibTeX, FormatRIS, FormatCSLJSON}
+
+// ErrUnknownBibliographyFormat is returned for a format not in BibliographyFormats
+var ErrUnknownBibliographyFormat = errors.New("format must be one of bibtex, ris, csl-json")
+
+// Source types, as stored in models.Source.Type
+const (
+	sourceAcademicPaper = "academic_paper"
+	sourceNewsArticle   = "news_article"
+	sourcePDF           = "pdf"
+)
+
+// keyStopWords are skipped when a citation key takes the first word of a title
+var keyStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "on": true, "of": true, "in": true,
+	"for": true, "and": true, "to": true, "with": true, "how": true, "what": true,
+}
+
+// RenderBibliography renders the sources in the given format
+func RenderBibliography(format string, sources []Source) ([]byte, error) {
+	switch format {
+	case FormatBibTeX:
+		return BibTeX(sources), nil
+	case FormatRIS:
+		return RIS(sources), nil
+	case FormatCSLJSON:
+		return CSLJSON(sources)
+	default:
+		return nil, ErrUnknownBibliographyFormat
+	}
+}
+
+// BibliographyContentType returns the media type of a bibliography format
+func BibliographyContentType(format string) string {
+	switch format {
+	case FormatBibTeX:
+		return "application/x-bibtex; charset=utf-8"
+	case FormatRIS:
+		return "application/x-research-info-systems; charset=utf-8"
+	case FormatCSLJSON:
+		return "application/vnd.citationstyles.csl+json; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// BibliographyExtension returns the file extension of a bibliography format
+func BibliographyExtension(format string) string {
+	switch format {
+	case FormatBibTeX:
+		return "bib"
+	case FormatRIS:
+		return "ris"
+	default:
+		return "json"
+	}
+}
+
+// CitationKeys returns a unique key for every source, built from the first
+// author's family name, the year and the first significant word of the title,
+// such as "smith2021solid". Without an author the site name stands in, and
+// repeated keys get a letter suffix.
+func CitationKeys(sources []Source) []string {
+	keys := make([]string, len(sources))
+	used := make(map[string]bool)
+	for i, source := range sources {
+		base := citationKey(source)
+		key := base
+		for n := 1; used[key]; n++ {
+			if n < 26 {
+				key = base + string(rune('a'+n))
+			} else {
+				key = fmt.Sprintf("%s%d", base, n+1)
+			}
+		}
+		used[key] = true
+		keys[i] = key
+	}
+	return keys
+}
+
+func citationKey(source Source) string {
+	var key string
+	if len(source.Authors) > 0 {
+		key = keyWord(parseName(source.Authors[0]).family())
+	}
+	if key == "" {
+		key = keyWord(siteName(source.Domain))
+	}
+	if key == "" {
+		key = "source"
+	}
+	if source.PublishedAt != nil {
+		key += fmt.Sprint(source.PublishedAt.UTC().Year())
+	}
+	for _, word := range strings.Fields(source.Title) {
+		if word = keyWord(word); word != "" && !keyStopWords[word] {
+			key += word
+			break
+		}
+	}
+	return key
+}
+
+// keyWord lowercases text and keeps only its ASCII letters and digits,
+// stripping accents first so that "Müller" becomes "muller"
+func keyWord(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// siteName returns the name part of a domain, such as "nature" for www.nature.com
+func siteName(domain string) string {
+	labels := strings.Split(strings.TrimPrefix(strings.ToLower(domain), "www."), ".")
+	if len(labels) >= 2 {
+		return labels[len(labels)-2]
+	}
+	return labels[0]
+}
+
+// personName is an author name split for bibliography formats. Names that
+// cannot be split, such as organisations, are kept whole in literal.
+type personName struct {
+	given, familyName, literal string
+}
+
+// parseName splits "Family, Given" and "Given Family" names. Single words and
+// long names are likely organisations and kept whole.
+func parseName(name string) personName {
+	name = oneLine(name)
+	if family, given, ok := strings.Cut(name, ","); ok {
+		return personName{familyName: strings.TrimSpace(family), given: strings.TrimSpace(given)}
+	}
+	words := strings.Fields(name)
+	if len(words) < 2 || len(words) > 4 {
+		return personName{literal: name}
+	}
+	return personName{familyName: words[len(words)-1], given: strings.Join(words[:len(words)-1], " ")}
+}
+
+func (n personName) family() string {
+	if n.literal != "" {
+		return n.literal
+	}
+	return n.familyName
+}
+
+// inverted returns "Family, Given", the form BibTeX and RIS expect
+func (n personName) inverted() string {
+	if n.literal != "" {
+		return n.literal
+	}
+	if n.given == "" {
+		return n.familyName
+	}
+	return n.familyName + ", " + n.given
+}
+
+// bibtexEscapes protects the characters BibTeX and LaTeX treat specially
+var bibtexEscapes = strings.NewReplacer(
+	`\`, `\textbackslash{}`, "{", `\{`, "}", `\}`, "&", `\&`, "%", `\%`,
+	"$", `\$`, "#", `\#`, "_", `\_`, "~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+// BibTeX renders the sources as BibTeX entries. Web pages are @misc entries
+// with their address in url and the date they were read in urldate.
+func BibTeX(sources []Source) []byte {
+	var b strings.Builder
+	for i, key := range CitationKeys(sources) {
+		source := sources[i]
+		entryType := "misc"
+		if source.Journal != "" && (source.Type == sourceAcademicPaper || source.Type == sourceNewsArticle) {
+			entryType = "article"
+		}
+
+		var fields [][2]string
+		if source.Title != "" {
+			fields = append(fields, [2]string{"title", "{" + bibtexEscapes.Replace(oneLine(source.Title)) + "}"})
+		}
+		if len(source.Authors) > 0 {
+			names := make([]string, len(source.Authors))
+			for j, author := range source.Authors {
+				name := parseName(author)
+				names[j] = bibtexEscapes.Replace(name.inverted())
+				if name.literal != "" {
+					// Braces stop BibTeX from splitting an organisation into given and family names
+					names[j] = "{" + names[j] + "}"
+				}
+			}
+			fields = append(fields, [2]string{"author", "{" + strings.Join(names, " and ") + "}"})
+		}
+		if source.PublishedAt != nil {
+			published := source.PublishedAt.UTC()
+			fields = append(fields, [2]string{"year", fmt.Sprint(published.Year())})
+			fields = append(fields, [2]string{"month", strings.ToLower(published.Month().String()[:3])})
+		}
+		if source.Journal != "" {
+			field := "howpublished"
+			if entryType == "article" {
+				field = "journal"
+			}
+			fields = append(fields, [2]string{field, "{" + bibtexEscapes.Replace(oneLine(source.Journal)) + "}"})
+		}
+		if source.Publisher != "" {
+			fields = append(fields, [2]string{"publisher", "{" + bibtexEscapes.Replace(oneLine(source.Publisher)) + "}"})
+		}
+		if source.DOI != "" {
+			fields = append(fields, [2]string{"doi", "{" + bibtexURL(source.DOI) + "}"})
+		}
+		if source.URL != "" {
+			fields = append(fields, [2]string{"url", "{" + bibtexURL(source.URL) + "}"})
+		}
+		if date := accessed(source); date != "" {
+			fields = append(fields, [2]string{"urldate", "{" + date + "}"})
+		}
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "@%s{%s", entryType, key)
+		for _, field := range fields {
+			fmt.Fprintf(&b, ",\n  %s = %s", field[0], field[1])
+		}
+		b.WriteString("\n}\n")
+	}
+	return []byte(b.String())
+}
+
+// bibtexURL escapes only what would break a braced BibTeX value, since the
+// url package typesets the rest verbatim
+func bibtexURL(value string) string {
+	return strings.NewReplacer("{", "%7B", "}", "%7D", " ", "%20").Replace(value)
+}
+
+// RIS renders the sources as RIS records
+func RIS(sources []Source) []byte {
+	var b strings.Builder
+	line := func(tag, value string) {
+		if value = oneLine(value); value != "" {
+			fmt.Fprintf(&b, "%s  - %s\r\n", tag, value)
+		}
+	}
+
+	for i, key := range CitationKeys(sources) {
+		source := sources[i]
+		recordType := "ELEC"
+		switch source.Type {
+		case sourceAcademicPaper:
+			recordType = "JOUR"
+		case sourceNewsArticle:
+			recordType = "NEWS"
+		case sourcePDF:
+			recordType = "GEN"
+		}
+
+		line("TY", recordType)
+		line("ID", key)
+		line("TI", source.Title)
+		for _, author := range source.Authors {
+			line("AU", parseName(author).inverted())
+		}
+		if source.PublishedAt != nil {
+			published := source.PublishedAt.UTC()
+			line("PY", fmt.Sprint(published.Year()))
+			line("DA", published.Format("2006/01/02"))
+		}
+		line("T2", source.Journal)
+		line("PB", source.Publisher)
+		line("DO", source.DOI)
+		line("UR", source.URL)
+		if source.AccessedAt != nil {
+			line("Y2", source.AccessedAt.UTC().Format("2006/01/02"))
+		}
+		b.WriteString("ER  - \r\n")
+	}
+	return []byte(b.String())
+}
+
+// cslItem is a CSL-JSON bibliography item
+type cslItem struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title,omitempty"`
+	Author         []cslName `json:"author,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	DOI            string    `json:"DOI,omitempty"`
+	URL            string    `json:"URL,omitempty"`
+	Accessed       *cslDate  `json:"accessed,omitempty"`
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+func newCSLDate(t *time.Time) *cslDate {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &cslDate{DateParts: [][]int{{utc.Year(), int(utc.Month()), utc.Day()}}}
+}
+
+// CSLJSON renders the sources as a CSL-JSON array, the format citation
+// processors and reference managers such as Zotero read natively
+func CSLJSON(sources []Source) ([]byte, error) {
+	items := make([]cslItem, len(sources))
+	for i, key := range CitationKeys(sources) {
+		source := sources[i]
+		item := cslItem{
+			ID:             key,
+			Type:           "webpage",
+			Title:          oneLine(source.Title),
+			Issued:         newCSLDate(source.PublishedAt),
+			ContainerTitle: oneLine(source.Journal),
+			Publisher:      oneLine(source.Publisher),
+			DOI:            source.DOI,
+			URL:            source.URL,
+			Accessed:       newCSLDate(source.AccessedAt),
+		}
+		switch source.Type {
+		case sourceAcademicPaper:
+			item.Type = "article-journal"
+		case sourceNewsArticle:
+			item.Type = "article-newspaper"
+		case sourcePDF:
+			item.Type = "document"
+		}
+		for _, author := range source.Authors {
+			name := parseName(author)
+			item.Author = append(item.Author, cslName{Family: name.familyName, Given: name.given, Literal: name.literal})
+		}
+		items[i] = item
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(items); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
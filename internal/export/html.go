@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markerPattern matches inline citation markers such as [1] and [1, 3]
+var markerPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"paragraphs":  paragraphs,
+	"cited":       citedHTML,
+	"accessed":    accessed,
+	"attribution": attribution,
+	"container":   container,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; max-width: 46em; margin: 2em auto; padding: 0 1em; line-height: 1.5; color: #222; }
+h1, h2, h3 { line-height: 1.2; }
+.query { color: #555; }
+ol.bibliography li { margin-bottom: 0.4em; word-break: break-word; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="query"><strong>Query:</strong> {{.Query}}</p>
+{{- if not .CreatedAt.IsZero}}
+<p class="query"><strong>Date:</strong> {{.CreatedAt.UTC.Format "2006-01-02"}}</p>
+{{- end}}
+{{- if .Summaries}}
+<h2>Summaries</h2>
+{{- range .Summaries}}
+<section>
+<h3>{{.Title}}</h3>
+{{- range paragraphs .Content}}
+<p>{{cited .}}</p>
+{{- end}}
+{{- if .KeyPoints}}
+<p>Key points:</p>
+<ul>
+{{- range .KeyPoints}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+{{- end}}
+{{- with paragraphs .Answer}}
+<h2>Answer</h2>
+{{- range .}}
+<p>{{cited .}}</p>
+{{- end}}
+{{- end}}
+{{- if .Sources}}
+<h2>Bibliography</h2>
+<ol class="bibliography">
+{{- range .Sources}}
+<li id="ref-{{.Number}}" value="{{.Number}}">
+{{- with attribution .}}{{.}}. {{end}}
+{{- if .URL}}<a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}{{.URL}}{{end}}</a>{{else}}{{.Title}}{{end}}
+{{- with container .}}. {{.}}{{end}}
+{{- with .DOI}}. doi:{{.}}{{end}}
+{{- with accessed .}}. Accessed {{.}}{{end}}.</li>
+{{- end}}
+</ol>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTML renders the report as a standalone HTML page whose citation markers
+// link to their bibliography entries
+func HTML(report *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// citedHTML escapes a paragraph, keeps its line breaks and links its citation markers
+func citedHTML(text string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, match := range markerPattern.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:match[0]]))
+		var links []string
+		for _, number := range strings.Split(text[match[2]:match[3]], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil {
+				continue
+			}
+			links = append(links, fmt.Sprintf(`<a href="#ref-%d">%d</a>`, n, n))
+		}
+		b.WriteString("[" + strings.Join(links, ", ") + "]")
+		last = match[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(strings.ReplaceAll(b.String(), "\n", "<br>\n"))
+}
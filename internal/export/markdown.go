@@ -0,0 +1,81 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the report as a Markdown document
+func Markdown(report *Report) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", oneLine(report.Title))
+	fmt.Fprintf(&b, "**Query:** %s\n\n", oneLine(report.Query))
+	if !report.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "**Date:** %s\n\n", report.CreatedAt.UTC().Format("2006-01-02"))
+	}
+
+	if len(report.Summaries) > 0 {
+		b.WriteString("## Summaries\n\n")
+		for _, summary := range report.Summaries {
+			fmt.Fprintf(&b, "### %s\n\n", oneLine(summary.Title))
+			for _, paragraph := range paragraphs(summary.Content) {
+				b.WriteString(paragraph + "\n\n")
+			}
+			if len(summary.KeyPoints) > 0 {
+				b.WriteString("Key points:\n\n")
+				for _, point := range summary.KeyPoints {
+					fmt.Fprintf(&b, "- %s\n", oneLine(point))
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	if answer := paragraphs(report.Answer); len(answer) > 0 {
+		b.WriteString("## Answer\n\n")
+		for _, paragraph := range answer {
+			b.WriteString(paragraph + "\n\n")
+		}
+	}
+
+	if len(report.Sources) > 0 {
+		b.WriteString("## Bibliography\n\n")
+		for _, source := range report.Sources {
+			fmt.Fprintf(&b, "%d. %s\n", source.Number, markdownReference(source))
+		}
+		b.WriteString("\n")
+	}
+
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
+
+// markdownReference formats a bibliography entry with a link to the source
+func markdownReference(source Source) string {
+	title := oneLine(source.Title)
+	if title == "" {
+		title = source.URL
+	}
+	entry := title
+	if source.URL != "" {
+		entry = fmt.Sprintf("[%s](<%s>)", strings.NewReplacer("[", "\\[", "]", "\\]").Replace(title), source.URL)
+	}
+	if by := attribution(source); by != "" {
+		entry = by + ". " + entry
+	}
+	if name := container(source); name != "" {
+		entry += ". " + name
+	}
+	if source.DOI != "" {
+		entry += ". doi:" + source.DOI
+	}
+	if date := accessed(source); date != "" {
+		entry += ". Accessed " + date
+	}
+	return entry + "."
+}
+
+// oneLine collapses whitespace so text fits on a single line
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,277 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Page layout in points, for A4 paper
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 56.0
+	pdfLineFactor = 1.4 // Line height as a multiple of the font size
+)
+
+// PDF fonts; both are standard fonts every reader provides, so nothing is embedded
+const (
+	pdfRegular = "F1"
+	pdfBold    = "F2"
+)
+
+// pdfWidths are the advance widths, in thousandths of the font size, of the
+// printable ASCII characters of Helvetica and Helvetica-Bold
+var pdfWidths = map[string][95]int{
+	pdfRegular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	pdfBold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// pdfWinAnsi maps the characters outside Latin-1 that WinAnsiEncoding can show
+var pdfWinAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// PDF renders the report as a PDF document. Text the standard fonts cannot show
+// is replaced with question marks.
+func PDF(report *Report) []byte {
+	w := &pdfWriter{}
+	w.newPage()
+
+	w.paragraph(pdfBold, 18, report.Title, 0)
+	w.space(6)
+	w.paragraph(pdfRegular, 11, "Query: "+oneLine(report.Query), 0)
+	if !report.CreatedAt.IsZero() {
+		w.paragraph(pdfRegular, 11, "Date: "+report.CreatedAt.UTC().Format("2006-01-02"), 0)
+	}
+
+	if len(report.Summaries) > 0 {
+		w.heading("Summaries")
+		for _, summary := range report.Summaries {
+			w.space(8)
+			w.paragraph(pdfBold, 12, summary.Title, 0)
+			w.space(4)
+			w.text(summary.Content)
+			if len(summary.KeyPoints) > 0 {
+				w.paragraph(pdfRegular, 11, "Key points:", 0)
+				for _, point := range summary.KeyPoints {
+					w.paragraph(pdfRegular, 11, "• "+oneLine(point), 12)
+				}
+			}
+		}
+	}
+
+	if strings.TrimSpace(report.Answer) != "" {
+		w.heading("Answer")
+		w.text(report.Answer)
+	}
+
+	if len(report.Sources) > 0 {
+		w.heading("Bibliography")
+		for _, source := range report.Sources {
+			parts := []string{attribution(source), oneLine(source.Title), container(source)}
+			if source.DOI != "" {
+				parts = append(parts, "doi:"+source.DOI)
+			}
+			parts = append(parts, source.URL)
+			if date := accessed(source); date != "" {
+				parts = append(parts, "Accessed "+date)
+			}
+			var fields []string
+			for _, part := range parts {
+				if part != "" {
+					fields = append(fields, part)
+				}
+			}
+			entry := strings.Join(fields, ". ")
+			w.paragraph(pdfRegular, 10, fmt.Sprintf("[%d] %s.", source.Number, entry), 0)
+			w.space(2)
+		}
+	}
+
+	return w.document()
+}
+
+// pdfWriter lays out text top to bottom over as many pages as it needs
+type pdfWriter struct {
+	pages []*bytes.Buffer // Content stream of every page
+	y     float64         // Baseline of the next line on the current page
+}
+
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.y = pdfPageHeight - pdfMargin
+}
+
+// space adds vertical space, which does not carry over to a new page
+func (w *pdfWriter) space(points float64) {
+	w.y -= points
+}
+
+// heading starts a section
+func (w *pdfWriter) heading(title string) {
+	w.space(12)
+	w.paragraph(pdfBold, 14, title, 0)
+	w.space(4)
+}
+
+// text writes every paragraph of text, keeping its line breaks
+func (w *pdfWriter) text(text string) {
+	for _, paragraph := range paragraphs(text) {
+		for _, line := range strings.Split(paragraph, "\n") {
+			indent := float64(len(line)-len(strings.TrimLeft(line, " \t"))) * 3
+			w.paragraph(pdfRegular, 11, line, indent)
+		}
+		w.space(6)
+	}
+}
+
+// paragraph wraps text to the page width, indented by indent points
+func (w *pdfWriter) paragraph(font string, size float64, text string, indent float64) {
+	for _, line := range wrapPDF(font, size, oneLine(text), pdfPageWidth-2*pdfMargin-indent) {
+		leading := size * pdfLineFactor
+		if w.y-leading < pdfMargin {
+			w.newPage()
+		}
+		w.y -= leading
+		fmt.Fprintf(w.pages[len(w.pages)-1], "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+			font, pdfNumber(size), pdfNumber(pdfMargin+indent), pdfNumber(w.y), pdfString(line))
+	}
+}
+
+// document assembles the pages into a PDF file
+func (w *pdfWriter) document() []byte {
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objects = append(objects, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range w.pages {
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pdfNumber(pdfPageWidth), pdfNumber(pdfPageHeight), pdfRegular, pdfBold, 6+2*i))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// wrapPDF breaks text into lines no wider than width, splitting words that are
+// wider than a line on their own, such as long URLs
+func wrapPDF(font string, size float64, text string, width float64) []string {
+	if text == "" {
+		return []string{""}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if pdfTextWidth(font, size, candidate) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for pdfTextWidth(font, size, word) > width {
+			_, cut := utf8.DecodeRuneInString(word)
+			for cut < len(word) {
+				_, n := utf8.DecodeRuneInString(word[cut:])
+				if pdfTextWidth(font, size, word[:cut+n]) > width {
+					break
+				}
+				cut += n
+			}
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+		line = word
+	}
+	return append(lines, line)
+}
+
+// pdfTextWidth measures text in points
+func pdfTextWidth(font string, size float64, text string) float64 {
+	widths := pdfWidths[font]
+	total := 0
+	for _, r := range text {
+		if r >= 32 && r < 127 {
+			total += widths[r-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// pdfString encodes text as a WinAnsi PDF string literal body
+func pdfString(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteByte(' ')
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			if code, ok := pdfWinAnsi[r]; ok {
+				fmt.Fprintf(&b, "\\%03o", code)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// pdfNumber formats a coordinate without trailing zeros
+func pdfNumber(value float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+}
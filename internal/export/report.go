@@ -0,0 +1,159 @@
+// Package export renders research sessions as shareable reports.
+// Rendering is a pure function of the Report, so equal reports give byte-identical output.
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report formats
+const (
+	FormatMarkdown = "md"
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+	FormatJSON     = "json"
+)
+
+// Formats lists every report format
+var Formats = []string{FormatMarkdown, FormatHTML, FormatPDF, FormatJSON}
+
+// ErrUnknownFormat is returned for a format not in Formats
+var ErrUnknownFormat = errors.New("format must be one of md, html, pdf, json")
+
+// Report is everything an export shows of a research session
+type Report struct {
+	Title     string    `json:"title"`
+	Query     string    `json:"query"`
+	CreatedAt time.Time `json:"created_at"`
+	Summaries []Summary `json:"summaries"`
+	Answer    string    `json:"answer"`  // Citation markers [n] refer to Sources[n-1]
+	Sources   []Source  `json:"sources"` // The bibliography
+}
+
+// Summary is one summary of the session
+type Summary struct {
+	Type      string   `json:"type"`
+	Title     string   `json:"title"`
+	Content   string   `json:"content"`
+	KeyPoints []string `json:"key_points"`
+}
+
+// Source is a bibliography entry
+type Source struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Domain      string     `json:"domain"`
+	Type        string     `json:"type"`
+	Authors     []string   `json:"authors,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	DOI         string     `json:"doi,omitempty"`
+	Publisher   string     `json:"publisher,omitempty"`
+	Journal     string     `json:"journal,omitempty"`
+	AccessedAt  *time.Time `json:"accessed_at,omitempty"`
+}
+
+// Render renders the report in the given format
+func Render(format string, report *Report) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return Markdown(report), nil
+	case FormatHTML:
+		return HTML(report)
+	case FormatPDF:
+		return PDF(report), nil
+	case FormatJSON:
+		return JSON(report)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatPDF:
+		return "application/pdf"
+	case FormatJSON:
+		return "application/json; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// JSON renders the report as indented JSON
+func JSON(report *Report) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// accessed formats the date a source was read, or returns an empty string
+func accessed(source Source) string {
+	if source.AccessedAt == nil {
+		return ""
+	}
+	return source.AccessedAt.UTC().Format("2006-01-02")
+}
+
+// attribution names the authors of a source with its year, such as
+// "Smith, Jane; Lee, Sam (2021)", or returns an empty string when neither is known
+func attribution(source Source) string {
+	text := oneLine(strings.Join(source.Authors, "; "))
+	if len(source.Authors) > 3 {
+		text = oneLine(source.Authors[0]) + " et al."
+	}
+	if source.PublishedAt != nil {
+		year := fmt.Sprintf("(%d)", source.PublishedAt.UTC().Year())
+		if text == "" {
+			return year
+		}
+		text += " " + year
+	}
+	return text
+}
+
+// container names where a source appeared: its journal, publisher or domain
+func container(source Source) string {
+	for _, name := range []string{source.Journal, source.Publisher, source.Domain} {
+		if name = oneLine(name); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// paragraphs splits text at blank lines, trimming trailing space from every line
+func paragraphs(text string) []string {
+	var result, current []string
+	flush := func() {
+		if len(current) > 0 {
+			result = append(result, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return result
+}
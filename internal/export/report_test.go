@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testReport exercises citations, summaries, markup that needs escaping,
+// non-ASCII text and a URL too long for one PDF line
+func testReport() *Report {
+	created := time.Date(2025, 6, 7, 1, 11, 28, 0, time.UTC)
+	published := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	accessed := time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC)
+	return &Report{
+		Title:     "Quantum error correction <today>",
+		Query:     "How do surface codes protect qubits?\nAnd at what cost?",
+		CreatedAt: created,
+		Summaries: []Summary{
+			{
+				Type:      "overview",
+				Title:     "Overview",
+				Content:   "Surface codes spread one logical qubit over many physical qubits [1].",
+				KeyPoints: []string{"Thresholds near 1% [2]", "Overhead grows with distance & fidelity"},
+			},
+		},
+		Answer: "Surface codes tolerate error rates near 1% [1][2].\n\nThey need many physical qubits per logical qubit — often hundreds [2].",
+		Sources: []Source{
+			{
+				Number:      1,
+				Title:       "Surface codes: Towards practical large-scale quantum computation",
+				URL:         "https://arxiv.org/abs/1208.0928",
+				Domain:      "arxiv.org",
+				Type:        sourceAcademicPaper,
+				Authors:     []string{"Austin G. Fowler", "Matteo Mariantoni", "John M. Martinis", "Andrew N. Cleland"},
+				PublishedAt: &published,
+				DOI:         "10.1103/PhysRevA.86.032324",
+				Journal:     "Physical Review A",
+				AccessedAt:  &accessed,
+			},
+			{
+				Number:     2,
+				Title:      "Qubit overhead — a survey",
+				URL:        "https://example.com/a/very/long/path/that/does/not/fit/on/a/single/line/of/the/pdf/bibliography/entry?with=query&and=more",
+				Domain:     "example.com",
+				Type:       "web",
+				Publisher:  "Example Press",
+				AccessedAt: &accessed,
+			},
+		},
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			got, err := Render(format, testReport())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			checkGolden(t, "report."+format, got)
+
+			again, err := Render(format, testReport())
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Error("rendering the same report twice gave different output")
+			}
+		})
+	}
+}
+
+func TestRenderBibliographyGolden(t *testing.T) {
+	for _, format := range BibliographyFormats {
+		t.Run(format, func(t *testing.T) {
+			got, err := RenderBibliography(format, testReport().Sources)
+			if err != nil {
+				t.Fatalf("RenderBibliography: %v", err)
+			}
+			checkGolden(t, "bibliography."+BibliographyExtension(format), got)
+		})
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	if _, err := Render("docx", testReport()); err != ErrUnknownFormat {
+		t.Errorf("Render error = %v, want ErrUnknownFormat", err)
+	}
+}
+
+// checkGolden compares output with testdata/name, rewriting the file with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run go test -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file; run go test ./internal/export -update and review the diff\ngot:\n%s", name, got)
+	}
+}
@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Content types stored in models.Document.ContentType
+const (
+	ContentTypeHTML      = "html"
+	ContentTypePDF       = "pdf"
+	ContentTypeMarkdown  = "markdown"
+	ContentTypePlainText = "plain_text"
+)
+
+// Result is the readable text extracted from a downloaded resource
+type Result struct {
+	Title       string
+	Text        string
+	ContentType string
+	Language    string
+	WordCount   int
+	Pages       []string // Per-page text, for paginated formats such as PDF
+	Abstract    string
+	Metadata    Metadata
+}
+
+// ErrUnsupported is returned for media types that cannot be turned into text
+type ErrUnsupported struct {
+	MediaType string
+}
+
+func (e *ErrUnsupported) Error() string {
+	return fmt.Sprintf("unsupported content type %q", e.MediaType)
+}
+
+// Extract turns a response body into readable text based on its media type
+func Extract(mediaType, charsetLabel string, body []byte) (*Result, error) {
+	var result *Result
+	var err error
+
+	// Servers often send PDFs as a generic binary type
+	if bytes.HasPrefix(body, []byte("%PDF-")) {
+		mediaType = "application/pdf"
+	}
+
+	switch mediaType {
+	case "application/pdf", "application/x-pdf":
+		result, err = PDF(body)
+	case "text/html", "application/xhtml+xml":
+		var reader io.Reader
+		reader, err = charset.NewReader(bytes.NewReader(body), "text/html; charset="+charsetLabel)
+		if err != nil {
+			return nil, err
+		}
+		result, err = HTML(reader)
+	case "text/plain", "":
+		result = &Result{Text: decodeText(body, charsetLabel), ContentType: ContentTypePlainText}
+	case "text/markdown", "text/x-markdown":
+		result = &Result{Text: decodeText(body, charsetLabel), ContentType: ContentTypeMarkdown}
+	default:
+		return nil, &ErrUnsupported{MediaType: mediaType}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Text = strings.TrimSpace(result.Text)
+	if result.Text == "" {
+		return nil, fmt.Errorf("no readable text found")
+	}
+	result.WordCount = WordCount(result.Text)
+	if result.Language == "" {
+		result.Language = DetectLanguage(result.Text)
+	}
+	return result, nil
+}
+
+// decodeText converts a body in the given charset to UTF-8
+func decodeText(body []byte, charsetLabel string) string {
+	if charsetLabel == "" {
+		return string(body)
+	}
+	reader, err := charset.NewReaderLabel(charsetLabel, bytes.NewReader(body))
+	if err != nil {
+		return string(body)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return string(body)
+	}
+	return string(decoded)
+}
@@ -0,0 +1,253 @@
+package extract
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// boilerplateTags never contain article text
+var boilerplateTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Nav: true,
+	atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Form: true,
+	atom.Iframe: true, atom.Svg: true, atom.Button: true, atom.Select: true,
+	atom.Template: true, atom.Object: true, atom.Embed: true,
+}
+
+// blockTags end a line of text
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Main: true,
+	atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Tr: true, atom.Table: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Br: true, atom.Blockquote: true, atom.Pre: true, atom.Dd: true, atom.Dt: true,
+	atom.Figcaption: true, atom.Hr: true,
+}
+
+// boilerplatePattern matches class and id values of navigation, ads and comment widgets
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[\s_-])(nav|navbar|menu|footer|sidebar|comment|comments|advert|ads?|sponsor|banner|cookie|consent|share|social|related|breadcrumbs?|popup|modal|subscribe|newsletter|promo)($|[\s_-])`)
+
+var whitespacePattern = regexp.MustCompile(`[ \t\f\r\v]+`)
+
+// HTML extracts the main readable text of an HTML page, stripping navigation,
+// ads and other boilerplate
+func HTML(r io.Reader) (*Result, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{ContentType: ContentTypeHTML}
+	var body *html.Node
+	var ogTitle string
+	meta := make(map[string][]string)
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Html:
+				if lang := attr(n, "lang"); lang != "" {
+					result.Language = strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+				}
+			case atom.Title:
+				if result.Title == "" {
+					result.Title = strings.TrimSpace(textOf(n))
+				}
+			case atom.Meta:
+				if attr(n, "property") == "og:title" {
+					ogTitle = strings.TrimSpace(attr(n, "content"))
+				}
+				name := attr(n, "name")
+				if name == "" {
+					name = attr(n, "property")
+				}
+				if name == "" {
+					name = attr(n, "itemprop")
+				}
+				if name != "" {
+					key := strings.ToLower(name)
+					meta[key] = append(meta[key], attr(n, "content"))
+				}
+			case atom.Body:
+				body = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if ogTitle != "" {
+		result.Title = ogTitle
+	}
+	result.Metadata = htmlMetadata(meta)
+	if body == nil {
+		body = doc
+	}
+
+	removeBoilerplate(body)
+	root := mainContent(body)
+
+	var text strings.Builder
+	renderText(root, &text)
+	result.Text = cleanLines(text.String())
+
+	if result.Title == "" {
+		if h1 := findFirst(body, atom.H1); h1 != nil {
+			result.Title = strings.TrimSpace(textOf(h1))
+		}
+	}
+	return result, nil
+}
+
+// removeBoilerplate detaches elements that are not part of the article
+func removeBoilerplate(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isBoilerplate(c)) {
+			n.RemoveChild(c)
+		} else {
+			removeBoilerplate(c)
+		}
+		c = next
+	}
+}
+
+func isBoilerplate(n *html.Node) bool {
+	if boilerplateTags[n.DataAtom] {
+		return true
+	}
+	if hasAttr(n, "hidden") || strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	switch strings.ToLower(attr(n, "role")) {
+	case "navigation", "banner", "contentinfo", "complementary", "dialog":
+		return true
+	}
+	// Never drop the containers that hold the article itself
+	if n.DataAtom == atom.Article || n.DataAtom == atom.Main || n.DataAtom == atom.Body {
+		return false
+	}
+	return boilerplatePattern.MatchString(attr(n, "class")) || boilerplatePattern.MatchString(attr(n, "id"))
+}
+
+// mainContent picks the element that holds the article text.
+// It prefers semantic containers and otherwise scores each element by the
+// paragraph text of its direct children.
+func mainContent(body *html.Node) *html.Node {
+	if n := findFirst(body, atom.Article); n != nil && len(textOf(n)) > 200 {
+		return n
+	}
+	if n := findFirst(body, atom.Main); n != nil && len(textOf(n)) > 200 {
+		return n
+	}
+
+	best, bestScore := body, 0
+	var score func(n *html.Node)
+	score = func(n *html.Node) {
+		total := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.P || c.DataAtom == atom.Pre || c.DataAtom == atom.Blockquote) {
+				text := textOf(c)
+				if len(text) > 25 {
+					total += len(text) + 10*strings.Count(text, ",")
+				}
+			}
+		}
+		if total > bestScore {
+			best, bestScore = n, total
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode {
+				score(c)
+			}
+		}
+	}
+	score(body)
+
+	// A single paragraph is too little to trust; fall back to the whole body
+	if bestScore < 200 {
+		return body
+	}
+	return best
+}
+
+// renderText writes the text of n, breaking lines at block elements
+func renderText(n *html.Node, w *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		w.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if blockTags[n.DataAtom] {
+			w.WriteString("\n")
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderText(c, w)
+	}
+	if n.Type == html.ElementNode && blockTags[n.DataAtom] {
+		w.WriteString("\n")
+	}
+}
+
+// cleanLines collapses whitespace and drops empty lines
+func cleanLines(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(whitespacePattern.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package extract
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Metadata is the bibliographic information a document declares about itself
+type Metadata struct {
+	Authors     []string // As written, e.g. "Smith, Jane" or "Jane Smith"
+	PublishedAt *time.Time
+	DOI         string // Bare DOI such as 10.1000/182, without a resolver prefix
+	Publisher   string // Publisher or site name
+	Journal     string // Journal, proceedings or other container title
+}
+
+// Meta tag names in order of preference. Highwire Press (citation_*) tags are
+// what scholarly publishers and Zotero use, so they come first.
+var (
+	authorMeta    = []string{"citation_author", "citation_authors", "dc.creator", "dcterms.creator", "author", "article:author", "parsely-author", "sailthru.author"}
+	dateMeta      = []string{"citation_publication_date", "citation_date", "citation_online_date", "dc.date.issued", "dcterms.issued", "dc.date", "dcterms.date", "article:published_time", "datepublished", "pubdate", "date"}
+	doiMeta       = []string{"citation_doi", "prism.doi", "bepress_citation_doi", "dc.identifier", "dcterms.identifier"}
+	publisherMeta = []string{"citation_publisher", "dc.publisher", "dcterms.publisher", "og:site_name", "application-name"}
+	journalMeta   = []string{"citation_journal_title", "citation_conference_title", "prism.publicationname", "citation_inbook_title"}
+)
+
+// doiPattern matches a DOI in free text or a resolver URL
+var doiPattern = regexp.MustCompile(`\b10\.\d{4,9}/[-._;()/:<>A-Za-z0-9]+`)
+
+// dateLayouts are the date formats documents commonly declare
+var dateLayouts = []string{
+	time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02",
+	"2006/01/02", "2006-01", "2006/01", "2006", "January 2, 2006", "Jan 2, 2006", "2 January 2006",
+}
+
+// htmlMetadata picks bibliographic metadata out of a page's meta tags, keyed by
+// lowercase name or property
+func htmlMetadata(meta map[string][]string) Metadata {
+	var result Metadata
+	for _, name := range authorMeta {
+		for _, value := range meta[name] {
+			// Some sites put a profile link rather than a name in article:author
+			if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+				continue
+			}
+			result.Authors = append(result.Authors, splitAuthors(value)...)
+		}
+		if len(result.Authors) > 0 {
+			break
+		}
+	}
+	for _, name := range dateMeta {
+		if result.PublishedAt = ParseDate(first(meta[name])); result.PublishedAt != nil {
+			break
+		}
+	}
+	for _, name := range doiMeta {
+		if result.DOI = FindDOI(first(meta[name])); result.DOI != "" {
+			break
+		}
+	}
+	for _, name := range publisherMeta {
+		if result.Publisher = first(meta[name]); result.Publisher != "" {
+			break
+		}
+	}
+	for _, name := range journalMeta {
+		if result.Journal = first(meta[name]); result.Journal != "" {
+			break
+		}
+	}
+	return result
+}
+
+// splitAuthors splits a list of names on semicolons and "and". Commas only
+// separate names when every part has a space, since "Smith, Jane" is one name.
+func splitAuthors(value string) []string {
+	value = strings.ReplaceAll(value, " and ", ";")
+	if !strings.Contains(value, ";") && strings.Contains(value, ",") {
+		separated := true
+		for _, part := range strings.Split(value, ",") {
+			if len(strings.Fields(part)) < 2 {
+				separated = false
+			}
+		}
+		if separated {
+			value = strings.ReplaceAll(value, ",", ";")
+		}
+	}
+
+	var authors []string
+	for _, name := range strings.Split(value, ";") {
+		name = strings.Join(strings.Fields(name), " ")
+		if name != "" {
+			authors = append(authors, name)
+		}
+	}
+	return authors
+}
+
+// ParseDate parses the date formats documents commonly declare, returning nil
+// when value is empty or unrecognised
+func ParseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// parsePDFDate parses an info dictionary date such as D:20230115093000+01'00'
+func parsePDFDate(value string) *time.Time {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "D:")
+	if len(value) < 8 {
+		return nil
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// FindDOI returns the first DOI in text, without trailing punctuation
+func FindDOI(text string) string {
+	doi := doiPattern.FindString(text)
+	return strings.TrimRight(doi, ".,;:)>")
+}
+
+func first(values []string) string {
+	for _, value := range values {
+		if value = strings.Join(strings.Fields(value), " "); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,236 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PageSeparator separates pages in the text extracted from a PDF
+const PageSeparator = "\f"
+
+// maxAbstractChars bounds the abstract when no closing heading is found
+const maxAbstractChars = 3000
+
+var ligatures = strings.NewReplacer("ﬀ", "ff", "ﬁ", "fi", "ﬂ", "fl", "ﬃ", "ffi", "ﬄ", "ffl", "­", "")
+
+// abstractHeading matches the line that starts an abstract, with optional inline text
+// after punctuation or starting with a capital letter ("Abstract. We ..." or "Abstract We ...")
+var abstractHeading = regexp.MustCompile(`^\s*(?i:abstract|summary)(?:\s*[.:—–-]\s*(.*)|\s+(\p{Lu}.*))?\s*$`)
+
+// abstractEnd matches the headings that usually follow an abstract
+var abstractEnd = regexp.MustCompile(`(?i)^\s*((1|I)\.?\s+)?(introduction|keywords|key words|index terms|background|contents)\b`)
+
+// hyphenatedBreak matches a word hyphenated across a line break
+var hyphenatedBreak = regexp.MustCompile(`(\p{L})-\n(\p{Ll})`)
+
+// PDF extracts the text of a PDF document page by page.
+// Pages are separated by PageSeparator; the title comes from the document
+// info dictionary or, failing that, the largest text on the first page.
+func PDF(data []byte) (*Result, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pdf: %w", err)
+	}
+
+	numPages := reader.NumPage()
+	if numPages == 0 {
+		return nil, errors.New("pdf has no pages")
+	}
+
+	result := &Result{ContentType: ContentTypePDF}
+	var firstPage []pdfLine
+	for i := 1; i <= numPages; i++ {
+		lines := pageLines(reader.Page(i))
+		if i == 1 {
+			firstPage = lines
+		}
+
+		text := make([]string, len(lines))
+		for j, line := range lines {
+			text[j] = line.text
+		}
+		result.Pages = append(result.Pages, cleanPDFText(strings.Join(text, "\n")))
+	}
+
+	result.Text = strings.Join(result.Pages, PageSeparator)
+	result.Title = pdfTitle(reader, firstPage)
+	result.Abstract = findAbstract(result.Pages)
+	result.Metadata = pdfMetadata(reader, result.Pages)
+	return result, nil
+}
+
+// pdfLine is a line of text with the largest font size used in it
+type pdfLine struct {
+	text     string
+	fontSize float64
+}
+
+// pageLines rebuilds the lines of a page from its positioned glyphs.
+// Malformed pages that make the parser panic yield no lines.
+func pageLines(page pdf.Page) (lines []pdfLine) {
+	defer func() {
+		if recover() != nil {
+			lines = nil
+		}
+	}()
+
+	if page.V.IsNull() {
+		return nil
+	}
+
+	var current strings.Builder
+	var fontSize, lastY, lastEnd float64
+	started := false
+
+	flush := func() {
+		text := strings.TrimSpace(whitespacePattern.ReplaceAllString(current.String(), " "))
+		if text != "" {
+			lines = append(lines, pdfLine{text: text, fontSize: fontSize})
+		}
+		current.Reset()
+		fontSize = 0
+	}
+
+	for _, glyph := range page.Content().Text {
+		if glyph.S == "\n" || glyph.S == "" {
+			continue
+		}
+
+		size := math.Abs(glyph.FontSize)
+		if size == 0 {
+			size = 10
+		}
+
+		if started && math.Abs(glyph.Y-lastY) > size*0.5 {
+			flush()
+		} else if started && lastEnd > 0 && glyph.X-lastEnd > size*0.2 {
+			// A visible gap between glyphs on the same line is a word break
+			current.WriteString(" ")
+		}
+
+		current.WriteString(glyph.S)
+		if size > fontSize {
+			fontSize = size
+		}
+		lastY = glyph.Y
+		lastEnd = 0
+		if glyph.W > 0 {
+			lastEnd = glyph.X + glyph.W
+		}
+		started = true
+	}
+	flush()
+	return lines
+}
+
+// cleanPDFText repairs ligatures and words hyphenated across line breaks
+func cleanPDFText(text string) string {
+	text = ligatures.Replace(text)
+	text = hyphenatedBreak.ReplaceAllString(text, "$1$2")
+	return strings.TrimSpace(text)
+}
+
+// pdfTitle returns the info dictionary title unless it looks auto-generated,
+// otherwise the text set in the largest font near the top of the first page
+func pdfTitle(reader *pdf.Reader, firstPage []pdfLine) (title string) {
+	defer func() {
+		if recover() != nil {
+			title = titleFromLines(firstPage)
+		}
+	}()
+
+	info := strings.TrimSpace(reader.Trailer().Key("Info").Key("Title").Text())
+	lower := strings.ToLower(info)
+	if info != "" && !strings.HasSuffix(lower, ".pdf") && !strings.HasSuffix(lower, ".dvi") &&
+		!strings.HasSuffix(lower, ".doc") && !strings.HasSuffix(lower, ".docx") &&
+		!strings.HasPrefix(lower, "microsoft word") && lower != "untitled" {
+		return info
+	}
+	return titleFromLines(firstPage)
+}
+
+// pdfMetadata reads the authors and date from the info dictionary and takes
+// the first DOI printed on the first page
+func pdfMetadata(reader *pdf.Reader, pages []string) (result Metadata) {
+	if len(pages) > 0 {
+		result.DOI = FindDOI(pages[0])
+	}
+
+	defer func() {
+		if recover() != nil {
+			result.Authors, result.PublishedAt = nil, nil
+		}
+	}()
+	info := reader.Trailer().Key("Info")
+	result.Authors = splitAuthors(info.Key("Author").Text())
+	result.PublishedAt = parsePDFDate(info.Key("CreationDate").Text())
+	return result
+}
+
+func titleFromLines(lines []pdfLine) string {
+	// Titles sit near the top, so only the first lines are considered
+	if len(lines) > 15 {
+		lines = lines[:15]
+	}
+
+	var largest float64
+	for _, line := range lines {
+		if line.fontSize > largest && len([]rune(line.text)) > 3 {
+			largest = line.fontSize
+		}
+	}
+	if largest == 0 {
+		return ""
+	}
+
+	// Join consecutive lines set in the largest font, e.g. a two-line title
+	var parts []string
+	for _, line := range lines {
+		if math.Abs(line.fontSize-largest) < 0.5 {
+			parts = append(parts, line.text)
+		} else if len(parts) > 0 {
+			break
+		}
+	}
+	return cleanPDFText(strings.Join(parts, " "))
+}
+
+// findAbstract returns the abstract found on the first two pages, if any
+func findAbstract(pages []string) string {
+	if len(pages) > 2 {
+		pages = pages[:2]
+	}
+	lines := strings.Split(strings.Join(pages, "\n"), "\n")
+
+	for i, line := range lines {
+		match := abstractHeading.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		var parts []string
+		if inline := strings.TrimSpace(match[1] + match[2]); inline != "" {
+			parts = append(parts, inline)
+		}
+		length := 0
+		for _, next := range lines[i+1:] {
+			if abstractEnd.MatchString(next) || length > maxAbstractChars {
+				break
+			}
+			parts = append(parts, next)
+			length += len(next)
+		}
+
+		abstract := strings.TrimSpace(strings.Join(parts, " "))
+		if len(abstract) > 50 {
+			return abstract
+		}
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package extract
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testdata/paper.pdf is a two-page paper set in Helvetica: an 18pt title over
+// two lines, an abstract, a word hyphenated across a line break and, in its
+// info dictionary, the auto-generated title "main.dvi", two authors and a
+// creation date.
+
+func TestPDF(t *testing.T) {
+	data, err := os.ReadFile("testdata/paper.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Extract("application/octet-stream", "", data)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if result.ContentType != ContentTypePDF {
+		t.Errorf("content type = %q, want %q", result.ContentType, ContentTypePDF)
+	}
+
+	if len(result.Pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(result.Pages))
+	}
+	if !strings.HasPrefix(result.Pages[0], "Grounded Answers for\n") || result.Pages[1] != "2 Method\nEach claim is checked against the passage it cites." {
+		t.Errorf("pages = %q", result.Pages)
+	}
+	if result.Text != strings.Join(result.Pages, PageSeparator) {
+		t.Errorf("text is not the pages joined by PageSeparator: %q", result.Text)
+	}
+	if !strings.Contains(result.Pages[0], "agents must read and extraction of the relevant") {
+		t.Errorf("hyphenated word was not joined: %q", result.Pages[0])
+	}
+
+	// The info title looks generated, so the largest text on the first page wins
+	if want := "Grounded Answers for Deep Research Agents"; result.Title != want {
+		t.Errorf("title = %q, want %q", result.Title, want)
+	}
+	if want := "We study how research agents cite the sources they read. Answers that quote retrieved passages are preferred by readers."; result.Abstract != want {
+		t.Errorf("abstract = %q, want %q", result.Abstract, want)
+	}
+	if result.WordCount != 67 || result.Language != "en" {
+		t.Errorf("word count = %d, language = %q", result.WordCount, result.Language)
+	}
+
+	if want := []string{"Ada Lovelace", "Alan Turing"}; !reflect.DeepEqual(result.Metadata.Authors, want) {
+		t.Errorf("authors = %q, want %q", result.Metadata.Authors, want)
+	}
+	if published := result.Metadata.PublishedAt; published == nil || !published.Equal(time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("published at = %v, want 2024-03-15", published)
+	}
+	if result.Metadata.DOI != "10.1234/deepresearch.2024.001" {
+		t.Errorf("DOI = %q", result.Metadata.DOI)
+	}
+}
+
+func TestPDFInvalid(t *testing.T) {
+	if _, err := PDF([]byte("%PDF-1.4\nnot really a pdf")); err == nil {
+		t.Error("PDF accepted a truncated document")
+	}
+}
+
+func TestFindAbstract(t *testing.T) {
+	const body = "Research agents read many sources and must decide which passages to cite in their reports."
+	tests := []struct {
+		name  string
+		pages []string
+		want  string
+	}{
+		{name: "heading on its own line", pages: []string{"Title\nAbstract\n" + body + "\n1. Introduction\nMore text"}, want: body},
+		{name: "inline after punctuation", pages: []string{"Title\nAbstract. " + body + "\nKeywords: agents"}, want: body},
+		{name: "inline after a capital letter", pages: []string{"Title\nAbstract " + body + "\nI. Introduction"}, want: body},
+		{name: "on the second page", pages: []string{"Title page", "Summary:\n" + body + "\nBackground"}, want: body},
+		{name: "on the third page", pages: []string{"Title", "Contents", "Abstract\n" + body}},
+		{name: "too short", pages: []string{"Abstract\nToo short.\nIntroduction"}},
+		{name: "no heading", pages: []string{body}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findAbstract(tt.pages); got != tt.want {
+				t.Errorf("findAbstract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"one two  three", 3},
+		{"line\nbreaks\fand\tpages", 4},
+		{"dashes — and • bullets - are not words", 6},
+		{"Go 1.24 was released in 2025.", 6},
+	}
+
+	for _, tt := range tests {
+		if got := WordCount(tt.text); got != tt.want {
+			t.Errorf("WordCount(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
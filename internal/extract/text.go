@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordCount counts whitespace-separated words that contain a letter or digit
+func WordCount(text string) int {
+	count := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r)
+		}) >= 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// languageStopWords holds frequent function words used to guess a text's language
+var languageStopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "with", "as", "are", "this"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "von", "zu", "ein", "auf"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "dans", "que", "pour", "pas", "sur"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "en", "una", "por", "para", "con"},
+	"it": {"il", "di", "che", "e", "la", "per", "una", "sono", "non", "gli", "con", "del"},
+	"pt": {"o", "os", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "niet", "met", "zijn", "voor"},
+}
+
+// DetectLanguage guesses the ISO 639-1 code of a text from its stop words, defaulting to "en"
+func DetectLanguage(text string) string {
+	// A few thousand words are plenty to decide
+	words := strings.Fields(strings.ToLower(text))
+	if len(words) > 5000 {
+		words = words[:5000]
+	}
+
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) })]++
+	}
+
+	best, bestScore := "en", 0
+	for _, lang := range []string{"en", "de", "fr", "es", "it", "pt", "nl"} {
+		score := 0
+		for _, stopWord := range languageStopWords[lang] {
+			score += counts[stopWord]
+		}
+		if score > bestScore {
+			best, bestScore = lang, score
+		}
+	}
+	return best
+}
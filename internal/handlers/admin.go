@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// AdminHandlers holds the admin service dependency
+type AdminHandlers struct {
+	adminService *services.AdminService
+}
+
+// NewAdminHandlers creates new admin handlers
+func NewAdminHandlers(adminService *services.AdminService) *AdminHandlers {
+	return &AdminHandlers{
+		adminService: adminService,
+	}
+}
+
+// @Summary List users
+// @Description Get a paginated list of every user. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (max 100)" default(20)
+// @Param role query string false "Filter by role" Enums(user, admin)
+// @Param q query string false "Search email and name"
+// @Success 200 {object} models.AdminUsersListResponse "List of users"
+// @Failure 400 {object} models.ErrorResponse "Invalid role"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Router /admin/users [get]
+func (h *AdminHandlers) ListUsers(c *gin.Context) {
+	page, perPage := pagination(c, 20)
+
+	users, total, err := h.adminService.ListUsers(page, perPage, c.Query("role"), c.Query("q"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if err.Error() == "invalid role" {
+			code = http.StatusBadRequest
+		}
+
+		c.JSON(code, models.ErrorResponse{
+			Error:   "Failed to fetch users",
+			Code:    code,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.AdminUsersListResponse{
+		Users:      make([]models.AdminUserResponse, len(users)),
+		Total:      int(total),
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	for i := range users {
+		response.Users[i] = toAdminUserResponse(&users[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Disable a user
+// @Description Stop a user from signing in and revoke all of their tokens. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} models.AdminUserResponse "Disabled user"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Failure 409 {object} models.ErrorResponse "Last enabled admin"
+// @Router /admin/users/{id}/disable [post]
+func (h *AdminHandlers) DisableUser(c *gin.Context) {
+	h.setDisabled(c, true)
+}
+
+// @Summary Enable a user
+// @Description Let a disabled user sign in again. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} models.AdminUserResponse "Enabled user"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Router /admin/users/{id}/enable [post]
+func (h *AdminHandlers) EnableUser(c *gin.Context) {
+	h.setDisabled(c, false)
+}
+
+func (h *AdminHandlers) setDisabled(c *gin.Context, disabled bool) {
+	actorID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	user, err := h.adminService.SetDisabled(actorID, c.Param("id"), disabled)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			status = http.StatusNotFound
+		case "invalid user ID", "cannot disable your own account":
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, services.ErrLastAdmin) {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to update user",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAdminUserResponse(user))
+}
+
+// @Summary Change a user's role
+// @Description Promote a user to admin or demote an admin to user. Requires the admin role.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Param request body models.UpdateRoleRequest true "New role"
+// @Success 200 {object} models.AdminUserResponse "Updated user"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Failure 409 {object} models.ErrorResponse "Last enabled admin"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandlers) UpdateUserRole(c *gin.Context) {
+	actorID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.adminService.SetRole(actorID, c.Param("id"), req.Role)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "user not found":
+			status = http.StatusNotFound
+		case "invalid user ID", "invalid role", "cannot change your own role":
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, services.ErrLastAdmin) {
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to update user",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAdminUserResponse(user))
+}
+
+// @Summary List all sessions
+// @Description Get a paginated list of the research sessions of every user. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (max 100)" default(20)
+// @Param status query string false "Filter by status" Enums(pending, running, completed, failed, cancelled)
+// @Param user_id query string false "Filter by owner"
+// @Success 200 {object} models.SessionsListResponse "List of sessions"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Router /admin/sessions [get]
+func (h *AdminHandlers) ListSessions(c *gin.Context) {
+	page, perPage := pagination(c, 20)
+
+	sessions, total, err := h.adminService.ListSessions(page, perPage, c.Query("status"), c.Query("user_id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidSessionStatus) || err.Error() == "invalid user ID" {
+			code = http.StatusBadRequest
+		}
+
+		c.JSON(code, models.ErrorResponse{
+			Error:   "Failed to fetch sessions",
+			Code:    code,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.SessionsListResponse{
+		Sessions:   make([]models.SessionResponse, len(sessions)),
+		Total:      int(total),
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	for i, session := range sessions {
+		response.Sessions[i] = models.SessionResponse{
+			ID:           session.ID.String(),
+			UserID:       session.UserID.String(),
+			Title:        session.Title,
+			Query:        session.Query,
+			Status:       string(session.Status),
+			MessageCount: session.MessageCount,
+			CreatedAt:    session.CreatedAt,
+			UpdatedAt:    session.UpdatedAt,
+			StartedAt:    session.StartedAt,
+			FinishedAt:   session.FinishedAt,
+			Tags:         parseTags(session.Tags),
+			MaxSources:   session.MaxSources,
+			SearchDepth:  string(session.SearchDepth),
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary List audit events
+// @Description Get a paginated list of audit events, such as sign-in attempts, newest first. Requires the admin role.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param per_page query int false "Items per page (max 100)" default(50)
+// @Param action query string false "Filter by action" Enums(login.succeeded, login.failed, login.locked)
+// @Param user_id query string false "Filter by user"
+// @Param email query string false "Filter by the email given"
+// @Success 200 {object} models.AuditEventsListResponse "List of audit events"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Not an admin"
+// @Router /admin/audit-events [get]
+func (h *AdminHandlers) ListAuditEvents(c *gin.Context) {
+	page, perPage := pagination(c, 50)
+
+	events, total, err := h.adminService.ListAuditEvents(page, perPage, c.Query("action"), c.Query("user_id"), c.Query("email"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch err.Error() {
+		case "invalid action", "invalid user ID":
+			code = http.StatusBadRequest
+		}
+
+		c.JSON(code, models.ErrorResponse{
+			Error:   "Failed to fetch audit events",
+			Code:    code,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.AuditEventsListResponse{
+		Events:     make([]models.AuditEventResponse, len(events)),
+		Total:      int(total),
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: int((total + int64(perPage) - 1) / int64(perPage)),
+	}
+	for i, event := range events {
+		response.Events[i] = models.AuditEventResponse{
+			ID:        event.ID.String(),
+			Action:    string(event.Action),
+			Method:    event.Method,
+			Email:     event.Email,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			Detail:    event.Detail,
+			CreatedAt: event.CreatedAt,
+		}
+		if event.UserID != nil {
+			userID := event.UserID.String()
+			response.Events[i].UserID = &userID
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// pagination reads page and per_page, clamping per_page to 1-100
+func pagination(c *gin.Context, defaultPerPage int) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", strconv.Itoa(defaultPerPage)))
+	if err != nil || perPage < 1 {
+		perPage = defaultPerPage
+	}
+	if perPage > 100 {
+		perPage = 100
+	}
+	return page, perPage
+}
+
+// toAdminUserResponse converts a user to an admin API response
+func toAdminUserResponse(user *models.User) models.AdminUserResponse {
+	return models.AdminUserResponse{
+		ID:              user.ID.String(),
+		Email:           user.Email,
+		Name:            user.Name,
+		Role:            string(user.Role()),
+		IsEmailVerified: user.IsEmailVerified,
+		IsDisabled:      user.IsDisabled,
+		DisabledAt:      user.DisabledAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// APIKeyHandlers holds the API key service dependency
+type APIKeyHandlers struct {
+	apiKeyService *services.APIKeyService
+}
+
+// NewAPIKeyHandlers creates new API key handlers
+func NewAPIKeyHandlers(apiKeyService *services.APIKeyService) *APIKeyHandlers {
+	return &APIKeyHandlers{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// @Summary Create API key
+// @Description Create a personal API key for scripts and notebooks. The key is returned once and cannot be shown again;
+// @Description send it as "Authorization: ApiKey <key>". The read scope allows GET requests, write every other request
+// @Description and admin the admin routes. API keys cannot manage API keys.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body models.CreateAPIKeyRequest true "Key details"
+// @Success 201 {object} models.APIKeyCreatedResponse "Created key"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Admin scope requires the admin role"
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandlers) CreateAPIKey(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	apiKey, key, err := h.apiKeyService.Create(user, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "name is required", "invalid scope", "expiry must be in the future", "too many API keys":
+			status = http.StatusBadRequest
+		case "admin scope requires the admin role":
+			status = http.StatusForbidden
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to create API key",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIKeyCreatedResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            key,
+	})
+}
+
+// @Summary List API keys
+// @Description Get every API key of the current user, newest first, including revoked and expired keys
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.APIKeysListResponse "API keys"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandlers) ListAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	apiKeys, err := h.apiKeyService.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch API keys",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.APIKeysListResponse{APIKeys: make([]models.APIKeyResponse, len(apiKeys))}
+	for i := range apiKeys {
+		response.APIKeys[i] = toAPIKeyResponse(&apiKeys[i])
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Get API key
+// @Description Get one API key of the current user
+// @Tags api-keys
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} models.APIKeyResponse "API key"
+// @Failure 400 {object} models.ErrorResponse "Invalid API key ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Router /auth/api-keys/{id} [get]
+func (h *APIKeyHandlers) GetAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	apiKey, err := h.apiKeyService.Get(c.Param("id"), userID)
+	if err != nil {
+		status := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to fetch API key",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAPIKeyResponse(apiKey))
+}
+
+// @Summary Update API key
+// @Description Rename an API key or replace its scopes. Revoked keys cannot be changed.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Param request body models.UpdateAPIKeyRequest true "Key updates"
+// @Success 200 {object} models.APIKeyResponse "Updated key"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Admin scope requires the admin role"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 409 {object} models.ErrorResponse "API key revoked"
+// @Router /auth/api-keys/{id} [put]
+func (h *APIKeyHandlers) UpdateAPIKey(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	var req models.UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	apiKey, err := h.apiKeyService.Update(c.Param("id"), user, req.Name, req.Scopes)
+	if err != nil {
+		status := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to update API key",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAPIKeyResponse(apiKey))
+}
+
+// @Summary Revoke API key
+// @Description Permanently disable an API key. It stays in the list with its revocation time.
+// @Tags api-keys
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 204 "API key revoked"
+// @Failure 400 {object} models.ErrorResponse "Invalid API key ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Router /auth/api-keys/{id} [delete]
+func (h *APIKeyHandlers) RevokeAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(c.Param("id"), userID); err != nil {
+		status := apiKeyErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to revoke API key",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// apiKeyErrorStatus maps API key service errors to HTTP status codes
+func apiKeyErrorStatus(err error) int {
+	if errors.Is(err, services.ErrAPIKeyRevoked) {
+		return http.StatusConflict
+	}
+	switch err.Error() {
+	case "API key not found":
+		return http.StatusNotFound
+	case "invalid API key ID", "invalid user ID", "invalid scope":
+		return http.StatusBadRequest
+	case "admin scope requires the admin role":
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// toAPIKeyResponse converts an API key to an API response
+func toAPIKeyResponse(apiKey *models.APIKey) models.APIKeyResponse {
+	scopes := make([]string, 0)
+	for _, scope := range apiKey.ScopeList() {
+		scopes = append(scopes, string(scope))
+	}
+	return models.APIKeyResponse{
+		ID:         apiKey.ID.String(),
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     scopes,
+		LastUsedAt: apiKey.LastUsedAt,
+		ExpiresAt:  apiKey.ExpiresAt,
+		RevokedAt:  apiKey.RevokedAt,
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
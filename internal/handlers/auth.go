@@ -1,28 +1,39 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
 	"github.com/lolzone13/DeepResearch/internal/models"
 	"github.com/lolzone13/DeepResearch/internal/services"
 )
 
-// AuthHandlers holds the auth service dependency
+// AuthHandlers holds the auth service dependencies
 type AuthHandlers struct {
-	authService *services.AuthService
+	authService    *services.AuthService
+	accountService *services.AccountService
+	loginGuard     *services.LoginGuard
 }
 
 // NewAuthHandlers creates new auth handlers
-func NewAuthHandlers(authService *services.AuthService) *AuthHandlers {
+func NewAuthHandlers(authService *services.AuthService, accountService *services.AccountService, loginGuard *services.LoginGuard) *AuthHandlers {
 	return &AuthHandlers{
-		authService: authService,
+		authService:    authService,
+		accountService: accountService,
+		loginGuard:     loginGuard,
 	}
 }
 
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with email and password. Repeated failures lock out the email and the client IP
+// @Description for exponentially longer periods; locked out attempts get 429 with a Retry-After header.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -30,6 +41,10 @@ func NewAuthHandlers(authService *services.AuthService) *AuthHandlers {
 // @Success 200 {object} models.AuthResponse "Successful login"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 401 {object} models.ErrorResponse "Invalid credentials"
+// @Header 401 {integer} Retry-After "Seconds until the next attempt is allowed, when this failure started a lockout"
+// @Failure 403 {object} models.ErrorResponse "Account disabled"
+// @Failure 429 {object} models.ErrorResponse "Too many failed attempts"
+// @Header 429 {integer} Retry-After "Seconds until the next attempt is allowed"
 // @Router /auth/login [post]
 func (h *AuthHandlers) Login(c *gin.Context) {
 	var req models.AuthRequest
@@ -42,34 +57,54 @@ func (h *AuthHandlers) Login(c *gin.Context) {
 		return
 	}
 
+	// Reject attempts while the email or the client IP is locked out
+	attempt := &services.LoginAttempt{
+		Email:     req.Email,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if wait := h.loginGuard.Reserve(c.Request.Context(), attempt); wait > 0 {
+		seconds := setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Error:   "Too many failed attempts",
+			Code:    http.StatusTooManyRequests,
+			Message: fmt.Sprintf("Try again in %d seconds", seconds),
+		})
+		return
+	}
+
 	// Authenticate user
-	user, token, err := h.authService.Login(req.Email, req.Password)
+	user, tokens, err := h.authService.Login(req.Email, req.Password)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+		status := http.StatusUnauthorized
+		if errors.Is(err, services.ErrAccountDisabled) {
+			status = http.StatusForbidden
+		}
+		if lockout := h.loginGuard.Failed(c.Request.Context(), attempt, err); lockout > 0 {
+			setRetryAfter(c, lockout)
+		}
+
+		c.JSON(status, models.ErrorResponse{
 			Error:   "Authentication failed",
-			Code:    401,
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
 	}
+	h.loginGuard.Succeeded(c.Request.Context(), attempt, user)
 
-	// Return success response
-	response := models.AuthResponse{
-		Token: token,
-		User: models.UserInfo{
-			ID:    user.ID.String(),
-			Email: user.Email,
-			Name:  user.Name,
-			Role:  "user",
-		},
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-	}
+	c.JSON(http.StatusOK, toAuthResponse(user, tokens))
+}
 
-	c.JSON(http.StatusOK, response)
+// setRetryAfter tells the client how many whole seconds to wait and returns them
+func setRetryAfter(c *gin.Context, wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	return seconds
 }
 
 // @Summary User registration
-// @Description Register a new user account
+// @Description Register a new user account. A link to verify the email address is sent when email is configured.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -115,8 +150,8 @@ func (h *AuthHandlers) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate token for new user
-	token, err := h.authService.GenerateToken(user)
+	// Generate tokens for new user
+	tokens, err := h.authService.IssueTokens(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Token generation failed",
@@ -126,17 +161,321 @@ func (h *AuthHandlers) Register(c *gin.Context) {
 		return
 	}
 
-	// Return success response
-	response := models.AuthResponse{
-		Token: token,
-		User: models.UserInfo{
-			ID:    user.ID.String(),
-			Email: user.Email,
-			Name:  user.Name,
-			Role:  "user",
-		},
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+	// Registration succeeds even if the email cannot be sent; it can be resent later
+	if err := h.accountService.SendVerification(c.Request.Context(), user); err != nil && !errors.Is(err, services.ErrMailerNotConfigured) {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, toAuthResponse(user, tokens))
+}
+
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token works once; presenting a used one again revokes every token issued since the login it came from.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.AuthResponse "New token pair"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 403 {object} models.ErrorResponse "Account disabled"
+// @Router /auth/refresh [post]
+func (h *AuthHandlers) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenExpired) ||
+			errors.Is(err, services.ErrRefreshTokenReused) {
+			status = http.StatusUnauthorized
+		}
+		if errors.Is(err, services.ErrAccountDisabled) {
+			status = http.StatusForbidden
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Token refresh failed",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuthResponse(user, tokens))
+}
+
+// @Summary Log out
+// @Description Revoke the access token of the request and, when given, the refresh token issued with it
+// @Tags auth
+// @Accept json
+// @Security ApiKeyAuth
+// @Param request body models.LogoutRequest false "Refresh token to revoke"
+// @Success 204 "Logged out"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /auth/logout [post]
+func (h *AuthHandlers) Logout(c *gin.Context) {
+	claims, exists := middleware.GetTokenClaimsFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "Token not found in context",
+		})
+		return
+	}
+
+	// The body is optional
+	var req models.LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Code:    400,
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.authService.Logout(claims, req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Log out of all devices
+// @Description Revoke every access token and refresh token of the current user. API keys keep working;
+// @Description revoke them with DELETE /auth/api-keys/{id}.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Success 204 "Logged out everywhere"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /auth/logout-all [post]
+func (h *AuthHandlers) LogoutAll(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	if err := h.authService.LogoutAll(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Logout failed",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Verify email address
+// @Description Confirm an email address with the token from the verification email. Each token works once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.UserInfo "Verified user"
+// @Failure 400 {object} models.ErrorResponse "Invalid, expired or used token"
+// @Router /auth/verify-email [post]
+func (h *AuthHandlers) VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, err := h.accountService.VerifyEmail(req.Token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidActionToken) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Email verification failed",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserInfo(user))
+}
+
+// @Summary Resend verification email
+// @Description Send a new email verification link to the current user
+// @Tags auth
+// @Security ApiKeyAuth
+// @Success 202 "Email sent"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 409 {object} models.ErrorResponse "Email already verified"
+// @Failure 503 {object} models.ErrorResponse "Email is not configured"
+// @Router /auth/verify-email/resend [post]
+func (h *AuthHandlers) ResendVerification(c *gin.Context) {
+	user, exists := middleware.GetUserFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	if err := h.accountService.SendVerification(c.Request.Context(), user); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrEmailAlreadyVerified):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrMailerNotConfigured):
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to send verification email",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// @Summary Forgot password
+// @Description Email a password reset link to the account with this address. The response is the same whether or not the address is registered.
+// @Tags auth
+// @Accept json
+// @Param request body models.ForgotPasswordRequest true "Account email"
+// @Success 202 "Reset email sent if the account exists"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 429 {object} models.ErrorResponse "Too many reset requests for the email or from the client IP"
+// @Header 429 {integer} Retry-After "Seconds until the next request is allowed"
+// @Failure 503 {object} models.ErrorResponse "Email is not configured"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandlers) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	wait, err := h.accountService.RequestPasswordReset(c.Request.Context(), req.Email, c.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrMailerNotConfigured) {
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to send reset email",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+	if wait > 0 {
+		seconds := setRetryAfter(c, wait)
+		c.JSON(http.StatusTooManyRequests, models.ErrorResponse{
+			Error:   "Too many reset requests",
+			Code:    http.StatusTooManyRequests,
+			Message: fmt.Sprintf("Try again in %d seconds", seconds),
+		})
+		return
 	}
 
-	c.JSON(http.StatusCreated, response)
+	c.Status(http.StatusAccepted)
+}
+
+// @Summary Reset password
+// @Description Set a new password with the token from the reset email. Each token works once. Every existing
+// @Description session of the account is signed out and every API key of the account is revoked.
+// @Tags auth
+// @Accept json
+// @Param request body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 204 "Password changed"
+// @Failure 400 {object} models.ErrorResponse "Invalid, expired or used token"
+// @Failure 403 {object} models.ErrorResponse "Account disabled"
+// @Router /auth/password/reset [post]
+func (h *AuthHandlers) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	if err := h.accountService.ResetPassword(req.Token, req.Password); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidActionToken):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrAccountDisabled):
+			status = http.StatusForbidden
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Password reset failed",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// toUserInfo converts a user to its public API representation
+func toUserInfo(user *models.User) models.UserInfo {
+	return models.UserInfo{
+		ID:            user.ID.String(),
+		Email:         user.Email,
+		Name:          user.Name,
+		Role:          string(user.Role()),
+		EmailVerified: user.IsEmailVerified,
+	}
+}
+
+// toAuthResponse converts a user and their tokens to an API response
+func toAuthResponse(user *models.User, tokens *services.TokenPair) models.AuthResponse {
+	return models.AuthResponse{
+		Token:            tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		User:             toUserInfo(user),
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
 }
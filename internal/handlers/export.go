@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/export"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// ExportHandlers holds the export service dependency
+type ExportHandlers struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandlers creates new export handlers
+func NewExportHandlers(exportService *services.ExportService) *ExportHandlers {
+	return &ExportHandlers{
+		exportService: exportService,
+	}
+}
+
+// @Summary Export a session
+// @Description Download a report of a research session with its title, query, summaries, final answer with numbered citations and a bibliography of its sources
+// @Tags research
+// @Produce text/markdown,text/html,application/pdf,json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param format query string false "Report format" Enums(md, html, pdf, json) default(md)
+// @Success 200 {file} file "Report as an attachment"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /research/sessions/{id}/export [get]
+func (h *ExportHandlers) ExportSession(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatMarkdown)
+	if !validFormat(format, export.Formats) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: export.ErrUnknownFormat.Error(),
+		})
+		return
+	}
+
+	report, err := h.exportService.BuildReport(c.Param("id"), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID":
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to export session",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	data, err := export.Render(format, report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to export session",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, exportFilename(report.Title), format))
+	c.Data(http.StatusOK, export.ContentType(format), data)
+}
+
+// @Summary Export the bibliography
+// @Description Download every source of a research session as BibTeX, RIS or CSL-JSON for import into a reference manager such as Zotero. Authors, publication date, DOI and journal are included where the source declared them.
+// @Tags research
+// @Produce application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param format query string false "Bibliography format" Enums(bibtex, ris, csl-json) default(bibtex)
+// @Success 200 {file} file "Bibliography as an attachment"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /research/sessions/{id}/bibliography [get]
+func (h *ExportHandlers) ExportBibliography(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	format := c.DefaultQuery("format", export.FormatBibTeX)
+	if !validFormat(format, export.BibliographyFormats) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: export.ErrUnknownBibliographyFormat.Error(),
+		})
+		return
+	}
+
+	sources, err := h.exportService.Bibliography(c.Param("id"), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID":
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to export bibliography",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	data, err := export.RenderBibliography(format, sources)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to export bibliography",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="bibliography-%s.%s"`, c.Param("id"), export.BibliographyExtension(format)))
+	c.Data(http.StatusOK, export.BibliographyContentType(format), data)
+}
+
+func validFormat(format string, formats []string) bool {
+	for _, known := range formats {
+		if format == known {
+			return true
+		}
+	}
+	return false
+}
+
+// exportFilename turns a session title into a file name of lowercase letters, digits and dashes
+func exportFilename(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+
+	name := b.String()
+	if len(name) > 80 {
+		name = strings.TrimRight(name[:80], "-")
+	}
+	if name == "" {
+		return "research-session"
+	}
+	return name
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/signing"
+)
+
+// JWKSHandler serves the public keys that verify access tokens
+// @Summary JSON Web Key Set
+// @Description Get the public keys access tokens are signed with, so that other services can verify them.
+// @Description Tokens name their key in the kid header. Empty when tokens are signed with a shared secret.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} oidc.JWKS "Public signing keys"
+// @Router /.well-known/jwks.json [get]
+func JWKSHandler(keyring *signing.Keyring) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		set, err := keyring.JWKS()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to encode keys",
+				Code:    500,
+				Message: err.Error(),
+			})
+			return
+		}
+
+		// Verifiers may cache the keys briefly; rotations keep old keys published
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, set)
+	}
+}
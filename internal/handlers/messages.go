@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// MessageHandlers holds the message service dependency
+type MessageHandlers struct {
+	messageService *services.MessageService
+}
+
+// NewMessageHandlers creates new message handlers
+func NewMessageHandlers(messageService *services.MessageService) *MessageHandlers {
+	return &MessageHandlers{
+		messageService: messageService,
+	}
+}
+
+// @Summary Send a message
+// @Description Submit a user turn to a research session. Research on it starts in the background
+// @Description and the assistant reply is stored as a new message when it finishes.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param request body models.CreateMessageRequest true "Message content"
+// @Success 202 {object} models.MessageResponse "Message accepted"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Email not verified"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 409 {object} models.ErrorResponse "Research is already running"
+// @Router /research/sessions/{id}/messages [post]
+func (h *MessageHandlers) CreateMessage(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	var req models.CreateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	message, err := h.messageService.CreateUserMessage(c.Param("id"), userID, req.Content)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID", "message content is required":
+			status = http.StatusBadRequest
+		case "research is already running":
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to send message",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, toMessageResponse(*message))
+}
+
+// @Summary List messages
+// @Description Get a session's messages in chronological order with their thoughts and cited sources.
+// @Description Pass next_cursor from the previous page as cursor to continue. The last page also
+// @Description returns a cursor; polling with it returns only messages published later, including
+// @Description assistant replies, which appear once their research finishes.
+// @Tags messages
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Messages per page (max 100)" default(20)
+// @Success 200 {object} models.MessagesListResponse "Page of messages"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /research/sessions/{id}/messages [get]
+func (h *MessageHandlers) ListMessages(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: "limit must be between 1 and 100",
+		})
+		return
+	}
+
+	messages, nextCursor, hasMore, err := h.messageService.ListMessages(c.Param("id"), userID, c.Query("cursor"), limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID", "invalid cursor":
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to fetch messages",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.MessagesListResponse{
+		Messages:   make([]models.MessageResponse, len(messages)),
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}
+	for i, message := range messages {
+		response.Messages[i] = toMessageResponse(message)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// toMessageResponse converts a message with its preloaded relationships to an API response
+func toMessageResponse(message models.Message) models.MessageResponse {
+	response := models.MessageResponse{
+		ID:        message.ID.String(),
+		SessionID: message.SessionID.String(),
+		Type:      string(message.Type),
+		Content:   message.Content,
+		Thoughts:  make([]models.ThoughtResponse, len(message.Thoughts)),
+		Sources:   make([]models.SourceResponse, len(message.Sources)),
+		Citations: make([]models.CitationResponse, len(message.Citations)),
+		CreatedAt: message.CreatedAt,
+	}
+	if message.ReplyToID != nil {
+		response.ReplyToID = message.ReplyToID.String()
+	}
+
+	for i, thought := range message.Thoughts {
+		response.Thoughts[i] = models.ThoughtResponse{
+			ID:          thought.ID.String(),
+			Type:        string(thought.Type),
+			Title:       thought.Title,
+			Content:     thought.Content,
+			Status:      thought.Status,
+			Progress:    thought.Progress,
+			Metadata:    thought.Metadata,
+			StartedAt:   thought.StartedAt,
+			CompletedAt: thought.CompletedAt,
+		}
+	}
+
+	for i, source := range message.Sources {
+		response.Sources[i] = toSourceResponse(source)
+	}
+
+	for i, citation := range message.Citations {
+		response.Citations[i] = models.CitationResponse{
+			ID:          citation.ID.String(),
+			Marker:      citation.Marker,
+			Position:    citation.Position,
+			Claim:       citation.Claim,
+			SourceID:    citation.SourceID.String(),
+			DocumentID:  citation.DocumentID.String(),
+			StartOffset: citation.StartOffset,
+			EndOffset:   citation.EndOffset,
+			Quote:       citation.Quote,
+		}
+		if citation.ChunkID != nil {
+			response.Citations[i].ChunkID = citation.ChunkID.String()
+		}
+	}
+
+	return response
+}
+
+// toSourceResponse converts a source to an API response
+func toSourceResponse(source models.Source) models.SourceResponse {
+	return models.SourceResponse{
+		ID:          source.ID.String(),
+		URL:         source.URL,
+		Type:        source.Type,
+		Domain:      source.Domain,
+		Title:       source.Title,
+		Description: source.Description,
+		LastCrawled: source.LastCrawled,
+		Authors:     source.AuthorNames(),
+		PublishedAt: source.PublishedAt,
+		DOI:         source.DOI,
+		Publisher:   source.Publisher,
+		Journal:     source.Journal,
+	}
+}
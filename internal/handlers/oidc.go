@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// oidcBindingCookie holds the secret that binds a login to the browser that started it
+const oidcBindingCookie = "oidc_login"
+
+// OIDCHandlers holds the OIDC service dependencies
+type OIDCHandlers struct {
+	oidcService *services.OIDCService
+	loginGuard  *services.LoginGuard
+	appURL      string // Web app the browser returns to after a login
+}
+
+// NewOIDCHandlers creates new OIDC handlers. Browser logins finish at
+// <appURL>/oidc/callback.
+func NewOIDCHandlers(oidcService *services.OIDCService, loginGuard *services.LoginGuard, appURL string) *OIDCHandlers {
+	return &OIDCHandlers{
+		oidcService: oidcService,
+		loginGuard:  loginGuard,
+		appURL:      strings.TrimRight(appURL, "/"),
+	}
+}
+
+// @Summary List identity providers
+// @Description Get the names of the OpenID Connect identity providers users can sign in with
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.OIDCProvidersResponse "Identity providers"
+// @Router /auth/oidc/providers [get]
+func (h *OIDCHandlers) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, models.OIDCProvidersResponse{Providers: h.oidcService.Providers()})
+}
+
+// @Summary Start identity provider login
+// @Description Start signing in at an identity provider with the authorization code flow and PKCE. Redirects to the
+// @Description provider, or returns its URL when redirect is false. The login is bound to the client that starts it
+// @Description with an HttpOnly cookie, and for apps also with the returned binding, which the callback must present.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Identity provider name"
+// @Param redirect query bool false "Redirect to the provider" default(true)
+// @Success 200 {object} models.OIDCLoginResponse "Provider URL"
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} models.ErrorResponse "Unknown identity provider"
+// @Failure 502 {object} models.ErrorResponse "Identity provider unavailable"
+// @Router /auth/oidc/{provider}/login [get]
+func (h *OIDCHandlers) Login(c *gin.Context) {
+	authURL, binding, err := h.oidcService.Begin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, services.ErrUnknownOIDCProvider) {
+			status = http.StatusNotFound
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to start login",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	h.setBindingCookie(c, binding, int(services.OIDCStateTTL.Seconds()))
+	if c.Query("redirect") == "false" {
+		c.JSON(http.StatusOK, models.OIDCLoginResponse{AuthorizationURL: authURL, Binding: binding})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// @Summary Finish identity provider login
+// @Description Finish signing in with the code and state the identity provider redirected the browser back with.
+// @Description The account linked to the identity signs in; otherwise the identity is linked to the account with its
+// @Description verified email, or a new account is created when the provider allows it. The browser is sent on to
+// @Description <app_url>/oidc/callback with a login_code to redeem at /auth/oidc/token, or with error and
+// @Description error_description when the login failed.
+// @Tags auth
+// @Param provider path string true "Identity provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 302 "Redirect to the web app"
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *OIDCHandlers) Callback(c *gin.Context) {
+	binding, _ := c.Cookie(oidcBindingCookie)
+	h.setBindingCookie(c, "", -1)
+
+	attempt := h.loginAttempt(c)
+	if providerError := c.Query("error"); providerError != "" {
+		message := providerError
+		if description := c.Query("error_description"); description != "" {
+			message += ": " + description
+		}
+		h.loginGuard.Failed(c.Request.Context(), attempt, errors.New(message))
+		h.redirectToApp(c, url.Values{"error": {"login_failed"}, "error_description": {message}})
+		return
+	}
+
+	user, err := h.oidcService.Authenticate(c.Request.Context(), c.Param("provider"), c.Query("state"), c.Query("code"), binding)
+	if err != nil {
+		h.loginGuard.Failed(c.Request.Context(), attempt, err)
+		h.redirectToApp(c, url.Values{"error": {oidcErrorCode(oidcErrorStatus(err))}, "error_description": {err.Error()}})
+		return
+	}
+
+	code, err := h.oidcService.IssueLoginCode(user)
+	if err != nil {
+		h.loginGuard.Failed(c.Request.Context(), attempt, err)
+		h.redirectToApp(c, url.Values{"error": {"server_error"}, "error_description": {"failed to finish login"}})
+		return
+	}
+	h.loginGuard.Succeeded(c.Request.Context(), attempt, user)
+	h.redirectToApp(c, url.Values{"login_code": {code}})
+}
+
+// @Summary Finish identity provider login from an app
+// @Description Finish signing in with the code and state the identity provider redirected an app back with,
+// @Description for apps that register their own page as the redirect URL. The binding returned when the login started
+// @Description must be sent along, unless the login cookie is.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Identity provider name"
+// @Param request body models.OIDCCallbackRequest true "Code, state and binding"
+// @Success 200 {object} models.AuthResponse "Successful login"
+// @Failure 400 {object} models.ErrorResponse "Invalid request or login state"
+// @Failure 401 {object} models.ErrorResponse "Identity provider login failed"
+// @Failure 403 {object} models.ErrorResponse "Account disabled or not allowed"
+// @Failure 404 {object} models.ErrorResponse "Unknown identity provider"
+// @Router /auth/oidc/{provider}/callback [post]
+func (h *OIDCHandlers) CallbackJSON(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+	if req.Binding == "" {
+		req.Binding, _ = c.Cookie(oidcBindingCookie)
+	}
+	h.setBindingCookie(c, "", -1)
+
+	attempt := h.loginAttempt(c)
+	user, tokens, err := h.oidcService.Complete(c.Request.Context(), c.Param("provider"), req.State, req.Code, req.Binding)
+	if err != nil {
+		h.loginGuard.Failed(c.Request.Context(), attempt, err)
+
+		status := oidcErrorStatus(err)
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Authentication failed",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+	h.loginGuard.Succeeded(c.Request.Context(), attempt, user)
+
+	c.JSON(http.StatusOK, toAuthResponse(user, tokens))
+}
+
+// @Summary Redeem an identity provider login
+// @Description Exchange the single-use login_code the web app received after an identity provider login for tokens.
+// @Description Codes expire after a minute.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.OIDCTokenRequest true "Login code"
+// @Success 200 {object} models.AuthResponse "Successful login"
+// @Failure 400 {object} models.ErrorResponse "Invalid, expired or used login code"
+// @Failure 403 {object} models.ErrorResponse "Account disabled"
+// @Router /auth/oidc/token [post]
+func (h *OIDCHandlers) Token(c *gin.Context) {
+	var req models.OIDCTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	user, tokens, err := h.oidcService.RedeemLoginCode(req.LoginCode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidLoginCode):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrAccountDisabled):
+			status = http.StatusForbidden
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Authentication failed",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuthResponse(user, tokens))
+}
+
+// loginAttempt describes the identity provider login of the request for auditing
+func (h *OIDCHandlers) loginAttempt(c *gin.Context) *services.LoginAttempt {
+	return &services.LoginAttempt{
+		Method:    models.LoginMethodOIDC + c.Param("provider"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// setBindingCookie sets the login binding cookie, or deletes it when maxAge is
+// negative. SameSite=Lax lets it through on the provider's redirect back.
+func (h *OIDCHandlers) setBindingCookie(c *gin.Context, binding string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(h.appURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, binding, maxAge, "/auth/oidc/", "", secure, true)
+}
+
+// redirectToApp sends the browser to the web app's login callback with params
+func (h *OIDCHandlers) redirectToApp(c *gin.Context, params url.Values) {
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, h.appURL+"/oidc/callback?"+params.Encode())
+}
+
+// oidcErrorStatus returns the HTTP status of a login error
+func oidcErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnknownOIDCProvider):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidOIDCState), errors.Is(err, services.ErrOIDCNoEmail):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrOIDCLoginFailed):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrAccountDisabled), errors.Is(err, services.ErrOIDCEmailUnverified),
+		errors.Is(err, services.ErrOIDCEmailNotAllowed), errors.Is(err, services.ErrOIDCSignupDisabled):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// oidcErrorCode names a login error status for the web app
+func oidcErrorCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return "unknown_provider"
+	case http.StatusBadRequest:
+		return "invalid_request"
+	case http.StatusUnauthorized:
+		return "login_failed"
+	case http.StatusForbidden:
+		return "access_denied"
+	}
+	return "server_error"
+}
@@ -1,30 +1,113 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
 	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
 )
 
+// keepAliveInterval is how often an idle event stream sends a comment to keep the connection open
+const keepAliveInterval = 15 * time.Second
+
+// ResearchHandlers holds the job queue, event broker and session service dependencies
+type ResearchHandlers struct {
+	sessionService *services.SessionService
+	jobs           *services.JobQueue
+	events         *services.EventBroker
+}
+
+// NewResearchHandlers creates new research handlers
+func NewResearchHandlers(sessionService *services.SessionService, jobs *services.JobQueue, events *services.EventBroker) *ResearchHandlers {
+	return &ResearchHandlers{
+		sessionService: sessionService,
+		jobs:           jobs,
+		events:         events,
+	}
+}
+
 // @Summary Research streaming
-// @Description Stream research progress in real-time using Server-Sent Events
+// @Description Queue research for a session and stream its progress in real-time using Server-Sent Events.
+// @Description When session_id is omitted a new session is created for the query. The run continues in the
+// @Description background if the client disconnects.
 // @Tags research
 // @Produce text/event-stream
 // @Security ApiKeyAuth
-// @Param query query string true "Research query"
+// @Param session_id query string false "Research session ID"
+// @Param query query string false "Research query (required without session_id)"
 // @Success 200 {object} models.ResearchProgressEvent "Research progress stream"
 // @Failure 400 {object} models.ErrorResponse "Invalid request"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Email not verified, or an API key without the write scope"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 409 {object} models.ErrorResponse "Research is already running"
 // @Router /research/stream [get]
-func ResearchStreamHandler(c *gin.Context) {
+func (h *ResearchHandlers) ResearchStream(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	sessionID := c.Query("session_id")
 	query := c.Query("query")
-	if query == "" {
-		c.JSON(400, models.ErrorResponse{
+	if sessionID == "" && query == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request",
 			Code:    400,
-			Message: "Query parameter is required",
+			Message: "Either session_id or query parameter is required",
+		})
+		return
+	}
+
+	var session *models.ResearchSession
+	var err error
+	if sessionID != "" {
+		session, err = h.sessionService.GetSession(sessionID, userID)
+		if err == nil && query == "" {
+			query = session.Query
+		}
+	} else {
+		session, err = h.sessionService.CreateSession(userID, query, query, nil, 0, "")
+	}
+
+	// Subscribe before queueing so no event of the new run is missed
+	var live <-chan models.ResearchEvent
+	if err == nil {
+		var lastEventID uint64
+		lastEventID, err = h.events.LastEventID(session.ID)
+		if err == nil {
+			var cancel func()
+			_, live, cancel, err = h.events.Subscribe(session.ID, lastEventID)
+			if err == nil {
+				defer cancel()
+				_, err = h.jobs.Enqueue(session, query)
+			}
+		}
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "research is already running":
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to start research",
+			Code:    status,
+			Message: err.Error(),
 		})
 		return
 	}
@@ -35,28 +118,235 @@ func ResearchStreamHandler(c *gin.Context) {
 	c.Header("Connection", "keep-alive")
 	c.Header("Access-Control-Allow-Origin", "*")
 
-	// Simulate research progress
-	steps := []models.ResearchProgressEvent{
-		{Step: "Starting research...", Progress: 0, Status: "processing"},
-		{Step: "Finding relevant sources...", Progress: 20, Status: "processing"},
-		{Step: "Found 3 academic papers", Progress: 40, Sources: 3, Status: "processing"},
-		{Step: "Found 5 news articles", Progress: 60, Sources: 8, Status: "processing"},
-		{Step: "Processing documents...", Progress: 80, Sources: 8, Status: "processing"},
-		{Step: "Generating summary...", Progress: 90, Sources: 8, Status: "processing"},
-		{Step: "Research complete!", Progress: 100, Sources: 8, Status: "completed"},
+	w := c.Writer
+	sources := 0
+	send := func(step string, progress int, thoughtType, status string) {
+		data, err := json.Marshal(models.ResearchProgressEvent{
+			Step:      step,
+			Progress:  progress,
+			Timestamp: time.Now().Format(time.RFC3339),
+			Sources:   sources,
+			Status:    status,
+			Type:      thoughtType,
+		})
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		w.Flush()
+	}
+
+	send("Starting research...", 0, "", "processing")
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case models.ResearchEventSourceFound:
+				sources++
+			case models.ResearchEventThoughtStarted:
+				send(event.Title+"...", event.Progress, event.ThoughtType, "processing")
+			case models.ResearchEventThoughtProgress:
+				send(event.Content, event.Progress, event.ThoughtType, "processing")
+			case models.ResearchEventCompleted:
+				send("Research complete!", 100, event.ThoughtType, "completed")
+				return
+			case models.ResearchEventRetrying:
+				// The job is queued again, so keep streaming its next attempt
+				send("Research attempt failed, retrying: "+event.Error, 0, event.ThoughtType, "processing")
+			case models.ResearchEventFailed:
+				send("Research failed: "+event.Error, 100, event.ThoughtType, "error")
+				return
+			}
+		}
+	}
+}
+
+// @Summary Session event stream
+// @Description Attach to a session's research runs and stream their typed events using Server-Sent Events.
+// @Description Every event except token deltas carries a monotonic id; reconnect with the Last-Event-ID header
+// @Description (or last_event_id query parameter) to replay the events that were missed. The stream stays open
+// @Description across turns.
+// @Tags research
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "ID of the last event received, for clients that cannot set headers"
+// @Success 200 {object} models.ResearchEvent "Research event stream"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /research/sessions/{id}/stream [get]
+func (h *ResearchHandlers) SessionStream(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var after uint64
+	if lastEventID != "" {
+		var err error
+		after, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request",
+				Code:    400,
+				Message: "Last-Event-ID must be a non-negative integer",
+			})
+			return
+		}
+	}
+
+	session, err := h.sessionService.GetSession(c.Param("id"), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID":
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to open stream",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	missed, live, cancel, err := h.events.Subscribe(session.ID, after)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to open stream",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
 	}
+	defer cancel()
+
+	// Set SSE headers
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("X-Accel-Buffering", "no")
 
 	w := c.Writer
-	for _, step := range steps {
-		step.Timestamp = time.Now().Format(time.RFC3339)
+	w.WriteHeader(http.StatusOK)
+	for _, event := range missed {
+		writeResearchEvent(w, event)
+	}
+	w.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				// The client fell behind; it reconnects and replays from its last event ID
+				return
+			}
+			writeResearchEvent(w, event)
+			w.Flush()
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			w.Flush()
+		}
+	}
+}
+
+// writeResearchEvent writes an event in the SSE wire format.
+// Token events are not stored, so they carry no ID and do not move the client's Last-Event-ID.
+func writeResearchEvent(w gin.ResponseWriter, event models.ResearchEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if event.ID == 0 {
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
 
-		fmt.Fprintf(w, "data: {\"step\": \"%s\", \"progress\": %d, \"timestamp\": \"%s\", \"sources\": %d, \"status\": \"%s\"}\n\n",
-			step.Step, step.Progress, step.Timestamp, step.Sources, step.Status)
+// @Summary Cancel research
+// @Description Stop the session's queued or running research job. A queued job is cancelled at once;
+// @Description a running job stops at its next checkpoint and the session becomes cancelled.
+// @Tags research
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 202 {object} models.JobResponse "Cancellation accepted"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 409 {object} models.ErrorResponse "No research run to cancel"
+// @Router /research/sessions/{id}/cancel [post]
+func (h *ResearchHandlers) CancelResearch(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
 
-		if f, ok := w.(gin.ResponseWriter); ok {
-			f.Flush()
+	session, err := h.sessionService.GetSession(c.Param("id"), userID)
+	var job *models.ResearchJob
+	if err == nil {
+		job, err = h.jobs.Cancel(session.ID)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID":
+			status = http.StatusBadRequest
+		case "no research run to cancel":
+			status = http.StatusConflict
 		}
 
-		time.Sleep(1 * time.Second)
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to cancel research",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
 	}
+
+	c.JSON(http.StatusAccepted, models.JobResponse{
+		ID:              job.ID.String(),
+		SessionID:       job.SessionID.String(),
+		Status:          string(job.Status),
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		CancelRequested: job.CancelRequested,
+		LastError:       job.LastError,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	})
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// RetrievalHandlers holds the retrieval service dependency
+type RetrievalHandlers struct {
+	retrievalService *services.RetrievalService
+}
+
+// NewRetrievalHandlers creates new retrieval handlers
+func NewRetrievalHandlers(retrievalService *services.RetrievalService) *RetrievalHandlers {
+	return &RetrievalHandlers{
+		retrievalService: retrievalService,
+	}
+}
+
+// @Summary Retrieve passages
+// @Description Find the indexed passages of a session's documents most similar to a question
+// @Tags research
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param q query string true "Question"
+// @Param k query int false "Number of passages (1-50)" default(8)
+// @Success 200 {object} models.RetrievalResponse "Closest passages, most similar first"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 503 {object} models.ErrorResponse "Retrieval is not configured"
+// @Router /research/sessions/{id}/retrieve [get]
+func (h *RetrievalHandlers) Retrieve(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	k, err := strconv.Atoi(c.DefaultQuery("k", "8"))
+	if err != nil || k < 1 || k > 50 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request",
+			Code:    400,
+			Message: "k must be between 1 and 50",
+		})
+		return
+	}
+
+	question := c.Query("q")
+	passages, err := h.retrievalService.Retrieve(c.Request.Context(), c.Param("id"), userID, question, k)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID", "question is required":
+			status = http.StatusBadRequest
+		case "retrieval is not configured":
+			status = http.StatusServiceUnavailable
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to retrieve passages",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.RetrievalResponse{
+		Query:  question,
+		Chunks: make([]models.ChunkResponse, len(passages)),
+	}
+	for i, passage := range passages {
+		response.Chunks[i] = models.ChunkResponse{
+			ID:          passage.Chunk.ID.String(),
+			DocumentID:  passage.Chunk.DocumentID.String(),
+			SourceID:    passage.Document.SourceID.String(),
+			Title:       passage.Document.Title,
+			URL:         passage.Document.Source.URL,
+			Index:       passage.Chunk.Index,
+			Content:     passage.Chunk.Content,
+			StartOffset: passage.Chunk.StartOffset,
+			EndOffset:   passage.Chunk.EndOffset,
+			Score:       passage.Score,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
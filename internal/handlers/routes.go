@@ -1,28 +1,304 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/lolzone13/DeepResearch/internal/config"
+	"github.com/lolzone13/DeepResearch/internal/crawler"
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/mailer"
 	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/oidc"
+	"github.com/lolzone13/DeepResearch/internal/ranking"
+	"github.com/lolzone13/DeepResearch/internal/retrieval"
+	"github.com/lolzone13/DeepResearch/internal/search"
 	"github.com/lolzone13/DeepResearch/internal/services"
+	"github.com/lolzone13/DeepResearch/internal/signing"
+	"github.com/lolzone13/DeepResearch/internal/throttle"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// SetupRoutesWithServices configures and registers all HTTP routes with dependency injection
-func SetupRoutesWithServices(cfg *config.Config, dbService *services.DatabaseService) (*gin.Engine, error) {
+// SetupRoutesWithServices configures and registers all HTTP routes with dependency injection.
+// Background workers run until ctx is cancelled; the returned wait function
+// blocks until they have stopped and queued emails are sent.
+func SetupRoutesWithServices(ctx context.Context, cfg *config.Config, dbService *services.DatabaseService) (*gin.Engine, func(), error) {
 	// Set Gin mode (can be set via environment variable)
 	gin.SetMode(gin.ReleaseMode)
 
 	router := gin.Default()
 
+	// Only trusted proxies may report the client IP, which login throttling keys on
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
 	// Create services
-	authService := services.NewAuthService(dbService.GetDB(), cfg.JWT.Secret, cfg.JWT.ExpiryHours)
+	accessTTL := time.Duration(cfg.JWT.AccessTokenMinutes) * time.Minute
+	if accessTTL <= 0 {
+		accessTTL = time.Duration(cfg.JWT.ExpiryHours) * time.Hour
+	}
+	keyring, err := loadKeyring(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	authService := services.NewAuthService(dbService.GetDB(), keyring, accessTTL,
+		time.Duration(cfg.JWT.RefreshTokenDays)*24*time.Hour)
 	sessionService := services.NewSessionService(dbService.GetDB())
 
+	// Fall back to single-query plans, lexical claim checks and extractive answers and summaries
+	// when no language model is configured
+	var synthesizer services.ResearchSynthesizer = services.ExtractiveSynthesizer{MaxSentences: 8}
+	var summarizer services.ResearchSummarizer = services.ExtractiveSummarizer{}
+	var planner services.ResearchPlanner = services.QueryPlanner{}
+	var validator services.ResearchValidator = services.LexicalValidator{}
+	llmProvider, err := llm.NewProvider(cfg.LLM.Provider, llm.Options{
+		BaseURL:        cfg.LLM.BaseURL,
+		APIKey:         cfg.LLM.APIKey,
+		Model:          cfg.LLM.Model,
+		EmbeddingModel: cfg.LLM.EmbeddingModel,
+		Timeout:        time.Duration(cfg.LLM.TimeoutSeconds) * time.Second,
+	})
+	switch {
+	case err == nil:
+		synthesizer = services.LLMSynthesizer{Provider: llmProvider}
+		summarizer = services.LLMSummarizer{Provider: llmProvider}
+		planner = services.LLMPlanner{Provider: llmProvider}
+		validator = services.LLMValidator{Provider: llmProvider}
+	case !errors.Is(err, llm.ErrNotConfigured):
+		return nil, nil, err
+	}
+
+	// Documents are ranked lexically and by domain; the embedding and judge components need the provider
+	rankingAnalyzer := services.RankingAnalyzer{
+		Weights:   services.DefaultRankingWeights(),
+		Threshold: services.DefaultRankingThreshold,
+		Domains:   ranking.DefaultDomainPriors(),
+	}
+	switch {
+	case cfg.Ranking.Threshold > 0:
+		rankingAnalyzer.Threshold = cfg.Ranking.Threshold
+	case cfg.Ranking.Threshold < 0:
+		rankingAnalyzer.Threshold = 0
+	}
+	weights := cfg.Ranking.Weights
+	if weights.BM25 != 0 || weights.Embedding != 0 || weights.LLM != 0 || weights.Domain != 0 {
+		rankingAnalyzer.Weights = services.RankingWeights{
+			BM25:      weights.BM25,
+			Embedding: weights.Embedding,
+			LLM:       weights.LLM,
+			Domain:    weights.Domain,
+		}
+	}
+	for _, prior := range cfg.Ranking.DomainScores {
+		rankingAnalyzer.Domains.Scores[prior.Domain] = prior.Score
+	}
+	if llmProvider != nil {
+		rankingAnalyzer.Embedder = llmProvider
+		rankingAnalyzer.Judge = llmProvider
+	}
+
+	// Passage retrieval embeds document chunks, so it needs the language model provider
+	var retriever *retrieval.Retriever
+	if llmProvider != nil {
+		var store retrieval.Store
+		switch cfg.Retrieval.Store {
+		case "pgvector":
+			// Databases without the extension can still retrieve from memory
+			if err := dbService.EnableVectorStore(); err != nil {
+				log.Printf("pgvector is unavailable, keeping embeddings in memory: %v", err)
+				store = retrieval.NewMemoryStore()
+			} else {
+				store = retrieval.NewPGVectorStore(dbService.GetDB())
+			}
+		case "", "memory":
+			store = retrieval.NewMemoryStore()
+		default:
+			return nil, nil, fmt.Errorf("unknown retrieval store %q", cfg.Retrieval.Store)
+		}
+
+		embeddingModel := cfg.LLM.EmbeddingModel
+		if embeddingModel == "" {
+			embeddingModel = cfg.LLM.Model
+		}
+		retriever = &retrieval.Retriever{
+			Store:          store,
+			Embedder:       llmProvider,
+			EmbeddingModel: embeddingModel,
+			Chunking: retrieval.ChunkOptions{
+				Unit:    cfg.Retrieval.ChunkUnit,
+				Size:    cfg.Retrieval.ChunkSize,
+				Overlap: cfg.Retrieval.ChunkOverlap,
+			},
+			BatchSize: cfg.Retrieval.BatchSize,
+		}
+	}
+
+	// Without a search provider only URLs mentioned in the query are researched
+	var searcher services.ResearchSearcher = services.SeedURLSearcher{}
+	var searchProviders []search.Provider
+	for _, name := range cfg.Search.Providers {
+		provider, err := search.NewProvider(name, search.Options{
+			SearxNGURL:  cfg.Search.SearxNG.BaseURL,
+			BraveURL:    cfg.Search.Brave.BaseURL,
+			BraveAPIKey: cfg.Search.Brave.APIKey,
+			FixturePath: cfg.Search.Fixture.Path,
+			Timeout:     time.Duration(cfg.Search.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		searchProviders = append(searchProviders, provider)
+	}
+	if len(searchProviders) > 0 {
+		searcher = services.ProviderSearcher{
+			Provider:        search.NewMulti(searchProviders...),
+			ResultsPerQuery: cfg.Search.ResultsPerQuery,
+		}
+	}
+
+	summaryTypes := []models.SummaryType{models.SummaryTypeOverview, models.SummaryTypeKeyPoints, models.SummaryTypeConclusion}
+	if cfg.Summaries.Types != nil {
+		summaryTypes = nil
+		for _, name := range cfg.Summaries.Types {
+			summaryType := models.SummaryType(name)
+			if !summaryType.IsValid() {
+				return nil, nil, fmt.Errorf("unknown summary type %q", name)
+			}
+			summaryTypes = append(summaryTypes, summaryType)
+		}
+	}
+
+	events := services.NewEventBroker(dbService.GetDB(), cfg.GetDatabaseDSN(), 0, 0)
+	researchEngine, err := services.NewResearchEngine(dbService.GetDB(), services.ResearchStages{
+		Planner:  planner,
+		Searcher: searcher,
+		Fetcher: services.CrawlFetcher{Crawler: crawler.New(crawler.Options{
+			Workers:       cfg.Crawler.Workers,
+			PerDomain:     cfg.Crawler.PerDomain,
+			Timeout:       time.Duration(cfg.Crawler.TimeoutSeconds) * time.Second,
+			MaxBodyBytes:  cfg.Crawler.MaxBodyBytes,
+			MaxRedirects:  cfg.Crawler.MaxRedirects,
+			UserAgent:     cfg.Crawler.UserAgent,
+			RespectRobots: !cfg.Crawler.IgnoreRobots,
+		})},
+		Analyzer:     rankingAnalyzer,
+		Synthesizer:  synthesizer,
+		Validator:    validator,
+		Summarizer:   summarizer,
+		SummaryTypes: summaryTypes,
+		Retriever:    retriever,
+		Passages:     cfg.Retrieval.Passages,
+	}, events)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Research runs execute on background workers so they outlive the requests that start them
+	jobQueue := services.NewJobQueue(dbService.GetDB(), researchEngine, services.JobQueueOptions{
+		Workers:           cfg.Jobs.Workers,
+		PollInterval:      time.Duration(cfg.Jobs.PollIntervalSeconds) * time.Second,
+		MaxAttempts:       cfg.Jobs.MaxAttempts,
+		RetryBackoff:      time.Duration(cfg.Jobs.RetryBackoffSeconds) * time.Second,
+		MaxBackoff:        time.Duration(cfg.Jobs.MaxBackoffSeconds) * time.Second,
+		HeartbeatInterval: time.Duration(cfg.Jobs.HeartbeatSeconds) * time.Second,
+		StaleAfter:        time.Duration(cfg.Jobs.StaleAfterSeconds) * time.Second,
+	})
+
 	// Create handlers
-	authHandlers := NewAuthHandlers(authService)
+	mailSender, err := mailer.NewSender(cfg.Mail.Driver, mailer.Options{
+		From:     cfg.Mail.From,
+		Host:     cfg.Mail.Host,
+		Port:     cfg.Mail.Port,
+		Username: cfg.Mail.Username,
+		Password: cfg.Mail.Password,
+		Timeout:  time.Duration(cfg.Mail.TimeoutSeconds) * time.Second,
+	})
+	if err != nil && !errors.Is(err, mailer.ErrNotConfigured) {
+		return nil, nil, err
+	}
+
+	throttleStore, err := newThrottleStore(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokenSecret := cfg.Accounts.TokenSecret
+	if tokenSecret == "" {
+		tokenSecret = cfg.JWT.Secret
+	}
+	accountService, err := services.NewAccountService(dbService.GetDB(), authService, mailSender, throttleStore, tokenSecret, services.AccountOptions{
+		AppURL:         cfg.Accounts.AppURL,
+		VerifyTTL:      time.Duration(cfg.Accounts.VerifyTokenHours) * time.Hour,
+		ResetTTL:       time.Duration(cfg.Accounts.ResetTokenMinutes) * time.Minute,
+		ResetsPerEmail: cfg.Accounts.ResetsPerEmail,
+		ResetsPerIP:    cfg.Accounts.ResetsPerIP,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("accounts: %w", err)
+	}
+
+	// Browser logins return to the web app
+	if len(cfg.OIDC.Providers) > 0 && cfg.Accounts.AppURL == "" {
+		return nil, nil, errors.New("identity providers need accounts.app_url to return to after a login")
+	}
+	var identityProviders []services.OIDCProviderOptions
+	for _, providerCfg := range cfg.OIDC.Providers {
+		provider, err := oidc.NewProvider(oidc.Config{
+			Issuer:       providerCfg.Issuer,
+			ClientID:     providerCfg.ClientID,
+			ClientSecret: providerCfg.ClientSecret,
+			RedirectURL:  providerCfg.RedirectURL,
+			Scopes:       providerCfg.Scopes,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("identity provider %q: %w", providerCfg.Name, err)
+		}
+		identityProviders = append(identityProviders, services.OIDCProviderOptions{
+			Name:           providerCfg.Name,
+			Provider:       provider,
+			AutoProvision:  providerCfg.AutoProvision,
+			AllowedDomains: providerCfg.AllowedDomains,
+		})
+	}
+	oidcService, err := services.NewOIDCService(dbService.GetDB(), authService, identityProviders)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loginGuard, err := services.NewLoginGuard(dbService.GetDB(), throttleStore, services.LoginGuardOptions{
+		AccountAttempts: cfg.LoginThrottle.AccountAttempts,
+		IPAttempts:      cfg.LoginThrottle.IPAttempts,
+		BaseLockout:     time.Duration(cfg.LoginThrottle.BaseLockoutSeconds) * time.Second,
+		MaxLockout:      time.Duration(cfg.LoginThrottle.MaxLockoutSeconds) * time.Second,
+		Window:          time.Duration(cfg.LoginThrottle.WindowMinutes) * time.Minute,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authHandlers := NewAuthHandlers(authService, accountService, loginGuard)
+	oidcHandlers := NewOIDCHandlers(oidcService, loginGuard, cfg.Accounts.AppURL)
+	apiKeyHandlers := NewAPIKeyHandlers(services.NewAPIKeyService(dbService.GetDB()))
+	adminService := services.NewAdminService(dbService.GetDB(), authService)
+	if err := adminService.PromoteAdmins(cfg.Admin.Emails); err != nil {
+		return nil, nil, fmt.Errorf("failed to promote admins: %w", err)
+	}
+	adminHandlers := NewAdminHandlers(adminService)
 	sessionHandlers := NewSessionHandlers(sessionService)
+	researchHandlers := NewResearchHandlers(sessionService, jobQueue, events)
+	messageHandlers := NewMessageHandlers(services.NewMessageService(dbService.GetDB(), jobQueue))
+	summaryHandlers := NewSummaryHandlers(services.NewSummaryService(dbService.GetDB(), researchEngine))
+	retrievalHandlers := NewRetrievalHandlers(services.NewRetrievalService(dbService.GetDB(), retriever))
+	exportHandlers := NewExportHandlers(services.NewExportService(dbService.GetDB()))
 
 	// Add CORS middleware
 	router.Use(middleware.CORSMiddleware())
@@ -33,30 +309,157 @@ func SetupRoutesWithServices(cfg *config.Config, dbService *services.DatabaseSer
 	// General routes
 	router.GET("/", HomeHandler)
 	router.GET("/health", HealthHandler)
+	router.GET("/.well-known/jwks.json", JWKSHandler(keyring))
 
 	// Auth routes (no auth required)
 	auth := router.Group("/auth")
 	{
 		auth.POST("/login", authHandlers.Login)
 		auth.POST("/register", authHandlers.Register)
+		auth.POST("/refresh", authHandlers.Refresh)
+		auth.POST("/logout", middleware.AuthMiddleware(authService), authHandlers.Logout)
+		auth.POST("/logout-all", middleware.AuthMiddleware(authService), middleware.RejectAPIKeys(), authHandlers.LogoutAll)
+		auth.POST("/verify-email", authHandlers.VerifyEmail)
+		auth.POST("/verify-email/resend", middleware.AuthMiddleware(authService), authHandlers.ResendVerification)
+		auth.POST("/password/forgot", authHandlers.ForgotPassword)
+		auth.POST("/password/reset", authHandlers.ResetPassword)
+
+		// Single sign-on through OpenID Connect identity providers
+		auth.GET("/oidc/providers", oidcHandlers.ListProviders)
+		auth.GET("/oidc/:provider/login", oidcHandlers.Login)
+		auth.GET("/oidc/:provider/callback", oidcHandlers.Callback)
+		auth.POST("/oidc/:provider/callback", oidcHandlers.CallbackJSON)
+		auth.POST("/oidc/token", oidcHandlers.Token)
+
+		// API keys are managed with a signed-in token, never with another key
+		apiKeys := auth.Group("/api-keys")
+		apiKeys.Use(middleware.AuthMiddleware(authService), middleware.RejectAPIKeys())
+		{
+			apiKeys.POST("", apiKeyHandlers.CreateAPIKey)
+			apiKeys.GET("", apiKeyHandlers.ListAPIKeys)
+			apiKeys.GET("/:id", apiKeyHandlers.GetAPIKey)
+			apiKeys.PUT("/:id", apiKeyHandlers.UpdateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandlers.RevokeAPIKey)
+		}
+	}
+
+	// Admin routes (admin role required)
+	admin := router.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(authService), middleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/users", adminHandlers.ListUsers)
+		admin.POST("/users/:id/disable", adminHandlers.DisableUser)
+		admin.POST("/users/:id/enable", adminHandlers.EnableUser)
+		admin.PUT("/users/:id/role", adminHandlers.UpdateUserRole)
+		admin.GET("/sessions", adminHandlers.ListSessions)
+		admin.GET("/audit-events", adminHandlers.ListAuditEvents)
+	}
+
+	// Routes that start research runs need the write scope of API keys, even
+	// over GET, and may require a verified email
+	startResearch := []gin.HandlerFunc{middleware.RequireScope(models.ScopeWrite)}
+	if cfg.Accounts.RequireVerifiedEmail {
+		startResearch = append(startResearch, middleware.RequireVerifiedEmail())
 	}
 
 	// Research routes (auth required)
 	research := router.Group("/research")
 	research.Use(middleware.AuthMiddleware(authService))
 	{
-		research.GET("/stream", ResearchStreamHandler)
+		research.GET("/stream", append(startResearch, researchHandlers.ResearchStream)...)
 
 		// Session management routes
 		sessions := research.Group("/sessions")
 		{
 			sessions.POST("/", sessionHandlers.CreateSession)
 			sessions.GET("/", sessionHandlers.ListSessions)
+			sessions.GET("/stats", sessionHandlers.GetSessionStats)
 			sessions.GET("/:id", sessionHandlers.GetSession)
 			sessions.PUT("/:id", sessionHandlers.UpdateSession)
 			sessions.DELETE("/:id", sessionHandlers.DeleteSession)
+			sessions.POST("/:id/messages", append(startResearch, messageHandlers.CreateMessage)...)
+			sessions.GET("/:id/messages", messageHandlers.ListMessages)
+			sessions.GET("/:id/stream", researchHandlers.SessionStream)
+			sessions.POST("/:id/cancel", researchHandlers.CancelResearch)
+			sessions.GET("/:id/summaries", summaryHandlers.ListSummaries)
+			sessions.POST("/:id/summaries/regenerate", summaryHandlers.RegenerateSummary)
+			sessions.GET("/:id/retrieve", retrievalHandlers.Retrieve)
+			sessions.GET("/:id/export", exportHandlers.ExportSession)
+			sessions.GET("/:id/bibliography", exportHandlers.ExportBibliography)
 		}
 	}
 
-	return router, nil
+	// Start the background workers last, once nothing can fail
+	jobQueue.Start(ctx)
+	events.Start(ctx)
+
+	wait := func() {
+		jobQueue.Wait()
+		accountService.Wait()
+	}
+	return router, wait, nil
+}
+
+// newThrottleStore returns the store of login and password reset throttling
+// counters: Redis when it is configured, so that every process shares them,
+// otherwise memory
+func newThrottleStore(cfg *config.Config) (throttle.Store, error) {
+	if cfg.Redis.Addr == "" {
+		return throttle.NewMemory(), nil
+	}
+
+	opts := &redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	}
+	if strings.Contains(cfg.Redis.Addr, "://") {
+		var err error
+		if opts, err = redis.ParseURL(cfg.Redis.Addr); err != nil {
+			return nil, fmt.Errorf("invalid redis address: %w", err)
+		}
+		if cfg.Redis.Password != "" {
+			opts.Password = cfg.Redis.Password
+		}
+	}
+	return throttle.NewRedis(redis.NewClient(opts)), nil
+}
+
+// loadKeyring returns the keys that sign access tokens, falling back to the
+// shared secret when no keys are configured. The JWKS endpoint is always
+// served, so the fallback is logged: other services cannot verify its tokens.
+func loadKeyring(cfg *config.Config) (*signing.Keyring, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		log.Printf("No jwt.keys configured: access tokens are signed with the shared secret and /.well-known/jwks.json publishes no keys")
+		return signing.NewHMACKeyring(cfg.JWT.Secret), nil
+	}
+
+	var keys []*signing.Key
+	for _, keyCfg := range cfg.JWT.Keys {
+		var key *signing.Key
+		var err error
+		switch {
+		case keyCfg.PrivateKey != "":
+			key, err = signing.NewPrivateKey(keyCfg.ID, []byte(keyCfg.PrivateKey))
+		case keyCfg.PrivateKeyFile != "":
+			var data []byte
+			if data, err = os.ReadFile(keyCfg.PrivateKeyFile); err == nil {
+				key, err = signing.NewPrivateKey(keyCfg.ID, data)
+			}
+		case keyCfg.PublicKey != "":
+			key, err = signing.NewPublicKey(keyCfg.ID, []byte(keyCfg.PublicKey))
+		case keyCfg.PublicKeyFile != "":
+			var data []byte
+			if data, err = os.ReadFile(keyCfg.PublicKeyFile); err == nil {
+				key, err = signing.NewPublicKey(keyCfg.ID, data)
+			}
+		default:
+			err = fmt.Errorf("signing key %q has no key material", keyCfg.ID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return signing.NewKeyring(cfg.JWT.SigningKeyID, keys...)
 }
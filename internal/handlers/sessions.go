@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -34,14 +35,15 @@ func parseTags(tagsJSON string) []string {
 }
 
 // @Summary Create research session
-// @Description Create a new research session
+// @Description Create a new research session. search_depth (shallow, medium, deep) sets the number of query expansion
+// @Description and follow-up search rounds and how many sources each round reads; max_sources (1-100) caps the sources of a run.
 // @Tags research
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param request body models.CreateSessionRequest true "Session details"
 // @Success 201 {object} models.SessionResponse "Session created successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 400 {object} models.ErrorResponse "Invalid request, max_sources or search_depth"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
 // @Router /research/sessions [post]
 func (h *SessionHandlers) CreateSession(c *gin.Context) {
@@ -66,11 +68,16 @@ func (h *SessionHandlers) CreateSession(c *gin.Context) {
 	}
 
 	// Create session using service
-	session, err := h.sessionService.CreateSession(userID, req.Title, req.Query, req.Tags)
+	session, err := h.sessionService.CreateSession(userID, req.Title, req.Query, req.Tags, req.MaxSources, req.SearchDepth)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		status := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidMaxSources) || errors.Is(err, models.ErrInvalidSearchDepth) {
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
 			Error:   "Failed to create session",
-			Code:    500,
+			Code:    status,
 			Message: err.Error(),
 		})
 		return
@@ -82,11 +89,15 @@ func (h *SessionHandlers) CreateSession(c *gin.Context) {
 		UserID:       session.UserID.String(),
 		Title:        session.Title,
 		Query:        session.Query,
-		Status:       "pending", // Default status since model doesn't have it
+		Status:       string(session.Status),
 		MessageCount: len(session.Messages),
 		CreatedAt:    session.CreatedAt,
 		UpdatedAt:    session.UpdatedAt,
+		StartedAt:    session.StartedAt,
+		FinishedAt:   session.FinishedAt,
 		Tags:         parseTags(session.Tags),
+		MaxSources:   session.MaxSources,
+		SearchDepth:  string(session.SearchDepth),
 	}
 
 	c.JSON(http.StatusCreated, response)
@@ -99,8 +110,9 @@ func (h *SessionHandlers) CreateSession(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Param page query int false "Page number" default(1)
 // @Param per_page query int false "Items per page" default(10)
-// @Param status query string false "Filter by status"
+// @Param status query string false "Filter by status" Enums(pending, running, completed, failed, cancelled)
 // @Success 200 {object} models.SessionsListResponse "List of sessions"
+// @Failure 400 {object} models.ErrorResponse "Invalid status"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
 // @Router /research/sessions [get]
 func (h *SessionHandlers) ListSessions(c *gin.Context) {
@@ -122,9 +134,14 @@ func (h *SessionHandlers) ListSessions(c *gin.Context) {
 	// Get sessions from service
 	sessions, total, err := h.sessionService.ListSessions(userID, page, perPage, status)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		code := http.StatusInternalServerError
+		if errors.Is(err, models.ErrInvalidSessionStatus) {
+			code = http.StatusBadRequest
+		}
+
+		c.JSON(code, models.ErrorResponse{
 			Error:   "Failed to fetch sessions",
-			Code:    500,
+			Code:    code,
 			Message: err.Error(),
 		})
 		return
@@ -138,11 +155,15 @@ func (h *SessionHandlers) ListSessions(c *gin.Context) {
 			UserID:       session.UserID.String(),
 			Title:        session.Title,
 			Query:        session.Query,
-			Status:       "pending", // Default status
+			Status:       string(session.Status),
 			MessageCount: len(session.Messages),
 			CreatedAt:    session.CreatedAt,
 			UpdatedAt:    session.UpdatedAt,
+			StartedAt:    session.StartedAt,
+			FinishedAt:   session.FinishedAt,
 			Tags:         parseTags(session.Tags),
+			MaxSources:   session.MaxSources,
+			SearchDepth:  string(session.SearchDepth),
 		}
 	}
 
@@ -151,6 +172,7 @@ func (h *SessionHandlers) ListSessions(c *gin.Context) {
 
 	response := models.SessionsListResponse{
 		Sessions:   sessionResponses,
+		Total:      int(total),
 		Page:       page,
 		PerPage:    perPage,
 		TotalPages: totalPages,
@@ -159,6 +181,38 @@ func (h *SessionHandlers) ListSessions(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// @Summary Get session statistics
+// @Description Get the number of the authenticated user's research sessions in each status
+// @Tags research
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.SessionStatsResponse "Session counts by status"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /research/sessions/stats [get]
+func (h *SessionHandlers) GetSessionStats(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	stats, err := h.sessionService.GetSessionStats(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to fetch session statistics",
+			Code:    500,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
 // @Summary Get research session
 // @Description Get a specific research session by ID
 // @Tags research
@@ -204,11 +258,15 @@ func (h *SessionHandlers) GetSession(c *gin.Context) {
 		UserID:       session.UserID.String(),
 		Title:        session.Title,
 		Query:        session.Query,
-		Status:       "pending", // Default status
+		Status:       string(session.Status),
 		MessageCount: len(session.Messages),
 		CreatedAt:    session.CreatedAt,
 		UpdatedAt:    session.UpdatedAt,
+		StartedAt:    session.StartedAt,
+		FinishedAt:   session.FinishedAt,
 		Tags:         parseTags(session.Tags),
+		MaxSources:   session.MaxSources,
+		SearchDepth:  string(session.SearchDepth),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -272,11 +330,15 @@ func (h *SessionHandlers) UpdateSession(c *gin.Context) {
 		UserID:       session.UserID.String(),
 		Title:        session.Title,
 		Query:        session.Query,
-		Status:       "pending", // Default status
+		Status:       string(session.Status),
 		MessageCount: len(session.Messages),
 		CreatedAt:    session.CreatedAt,
 		UpdatedAt:    session.UpdatedAt,
+		StartedAt:    session.StartedAt,
+		FinishedAt:   session.FinishedAt,
 		Tags:         parseTags(session.Tags),
+		MaxSources:   session.MaxSources,
+		SearchDepth:  string(session.SearchDepth),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/middleware"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/services"
+)
+
+// SummaryHandlers holds the summary service dependency
+type SummaryHandlers struct {
+	summaryService *services.SummaryService
+}
+
+// NewSummaryHandlers creates new summary handlers
+func NewSummaryHandlers(summaryService *services.SummaryService) *SummaryHandlers {
+	return &SummaryHandlers{
+		summaryService: summaryService,
+	}
+}
+
+// @Summary List summaries
+// @Description Get the summaries written for a research session, one per summary type
+// @Tags summaries
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} models.SummariesListResponse "Session summaries"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /research/sessions/{id}/summaries [get]
+func (h *SummaryHandlers) ListSummaries(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	summaries, err := h.summaryService.ListSummaries(c.Param("id"), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID":
+			status = http.StatusBadRequest
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to fetch summaries",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	response := models.SummariesListResponse{
+		Summaries: make([]models.SummaryResponse, len(summaries)),
+	}
+	for i, summary := range summaries {
+		response.Summaries[i] = toSummaryResponse(summary)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// @Summary Regenerate a summary
+// @Description Rewrite one summary of a session from its stored documents, without searching or crawling again
+// @Tags summaries
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param type query string true "Summary type" Enums(overview, detailed, key_points, conclusion)
+// @Success 200 {object} models.SummaryResponse "Regenerated summary"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Failure 409 {object} models.ErrorResponse "No documents to summarize"
+// @Router /research/sessions/{id}/summaries/regenerate [post]
+func (h *SummaryHandlers) RegenerateSummary(c *gin.Context) {
+	userID, exists := middleware.GetUserIDFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error:   "Unauthorized",
+			Code:    401,
+			Message: "User not found in context",
+		})
+		return
+	}
+
+	summary, err := h.summaryService.RegenerateSummary(c.Request.Context(), c.Param("id"), userID, c.Query("type"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch err.Error() {
+		case "session not found":
+			status = http.StatusNotFound
+		case "invalid session ID", "invalid summary type":
+			status = http.StatusBadRequest
+		case "no documents to summarize":
+			status = http.StatusConflict
+		}
+
+		c.JSON(status, models.ErrorResponse{
+			Error:   "Failed to regenerate summary",
+			Code:    status,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, toSummaryResponse(*summary))
+}
+
+// toSummaryResponse converts a summary to an API response
+func toSummaryResponse(summary models.Summary) models.SummaryResponse {
+	keyPoints := []string{}
+	if summary.KeyPoints != "" {
+		json.Unmarshal([]byte(summary.KeyPoints), &keyPoints)
+	}
+
+	return models.SummaryResponse{
+		ID:              summary.ID.String(),
+		SessionID:       summary.SessionID.String(),
+		Type:            string(summary.Type),
+		Title:           summary.Title,
+		Content:         summary.Content,
+		KeyPoints:       keyPoints,
+		SourcesUsed:     summary.SourcesUsed,
+		ConfidenceScore: summary.ConfidenceScore,
+		GeneratedBy:     summary.GeneratedBy,
+		GeneratedAt:     summary.GeneratedAt,
+	}
+}
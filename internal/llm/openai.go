@@ -0,0 +1,296 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient talks to any server implementing the OpenAI-compatible
+// /v1/chat/completions and /v1/embeddings endpoints (OpenAI, llama.cpp, Ollama, vLLM)
+type OpenAIClient struct {
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string
+	timeout        time.Duration // Bounds whole requests, and the silence between streamed chunks
+	httpClient     *http.Client
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible client
+func NewOpenAIClient(opts Options) (*OpenAIClient, error) {
+	if opts.BaseURL == "" {
+		return nil, errors.New("llm base URL is required")
+	}
+	if opts.Model == "" {
+		return nil, errors.New("llm model is required")
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 2 * time.Minute
+	}
+	embeddingModel := opts.EmbeddingModel
+	if embeddingModel == "" {
+		embeddingModel = opts.Model
+	}
+
+	return &OpenAIClient{
+		baseURL:        strings.TrimSuffix(opts.BaseURL, "/"),
+		apiKey:         opts.APIKey,
+		model:          opts.Model,
+		embeddingModel: embeddingModel,
+		timeout:        timeout,
+		// Deadlines are set per request, since streams may run longer than the timeout
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// Name returns the provider name
+func (c *OpenAIClient) Name() string {
+	return "openai"
+}
+
+// Wire format types for the OpenAI-compatible API
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      Message `json:"message"`
+		Delta        Message `json:"delta"`
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+type apiErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// Chat runs a chat completion and returns the full reply
+func (c *OpenAIClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.post(ctx, "/v1/chat/completions", c.chatBody(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode chat completion: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, errors.New("chat completion returned no choices")
+	}
+
+	result := &ChatResponse{
+		Model:   completion.Model,
+		Content: completion.Choices[0].Message.Content,
+	}
+	if reason := completion.Choices[0].FinishReason; reason != nil {
+		result.FinishReason = *reason
+	}
+	if completion.Usage != nil {
+		result.Usage = *completion.Usage
+	}
+	return result, nil
+}
+
+// ChatStream runs a streaming chat completion using server-sent events. The
+// stream may take longer than the timeout as long as no gap between chunks does.
+func (c *OpenAIClient) ChatStream(ctx context.Context, req ChatRequest, onToken TokenFunc) (*ChatResponse, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(c.timeout, func() {
+		cancel(fmt.Errorf("llm stream was silent for longer than %s", c.timeout))
+	})
+	defer idle.Stop()
+
+	resp, err := c.post(ctx, "/v1/chat/completions", c.chatBody(req, true))
+	if err != nil {
+		return nil, streamError(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	result := &ChatResponse{}
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		idle.Reset(c.timeout)
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != nil {
+				result.FinishReason = *choice.FinishReason
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onToken != nil {
+				if err := onToken(choice.Delta.Content); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, streamError(ctx, err)
+	}
+
+	result.Content = content.String()
+	return result, nil
+}
+
+// Embed returns one embedding vector per input text
+func (c *OpenAIClient) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := c.post(ctx, "/v1/embeddings", embeddingRequest{
+		Model: c.embeddingModel,
+		Input: inputs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var embeddings embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddings); err != nil {
+		return nil, fmt.Errorf("failed to decode embeddings: %w", err)
+	}
+	if len(embeddings.Data) != len(inputs) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embeddings.Data))
+	}
+
+	vectors := make([][]float32, len(inputs))
+	for _, item := range embeddings.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+// streamError reports why a stream's context was cancelled, such as the idle
+// timeout, in place of the bare cancellation error
+func streamError(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+		return cause
+	}
+	return err
+}
+
+func (c *OpenAIClient) chatBody(req ChatRequest, stream bool) chatCompletionRequest {
+	model := req.Model
+	if model == "" {
+		model = c.model
+	}
+	body := chatCompletionRequest{
+		Model:       model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		Stream:      stream,
+	}
+	if req.JSONMode {
+		body.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+	return body
+}
+
+// post sends a JSON request and returns the response when it succeeded
+func (c *OpenAIClient) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+		var apiErr apiErrorResponse
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("llm request failed with status %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("llm request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return resp, nil
+}
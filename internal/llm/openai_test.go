@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixture returns the contents of a recorded response in testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// newTestClient returns a client for a server that checks each request with
+// check and answers with status and body
+func newTestClient(t *testing.T, status int, body []byte, check func(t *testing.T, r *http.Request, req map[string]interface{})) *OpenAIClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		if check != nil {
+			check(t, r, req)
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewOpenAIClient(Options{BaseURL: server.URL + "/", APIKey: "sk-test", Model: "llama3.1", EmbeddingModel: "nomic-embed-text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestChat(t *testing.T) {
+	client := newTestClient(t, http.StatusOK, fixture(t, "chat_completion.json"), func(t *testing.T, r *http.Request, req map[string]interface{}) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if req["model"] != "llama3.1" || req["stream"] != nil {
+			t.Errorf("model = %v, stream = %v", req["model"], req["stream"])
+		}
+		if format, _ := req["response_format"].(map[string]interface{}); format["type"] != "json_object" {
+			t.Errorf("response_format = %v, want json_object", req["response_format"])
+		}
+	})
+
+	resp, err := client.Chat(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "When was Go released?"}},
+		JSONMode: true,
+	})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	want := &ChatResponse{
+		Model:        "llama3.1",
+		Content:      "Go was released in 2009.",
+		FinishReason: "stop",
+		Usage:        Usage{PromptTokens: 12, CompletionTokens: 7, TotalTokens: 19},
+	}
+	if !reflect.DeepEqual(resp, want) {
+		t.Errorf("Chat = %+v, want %+v", resp, want)
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	client := newTestClient(t, http.StatusOK, fixture(t, "chat_stream.txt"), func(t *testing.T, r *http.Request, req map[string]interface{}) {
+		if req["stream"] != true {
+			t.Errorf("stream = %v, want true", req["stream"])
+		}
+	})
+
+	var tokens []string
+	resp, err := client.ChatStream(context.Background(), ChatRequest{
+		Messages: []Message{{Role: RoleUser, Content: "When was Go released?"}},
+	}, func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	// Empty deltas are skipped and nothing after [DONE] is read
+	if want := []string{"Go was ", "released ", "in 2009."}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("tokens = %q, want %q", tokens, want)
+	}
+	if resp.Content != "Go was released in 2009." || resp.FinishReason != "stop" || resp.Model != "llama3.1" || resp.Usage.TotalTokens != 19 {
+		t.Errorf("ChatStream = %+v", resp)
+	}
+}
+
+func TestChatStreamStopsOnTokenError(t *testing.T) {
+	client := newTestClient(t, http.StatusOK, fixture(t, "chat_stream.txt"), nil)
+
+	stop := fmt.Errorf("client went away")
+	_, err := client.ChatStream(context.Background(), ChatRequest{}, func(string) error { return stop })
+	if err != stop {
+		t.Errorf("ChatStream error = %v, want the token callback's", err)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    []byte
+		wantErr string
+	}{
+		{
+			name:    "API error body",
+			status:  http.StatusUnauthorized,
+			body:    fixture(t, "error.json"),
+			wantErr: "llm request failed with status 401: Incorrect API key provided",
+		},
+		{
+			name:    "plain text body",
+			status:  http.StatusBadGateway,
+			body:    []byte("upstream unavailable\n"),
+			wantErr: "llm request failed with status 502: upstream unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.status, tt.body, nil)
+			calls := map[string]func() error{
+				"Chat": func() error {
+					_, err := client.Chat(context.Background(), ChatRequest{})
+					return err
+				},
+				"ChatStream": func() error {
+					_, err := client.ChatStream(context.Background(), ChatRequest{}, nil)
+					return err
+				},
+				"Embed": func() error {
+					_, err := client.Embed(context.Background(), []string{"text"})
+					return err
+				},
+			}
+			for name, call := range calls {
+				if err := call(); err == nil || err.Error() != tt.wantErr {
+					t.Errorf("%s error = %v, want %q", name, err, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbedOrder(t *testing.T) {
+	client := newTestClient(t, http.StatusOK, fixture(t, "embeddings.json"), func(t *testing.T, r *http.Request, req map[string]interface{}) {
+		if r.URL.Path != "/v1/embeddings" || req["model"] != "nomic-embed-text" {
+			t.Errorf("path = %s, model = %v", r.URL.Path, req["model"])
+		}
+	})
+
+	vectors, err := client.Embed(context.Background(), []string{"first", "second", "third"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	want := [][]float32{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	if !reflect.DeepEqual(vectors, want) {
+		t.Errorf("Embed = %v, want vectors in input order %v", vectors, want)
+	}
+
+	if _, err := client.Embed(context.Background(), []string{"one", "two"}); err == nil {
+		t.Error("Embed accepted three embeddings for two inputs")
+	}
+}
+
+func TestChatStreamTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		gap     time.Duration // Pause before each chunk
+		wantErr bool
+	}{
+		{name: "stream longer than the timeout", gap: 40 * time.Millisecond},
+		{name: "silent stream", gap: 400 * time.Millisecond, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(http.StatusOK)
+				for i := range 5 {
+					select {
+					case <-time.After(tt.gap):
+					case <-r.Context().Done():
+						return
+					}
+					fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"%d\"}}]}\n\n", i)
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			defer server.Close()
+
+			client, err := NewOpenAIClient(Options{BaseURL: server.URL, Model: "llama3.1", Timeout: 150 * time.Millisecond})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.ChatStream(context.Background(), ChatRequest{}, nil)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "silent") {
+					t.Fatalf("ChatStream error = %v, want the idle timeout", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ChatStream: %v", err)
+			}
+			if resp.Content != "01234" {
+				t.Errorf("content = %q, want 01234", resp.Content)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Role identifies the author of a chat message
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is a single chat message sent to or received from a model
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest describes a chat completion request
+type ChatRequest struct {
+	Model       string    // Overrides the provider's default model when set
+	Messages    []Message // Conversation so far, oldest first
+	Temperature float64
+	MaxTokens   int
+	JSONMode    bool // Ask the model to reply with a JSON object
+}
+
+// Usage reports how many tokens a request consumed
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatResponse is the result of a chat completion
+type ChatResponse struct {
+	Model        string
+	Content      string
+	FinishReason string
+	Usage        Usage
+}
+
+// TokenFunc receives streamed tokens; returning an error stops the stream
+type TokenFunc func(token string) error
+
+// Provider is a language model backend
+type Provider interface {
+	// Name returns the provider name, e.g. "openai"
+	Name() string
+
+	// Chat runs a chat completion and returns the full reply
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+
+	// ChatStream runs a chat completion, calling onToken for each token as it arrives,
+	// and returns the full reply once the stream ends
+	ChatStream(ctx context.Context, req ChatRequest, onToken TokenFunc) (*ChatResponse, error)
+
+	// Embed returns one embedding vector per input text
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
+// Options configures a provider
+type Options struct {
+	BaseURL        string
+	APIKey         string
+	Model          string
+	EmbeddingModel string
+	Timeout        time.Duration
+}
+
+// ErrNotConfigured is returned when no provider is configured
+var ErrNotConfigured = errors.New("llm provider not configured")
+
+// NewProvider creates a provider by name
+func NewProvider(name string, opts Options) (Provider, error) {
+	switch name {
+	case "":
+		return nil, ErrNotConfigured
+	case "openai", "llamacpp", "ollama", "vllm":
+		return NewOpenAIClient(opts)
+	default:
+		return nil, fmt.Errorf("unknown llm provider %q", name)
+	}
+}
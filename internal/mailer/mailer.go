@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Options configures a sender
+type Options struct {
+	From     string // Address the emails are sent from, e.g. "DeepResearch <no-reply@example.com>"
+	Host     string
+	Port     int
+	Username string // Leave empty for servers that accept mail without authentication
+	Password string
+	Timeout  time.Duration
+}
+
+// ErrNotConfigured is returned when no sender is configured
+var ErrNotConfigured = errors.New("mailer not configured")
+
+// NewSender creates a sender by name: smtp, or memory to capture emails instead of sending them
+func NewSender(name string, opts Options) (Sender, error) {
+	switch name {
+	case "":
+		return nil, ErrNotConfigured
+	case "smtp":
+		return NewSMTP(opts)
+	case "memory":
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", name)
+	}
+}
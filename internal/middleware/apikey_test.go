@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		apiKey *models.APIKey
+		want   int
+	}{
+		{name: "token session", want: http.StatusOK},
+		{name: "read key", apiKey: &models.APIKey{Scopes: `["read"]`}, want: http.StatusForbidden},
+		{name: "write key", apiKey: &models.APIKey{Scopes: `["read","write"]`}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/research/stream", func(c *gin.Context) {
+				c.Set("user", &models.User{})
+				if tt.apiKey != nil {
+					c.Set("api_key", tt.apiKey)
+				}
+			}, RequireScope(models.ScopeWrite), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/research/stream", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
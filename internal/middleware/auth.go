@@ -24,17 +24,21 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Check if token is in Bearer format
+		// Check if token is in Bearer or ApiKey format
 		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
+		if len(tokenParts) != 2 || (tokenParts[0] != "Bearer" && tokenParts[0] != apiKeyScheme) {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Error:   "Invalid authorization format",
 				Code:    401,
-				Message: "Authorization header must be in format: Bearer <token>",
+				Message: "Authorization header must be in format: Bearer <token> or ApiKey <key>",
 			})
 			c.Abort()
 			return
 		}
+		if tokenParts[0] == apiKeyScheme {
+			apiKeyAuth(c, authService, tokenParts[1])
+			return
+		}
 
 		tokenString := tokenParts[1]
 
@@ -62,10 +66,32 @@ func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens revoked by a logout
+		if err := authService.CheckRevoked(claims, user); err != nil {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Invalid token",
+				Code:    401,
+				Message: err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		if user.IsDisabled {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Account disabled",
+				Code:    403,
+				Message: "This account has been disabled",
+			})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context for use in handlers
 		c.Set("user", user)
 		c.Set("user_id", user.ID.String())
 		c.Set("user_email", user.Email)
+		c.Set("token_claims", claims)
 
 		c.Next()
 	})
@@ -81,8 +107,16 @@ func OptionalAuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			return
 		}
 
-		// Check if token is in Bearer format
+		// Check if token is in Bearer or ApiKey format
 		tokenParts := strings.Split(authHeader, " ")
+		if len(tokenParts) == 2 && tokenParts[0] == apiKeyScheme {
+			user, apiKey, err := authService.AuthenticateAPIKey(tokenParts[1])
+			if err == nil && !user.IsDisabled && apiKey.HasScope(requiredScope(c.Request.Method)) {
+				setAPIKeyUser(c, user, apiKey)
+			}
+			c.Next()
+			return
+		}
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
 			c.Next()
 			return
@@ -103,11 +137,16 @@ func OptionalAuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 			c.Next()
 			return
 		}
+		if err := authService.CheckRevoked(claims, user); err != nil || user.IsDisabled {
+			c.Next()
+			return
+		}
 
 		// Set user info in context for use in handlers
 		c.Set("user", user)
 		c.Set("user_id", user.ID.String())
 		c.Set("user_email", user.Email)
+		c.Set("token_claims", claims)
 
 		c.Next()
 	})
@@ -132,3 +171,13 @@ func GetUserIDFromContext(c *gin.Context) (string, bool) {
 	}
 	return "", false
 }
+
+// GetTokenClaimsFromContext extracts the claims of the request's access token from Gin context
+func GetTokenClaimsFromContext(c *gin.Context) (*services.JWTClaims, bool) {
+	if claims, exists := c.Get("token_claims"); exists {
+		if tc, ok := claims.(*services.JWTClaims); ok {
+			return tc, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// RequireRole creates a middleware that only lets users with one of the given
+// roles through. It must run after AuthMiddleware, and checks the role stored
+// on the user rather than the one in the token, so demotions apply at once.
+// API keys also need the admin scope to act as an admin.
+func RequireRole(roles ...models.UserRole) gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Code:    401,
+				Message: "User not found in context",
+			})
+			c.Abort()
+			return
+		}
+
+		// An admin's API key without the admin scope acts as a regular user
+		effective := user.Role()
+		apiKey, viaKey := GetAPIKeyFromContext(c)
+		limited := viaKey && effective == models.RoleAdmin && !apiKey.HasScope(models.ScopeAdmin)
+		if limited {
+			effective = models.RoleUser
+		}
+
+		for _, role := range roles {
+			if effective == role {
+				c.Next()
+				return
+			}
+		}
+
+		message := "Insufficient role for this action"
+		if limited {
+			message = "This API key lacks the admin scope"
+		}
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Forbidden",
+			Code:    403,
+			Message: message,
+		})
+		c.Abort()
+	})
+}
+
+// RequireVerifiedEmail creates a middleware that only lets users who verified
+// their email address through. It must run after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		user, exists := GetUserFromContext(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Code:    401,
+				Message: "User not found in context",
+			})
+			c.Abort()
+			return
+		}
+
+		if !user.IsEmailVerified {
+			c.JSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Email not verified",
+				Code:    403,
+				Message: "Verify your email address before starting research",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		user   *models.User
+		apiKey *models.APIKey
+		want   int
+	}{
+		{name: "no user", want: http.StatusUnauthorized},
+		{name: "regular user", user: &models.User{Roles: models.RoleUser}, want: http.StatusForbidden},
+		{name: "user without a stored role", user: &models.User{}, want: http.StatusForbidden},
+		{name: "admin", user: &models.User{Roles: models.RoleAdmin}, want: http.StatusOK},
+		{
+			name:   "admin API key with the admin scope",
+			user:   &models.User{Roles: models.RoleAdmin},
+			apiKey: &models.APIKey{Scopes: `["read","admin"]`},
+			want:   http.StatusOK,
+		},
+		{
+			name:   "admin API key without the admin scope",
+			user:   &models.User{Roles: models.RoleAdmin},
+			apiKey: &models.APIKey{Scopes: `["read","write"]`},
+			want:   http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", func(c *gin.Context) {
+				if tt.user != nil {
+					c.Set("user", tt.user)
+				}
+				if tt.apiKey != nil {
+					c.Set("api_key", tt.apiKey)
+				}
+			}, RequireRole(models.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
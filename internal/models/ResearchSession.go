@@ -1,12 +1,147 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// SessionStatus is the lifecycle state of a research session
+type SessionStatus string
+
+const (
+	SessionStatusPending   SessionStatus = "pending"
+	SessionStatusRunning   SessionStatus = "running"
+	SessionStatusCompleted SessionStatus = "completed"
+	SessionStatusFailed    SessionStatus = "failed"
+	SessionStatusCancelled SessionStatus = "cancelled"
+)
+
+// SessionStatuses lists every session status
+var SessionStatuses = []SessionStatus{
+	SessionStatusPending,
+	SessionStatusRunning,
+	SessionStatusCompleted,
+	SessionStatusFailed,
+	SessionStatusCancelled,
+}
+
+// sessionTransitions lists the statuses each status may move to.
+// Finished sessions go back to pending when a follow-up turn is queued.
+var sessionTransitions = map[SessionStatus][]SessionStatus{
+	SessionStatusPending:   {SessionStatusRunning, SessionStatusCancelled},
+	SessionStatusRunning:   {SessionStatusCompleted, SessionStatusFailed, SessionStatusCancelled},
+	SessionStatusCompleted: {SessionStatusPending},
+	SessionStatusFailed:    {SessionStatusPending},
+	SessionStatusCancelled: {SessionStatusPending},
+}
+
+var (
+	// ErrInvalidStatusTransition is returned when a session cannot move to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	// ErrInvalidSessionStatus is returned for an unknown session status
+	ErrInvalidSessionStatus = errors.New("status must be one of pending, running, completed, failed, cancelled; " +
+		"sessions move from pending to running or cancelled, from running to completed, failed or cancelled, " +
+		"and from completed, failed or cancelled back to pending")
+)
+
+// IsValid reports whether s is a known session status
+func (s SessionStatus) IsValid() bool {
+	_, ok := sessionTransitions[s]
+	return ok
+}
+
+// IsTerminal reports whether a session in this status has finished running
+func (s SessionStatus) IsTerminal() bool {
+	return s == SessionStatusCompleted || s == SessionStatusFailed || s == SessionStatusCancelled
+}
+
+// CanTransitionTo reports whether a session may move from s to next
+func (s SessionStatus) CanTransitionTo(next SessionStatus) bool {
+	for _, allowed := range sessionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// PreviousStatuses returns the statuses a session may move to s from
+func (s SessionStatus) PreviousStatuses() []SessionStatus {
+	var previous []SessionStatus
+	for _, from := range SessionStatuses {
+		if from.CanTransitionTo(s) {
+			previous = append(previous, from)
+		}
+	}
+	return previous
+}
+
+// TransitionError describes a rejected status change
+func TransitionError(from, to SessionStatus) error {
+	return fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, from, to)
+}
+
+// SearchDepth controls how much searching a research run does
+type SearchDepth string
+
+const (
+	SearchDepthShallow SearchDepth = "shallow"
+	SearchDepthMedium  SearchDepth = "medium"
+	SearchDepthDeep    SearchDepth = "deep"
+)
+
+// SearchDepths lists every search depth, shallowest first
+var SearchDepths = []SearchDepth{SearchDepthShallow, SearchDepthMedium, SearchDepthDeep}
+
+// DepthProfile holds the search limits of a search depth
+type DepthProfile struct {
+	SubQuestions       int // Sub-questions a planner may split the question into
+	ExpansionRounds    int // Rounds of related queries added after the first search
+	FollowUpIterations int // Rounds of searching again based on what was read
+	CrawlFanOut        int // Sources read per round
+}
+
+// depthProfiles maps every search depth to its limits
+var depthProfiles = map[SearchDepth]DepthProfile{
+	SearchDepthShallow: {SubQuestions: 2, ExpansionRounds: 0, FollowUpIterations: 0, CrawlFanOut: 5},
+	SearchDepthMedium:  {SubQuestions: 3, ExpansionRounds: 1, FollowUpIterations: 1, CrawlFanOut: 10},
+	SearchDepthDeep:    {SubQuestions: 5, ExpansionRounds: 2, FollowUpIterations: 3, CrawlFanOut: 20},
+}
+
+const (
+	// DefaultSearchDepth is used when a session does not choose one
+	DefaultSearchDepth = SearchDepthMedium
+	// DefaultMaxSources is used when a session does not set max_sources
+	DefaultMaxSources = 20
+	// MaxSourcesLimit is the largest max_sources a session may set
+	MaxSourcesLimit = 100
+)
+
+var (
+	// ErrInvalidSearchDepth is returned for an unknown search depth
+	ErrInvalidSearchDepth = errors.New("search_depth must be one of shallow, medium, deep")
+	// ErrInvalidMaxSources is returned for a max_sources outside 1-MaxSourcesLimit
+	ErrInvalidMaxSources = fmt.Errorf("max_sources must be between 1 and %d", MaxSourcesLimit)
+)
+
+// IsValid reports whether d is a known search depth
+func (d SearchDepth) IsValid() bool {
+	_, ok := depthProfiles[d]
+	return ok
+}
+
+// Profile returns the search limits of d, or of DefaultSearchDepth when d is unknown
+func (d SearchDepth) Profile() DepthProfile {
+	if profile, ok := depthProfiles[d]; ok {
+		return profile
+	}
+	return depthProfiles[DefaultSearchDepth]
+}
+
 type ResearchSession struct {
 	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID       uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
@@ -18,6 +153,11 @@ type ResearchSession struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 	Query        string         `gorm:"not null" json:"query"` // Original search query
+	Status       SessionStatus  `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	StartedAt    *time.Time     `json:"started_at,omitempty"`                   // When the current run started
+	FinishedAt   *time.Time     `json:"finished_at,omitempty"`                  // When the last run finished
+	MaxSources   int            `gorm:"not null;default:20" json:"max_sources"` // Hard cap on the sources of a run
+	SearchDepth  SearchDepth    `gorm:"type:varchar(20);not null;default:'medium'" json:"search_depth"`
 
 	// Relationships
 	User      User       `gorm:"foreignKey:UserID" json:"user,omitempty"`
@@ -31,5 +171,14 @@ func (rs *ResearchSession) BeforeCreate(tx *gorm.DB) error {
 	if rs.ID == uuid.Nil {
 		rs.ID = uuid.New()
 	}
+	if rs.Status == "" {
+		rs.Status = SessionStatusPending
+	}
+	if rs.MaxSources == 0 {
+		rs.MaxSources = DefaultMaxSources
+	}
+	if rs.SearchDepth == "" {
+		rs.SearchDepth = DefaultSearchDepth
+	}
 	return nil
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSessionStatusTransitions(t *testing.T) {
+	allowed := map[SessionStatus][]SessionStatus{
+		SessionStatusPending:   {SessionStatusRunning, SessionStatusCancelled},
+		SessionStatusRunning:   {SessionStatusCompleted, SessionStatusFailed, SessionStatusCancelled},
+		SessionStatusCompleted: {SessionStatusPending},
+		SessionStatusFailed:    {SessionStatusPending},
+		SessionStatusCancelled: {SessionStatusPending},
+	}
+
+	// Every pair of statuses, so a transition added by mistake fails too
+	for _, from := range SessionStatuses {
+		for _, to := range SessionStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				want = want || next == to
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s allowed = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestSessionStatus(t *testing.T) {
+	tests := []struct {
+		status   SessionStatus
+		valid    bool
+		terminal bool
+		previous []SessionStatus
+	}{
+		{status: SessionStatusPending, valid: true, previous: []SessionStatus{SessionStatusCompleted, SessionStatusFailed, SessionStatusCancelled}},
+		{status: SessionStatusRunning, valid: true, previous: []SessionStatus{SessionStatusPending}},
+		{status: SessionStatusCompleted, valid: true, terminal: true, previous: []SessionStatus{SessionStatusRunning}},
+		{status: SessionStatusCancelled, valid: true, terminal: true, previous: []SessionStatus{SessionStatusPending, SessionStatusRunning}},
+		{status: "paused"},
+		{status: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.IsValid(); got != tt.valid {
+				t.Errorf("IsValid = %v, want %v", got, tt.valid)
+			}
+			if got := tt.status.IsTerminal(); got != tt.terminal {
+				t.Errorf("IsTerminal = %v, want %v", got, tt.terminal)
+			}
+			previous := tt.status.PreviousStatuses()
+			if strings.Join(statusNames(previous), ",") != strings.Join(statusNames(tt.previous), ",") {
+				t.Errorf("PreviousStatuses = %v, want %v", previous, tt.previous)
+			}
+		})
+	}
+}
+
+func TestTransitionError(t *testing.T) {
+	err := TransitionError(SessionStatusCompleted, SessionStatusRunning)
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("TransitionError does not wrap ErrInvalidStatusTransition: %v", err)
+	}
+	if want := "invalid status transition from completed to running"; err.Error() != want {
+		t.Errorf("error = %q, want %q", err, want)
+	}
+
+	// The message of unknown statuses lists every status a client may send
+	for _, status := range SessionStatuses {
+		if !strings.Contains(ErrInvalidSessionStatus.Error(), string(status)) {
+			t.Errorf("ErrInvalidSessionStatus does not mention %s", status)
+		}
+	}
+}
+
+func statusNames(statuses []SessionStatus) []string {
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return names
+}
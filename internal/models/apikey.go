@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyScope limits what requests an API key may make
+type APIKeyScope string
+
+const (
+	ScopeRead  APIKeyScope = "read"  // GET requests
+	ScopeWrite APIKeyScope = "write" // Requests that change data or start research
+	ScopeAdmin APIKeyScope = "admin" // Admin routes; only admins can grant it
+)
+
+// APIKeyScopes lists every scope
+var APIKeyScopes = []APIKeyScope{ScopeRead, ScopeWrite, ScopeAdmin}
+
+// IsValid reports whether s is a known scope
+func (s APIKeyScope) IsValid() bool {
+	for _, known := range APIKeyScopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a personal key that authenticates scripts as its user. Only the
+// SHA-256 hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"` // First characters of the key
+	KeyHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     string     `gorm:"type:text;not null" json:"scopes"` // JSON array of APIKeyScope
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `gorm:"index" json:"expires_at,omitempty"` // Never expires when nil
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// ScopeList returns the scopes stored in Scopes
+func (k *APIKey) ScopeList() []APIKeyScope {
+	var scopes []APIKeyScope
+	if k.Scopes != "" {
+		_ = json.Unmarshal([]byte(k.Scopes), &scopes)
+	}
+	return scopes
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive reports whether the key is neither revoked nor expired at now
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
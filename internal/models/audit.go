@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditAction identifies what an audit event records
+type AuditAction string
+
+const (
+	AuditLoginSucceeded AuditAction = "login.succeeded"
+	AuditLoginFailed    AuditAction = "login.failed"
+	AuditLoginLocked    AuditAction = "login.locked" // Rejected without checking the password
+)
+
+// Sign-in methods recorded with login events. Identity provider logins are
+// recorded as LoginMethodOIDC followed by the provider name.
+const (
+	LoginMethodPassword = "password"
+	LoginMethodOIDC     = "oidc:"
+)
+
+// IsValid reports whether the action is known
+func (a AuditAction) IsValid() bool {
+	switch a {
+	case AuditLoginSucceeded, AuditLoginFailed, AuditLoginLocked:
+		return true
+	}
+	return false
+}
+
+// AuditEvent records a security-relevant action, such as a sign-in attempt.
+// Email is the address the client gave, which may not belong to any user.
+type AuditEvent struct {
+	ID        uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Action    AuditAction `gorm:"not null;index" json:"action"`
+	Method    string      `json:"method,omitempty"` // How the user signed in, such as password or oidc:google
+	UserID    *uuid.UUID  `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Email     string      `gorm:"index" json:"email,omitempty"`
+	IP        string      `gorm:"index" json:"ip"`
+	UserAgent string      `json:"user_agent,omitempty"`
+	Detail    string      `json:"detail,omitempty"` // Why an attempt failed or was locked out
+	CreatedAt time.Time   `gorm:"index" json:"created_at"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (e *AuditEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
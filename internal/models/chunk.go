@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentChunk is an overlapping passage of a document with its embedding
+type DocumentChunk struct {
+	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SessionID      uuid.UUID `gorm:"type:uuid;not null;index" json:"session_id"`
+	DocumentID     uuid.UUID `gorm:"type:uuid;not null;index" json:"document_id"`
+	Index          int       `gorm:"not null" json:"index"` // Position of the chunk within the document
+	Content        string    `gorm:"type:text;not null" json:"content"`
+	StartOffset    int       `gorm:"not null" json:"start_offset"` // Byte offsets of Content within Document.Content
+	EndOffset      int       `gorm:"not null" json:"end_offset"`
+	TokenCount     int       `json:"token_count"`
+	Embedding      Vector    `gorm:"type:vector" json:"-"`
+	EmbeddingModel string    `gorm:"index" json:"embedding_model"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relationships
+	Session  ResearchSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Document Document        `gorm:"foreignKey:DocumentID" json:"document,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *DocumentChunk) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Citation links an inline marker such as [1] in an assistant message to the
+// passage it cites. Offsets are byte offsets: Position into Message.Content,
+// StartOffset and EndOffset into Document.Content.
+type Citation struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	MessageID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"message_id"`
+	Marker      int        `gorm:"not null" json:"marker"`   // The n of the [n] marker
+	Position    int        `gorm:"not null" json:"position"` // Where the marker appears in the message
+	Claim       string     `gorm:"type:text" json:"claim"`   // The sentence the marker supports
+	SourceID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"source_id"`
+	DocumentID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"document_id"`
+	ChunkID     *uuid.UUID `gorm:"type:uuid;index" json:"chunk_id,omitempty"` // Set when the answer was grounded in retrieved passages
+	StartOffset int        `gorm:"not null" json:"start_offset"`
+	EndOffset   int        `gorm:"not null" json:"end_offset"`
+	Quote       string     `gorm:"type:text" json:"quote"` // The cited text, truncated for long spans
+	CreatedAt   time.Time  `json:"created_at"`
+
+	// Relationships
+	Message  Message  `gorm:"foreignKey:MessageID" json:"message,omitempty"`
+	Source   Source   `gorm:"foreignKey:SourceID" json:"source,omitempty"`
+	Document Document `gorm:"foreignKey:DocumentID" json:"document,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (c *Citation) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
@@ -16,8 +16,12 @@ type Document struct {
 	Content     string    `gorm:"type:text;not null" json:"content"`
 	ContentType string    `gorm:"not null" json:"content_type"` // html, pdf, markdown, plain_text
 	WordCount   int       `json:"word_count"`
+	PageCount   int       `gorm:"default:0" json:"page_count"` // Pages are separated by form feeds in Content
+	Abstract    string    `gorm:"type:text" json:"abstract"`   // Abstract of papers, when one was found
 	Language    string    `gorm:"default:'en'" json:"language"`
-	Relevance   float64   `gorm:"default:0.0" json:"relevance"` // AI-calculated relevance score 0-1
+	Relevance   float64   `gorm:"default:0.0" json:"relevance"`           // AI-calculated relevance score 0-1
+	Scores      string    `gorm:"type:jsonb;default:'{}'" json:"scores"`  // Components the relevance was combined from
+	DropReason  string    `gorm:"type:text" json:"drop_reason,omitempty"` // Why the document was left out of synthesis
 	ProcessedAt time.Time `json:"processed_at"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -32,6 +36,9 @@ func (d *Document) BeforeCreate(tx *gorm.DB) error {
 	if d.ID == uuid.Nil {
 		d.ID = uuid.New()
 	}
+	if d.Scores == "" {
+		d.Scores = "{}"
+	}
 	if d.ProcessedAt.IsZero() {
 		d.ProcessedAt = time.Now()
 	}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionEvent is a research event stored for replay. IDs come from a database
+// sequence, so they are unique across every process sharing the database and
+// increase within a session. Token events are not stored.
+type SessionEvent struct {
+	ID        uint64            `gorm:"primaryKey;autoIncrement;index:idx_session_events_replay,priority:2" json:"id"`
+	SessionID uuid.UUID         `gorm:"type:uuid;not null;index:idx_session_events_replay,priority:1" json:"session_id"`
+	Type      ResearchEventType `gorm:"type:varchar(40);not null" json:"type"`
+	Payload   string            `gorm:"type:jsonb;not null" json:"payload"` // The event as sent to clients, without its ID
+	CreatedAt time.Time         `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a user to an account at an external identity provider
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider    string    `gorm:"not null" json:"provider"` // Configured provider name
+	Issuer      string    `gorm:"not null;uniqueIndex:idx_identity_subject" json:"issuer"`
+	Subject     string    `gorm:"not null;uniqueIndex:idx_identity_subject" json:"subject"`
+	Email       string    `json:"email"` // Address the provider last reported
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// BeforeCreate will set a UUID rather than numeric ID
+func (i *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// OIDCLoginState holds what the callback of a login at an identity provider
+// needs to finish it. Each state is used once, and only by the client that
+// started the login, which holds the secret BindingHash is the hash of.
+type OIDCLoginState struct {
+	State        string    `gorm:"primaryKey" json:"-"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"` // PKCE verifier
+	BindingHash  string    `gorm:"not null;default:''" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// OIDCLoginCode is a short-lived, single-use code the web app redeems for
+// tokens after an identity provider login finished in the browser, so that
+// tokens never appear in a URL. Only the hash of the code is stored.
+type OIDCLoginCode struct {
+	CodeHash  string    `gorm:"primaryKey" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// ResearchJob is a queued research run for a session.
+// Workers claim jobs with SELECT ... FOR UPDATE SKIP LOCKED; a session has at most one active job.
+type ResearchJob struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SessionID       uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_research_jobs_active_session,where:status = 'queued' OR status = 'running'" json:"session_id"`
+	MessageID       *uuid.UUID `gorm:"type:uuid" json:"message_id,omitempty"` // User message that requested the run
+	ReplyID         *uuid.UUID `gorm:"type:uuid" json:"reply_id,omitempty"`   // Assistant message every attempt writes its answer to
+	Query           string     `gorm:"type:text;not null" json:"query"`
+	Status          JobStatus  `gorm:"type:varchar(20);not null;default:'queued';index:idx_research_jobs_claim,priority:1" json:"status"`
+	RunAt           time.Time  `gorm:"not null;index:idx_research_jobs_claim,priority:2" json:"run_at"` // Earliest time a worker may claim the job
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts     int        `gorm:"not null;default:3" json:"max_attempts"`
+	LockedBy        string     `json:"locked_by,omitempty"` // Worker running the job
+	HeartbeatAt     *time.Time `json:"heartbeat_at,omitempty"`
+	CancelRequested bool       `gorm:"not null;default:false" json:"cancel_requested"`
+	LastError       string     `gorm:"type:text" json:"last_error,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relationships
+	Session ResearchSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+}
+
+// BeforeCreate will set UUIDs and timestamps
+func (j *ResearchJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	if j.RunAt.IsZero() {
+		j.RunAt = time.Now()
+	}
+	if j.Status == "" {
+		j.Status = JobStatusQueued
+	}
+	return nil
+}
@@ -21,14 +21,16 @@ type Message struct {
 	SessionID uuid.UUID   `gorm:"type:uuid;not null;index" json:"session_id"`
 	Type      MessageType `gorm:"not null" json:"type"`
 	Content   string      `gorm:"type:text;not null" json:"content"`
-	IsVisible bool        `gorm:"default:true" json:"is_visible"` // Whether to show in chat UI
+	IsVisible bool        `gorm:"default:true" json:"is_visible"`               // Whether to show in chat UI
+	ReplyToID *uuid.UUID  `gorm:"type:uuid;index" json:"reply_to_id,omitempty"` // User message an assistant reply answers
 	CreatedAt time.Time   `json:"created_at"`
 	UpdatedAt time.Time   `json:"updated_at"`
 
 	// Relationships
-	Session  ResearchSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
-	Thoughts []Thought       `gorm:"foreignKey:MessageID" json:"thoughts,omitempty"`
-	Sources  []Source        `gorm:"many2many:message_sources" json:"sources,omitempty"` // Many-to-many with sources
+	Session   ResearchSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
+	Thoughts  []Thought       `gorm:"foreignKey:MessageID" json:"thoughts,omitempty"`
+	Sources   []Source        `gorm:"many2many:message_sources" json:"sources,omitempty"` // Many-to-many with sources
+	Citations []Citation      `gorm:"foreignKey:MessageID" json:"citations,omitempty"`    // Inline [n] markers resolved to passages
 }
 
 // BeforeCreate will set UUIDs and timestamps
@@ -9,5 +9,25 @@ func AllModels() []interface{} {
 		&Thought{},
 		&Source{},
 		&Document{},
+		&Citation{},
+		&Summary{},
+		&ResearchJob{},
+		&RefreshToken{},
+		&RevokedToken{},
+		&ActionToken{},
+		&APIKey{},
+		&UserIdentity{},
+		&OIDCLoginState{},
+		&OIDCLoginCode{},
+		&AuditEvent{},
+		&SessionEvent{},
+	}
+}
+
+// VectorModels returns the models that need the pgvector extension. They are
+// only migrated when the pgvector retrieval store is configured.
+func VectorModels() []interface{} {
+	return []interface{}{
+		&DocumentChunk{},
 	}
 }
@@ -25,41 +25,193 @@ type AuthRequest struct {
 
 // AuthResponse represents login/register response
 type AuthResponse struct {
-	Token     string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	User      UserInfo  `json:"user"`
-	ExpiresAt time.Time `json:"expires_at" example:"2025-06-08T01:11:28Z"`
+	Token            string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken     string    `json:"refresh_token" example:"bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"` // Single use; exchange it at /auth/refresh for a new pair
+	User             UserInfo  `json:"user"`
+	ExpiresAt        time.Time `json:"expires_at" example:"2025-06-07T01:26:28Z"` // When the access token expires
+	RefreshExpiresAt time.Time `json:"refresh_expires_at" example:"2025-07-07T01:11:28Z"`
 } // @name AuthResponse
 
+// RefreshRequest represents a request to renew an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"`
+} // @name RefreshRequest
+
+// LogoutRequest represents a logout request
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty" example:"bG9uZy1yYW5kb20tcmVmcmVzaC10b2tlbg"` // Revoked with the access token when given
+} // @name LogoutRequest
+
+// VerifyEmailRequest represents a request to verify an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // From the verification email
+} // @name VerifyEmailRequest
+
+// ForgotPasswordRequest represents a request for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+} // @name ForgotPasswordRequest
+
+// ResetPasswordRequest represents a request to set a new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // From the password reset email
+	Password string `json:"password" binding:"required,min=6" example:"newpassword123"`
+} // @name ResetPasswordRequest
+
+// OIDCProvidersResponse lists the identity providers users can sign in with
+type OIDCProvidersResponse struct {
+	Providers []string `json:"providers" example:"google,okta"`
+} // @name OIDCProvidersResponse
+
+// OIDCLoginResponse represents the identity provider URL a login continues at
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.example.com/authorize?response_type=code&client_id=deepresearch"`
+	Binding          string `json:"binding" example:"Yc2vB1mL0sQh7kXxW3d9n4tR6pZfJ8uA5eGiOqKwN2M"` // Secret the app sends with the callback
+} // @name OIDCLoginResponse
+
+// OIDCCallbackRequest represents the parameters an identity provider redirected back with
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required" example:"SplxlOBeZQQYbYS6WxSbIA"`
+	State string `json:"state" binding:"required" example:"af0ifjsldkj"`
+	// Binding returned when the login started; may be left out when the login cookie is sent
+	Binding string `json:"binding,omitempty" example:"Yc2vB1mL0sQh7kXxW3d9n4tR6pZfJ8uA5eGiOqKwN2M"`
+} // @name OIDCCallbackRequest
+
+// OIDCTokenRequest represents a login code to redeem for tokens
+type OIDCTokenRequest struct {
+	LoginCode string `json:"login_code" binding:"required" example:"q3Jm0dH8sLwT5bVx1kC9nE2rY7uPzA4fGiOoKeN6S_w"`
+} // @name OIDCTokenRequest
+
 // UserInfo represents user information
 type UserInfo struct {
-	ID    string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	Email string `json:"email" example:"user@example.com"`
-	Name  string `json:"name" example:"John Doe"`
-	Role  string `json:"role" example:"user"`
+	ID            string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email         string `json:"email" example:"user@example.com"`
+	Name          string `json:"name" example:"John Doe"`
+	Role          string `json:"role" example:"user" enums:"user,admin"`
+	EmailVerified bool   `json:"email_verified" example:"true"`
 } // @name UserInfo
 
+// CreateAPIKeyRequest represents a request to create an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100" example:"Analysis notebook"`
+	Scopes    []string   `json:"scopes,omitempty" enums:"read,write,admin" example:"read,write"` // Defaults to read and write
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`            // Never expires when omitted
+} // @name CreateAPIKeyRequest
+
+// UpdateAPIKeyRequest represents a request to rename an API key or change its scopes
+type UpdateAPIKeyRequest struct {
+	Name   string   `json:"name,omitempty" binding:"max=100" example:"Nightly report script"`
+	Scopes []string `json:"scopes,omitempty" enums:"read,write,admin" example:"read"`
+} // @name UpdateAPIKeyRequest
+
+// APIKeyResponse represents an API key without its secret
+type APIKeyResponse struct {
+	ID         string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name       string     `json:"name" example:"Analysis notebook"`
+	Prefix     string     `json:"prefix" example:"dr_Q2hhbmdl"` // First characters of the key
+	Scopes     []string   `json:"scopes" example:"read,write"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2025-06-08T09:00:00Z"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" example:"2025-06-09T09:00:00Z"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-06-07T01:11:28Z"`
+} // @name APIKeyResponse
+
+// APIKeyCreatedResponse represents a new API key, the only response that includes the key itself
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"dr_Q2hhbmdlIHRoaXMgdG8gYSByYW5kb20ga2V5"` // Send as "Authorization: ApiKey <key>"; it is not shown again
+} // @name APIKeyCreatedResponse
+
+// APIKeysListResponse represents the API keys of a user
+type APIKeysListResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+} // @name APIKeysListResponse
+
+// AdminUserResponse represents a user as administrators see it
+type AdminUserResponse struct {
+	ID              string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email           string     `json:"email" example:"user@example.com"`
+	Name            string     `json:"name" example:"John Doe"`
+	Role            string     `json:"role" example:"user" enums:"user,admin"`
+	IsEmailVerified bool       `json:"is_email_verified" example:"true"`
+	IsDisabled      bool       `json:"is_disabled" example:"false"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty" example:"2025-06-08T09:00:00Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2025-06-07T01:11:28Z"`
+} // @name AdminUserResponse
+
+// AdminUsersListResponse represents a page of users
+type AdminUsersListResponse struct {
+	Users      []AdminUserResponse `json:"users"`
+	Total      int                 `json:"total" example:"42"`
+	Page       int                 `json:"page" example:"1"`
+	PerPage    int                 `json:"per_page" example:"20"`
+	TotalPages int                 `json:"total_pages" example:"3"`
+} // @name AdminUsersListResponse
+
+// AuditEventResponse represents an audit log entry
+type AuditEventResponse struct {
+	ID        string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Action    string    `json:"action" example:"login.failed" enums:"login.succeeded,login.failed,login.locked"`
+	Method    string    `json:"method,omitempty" example:"password"`
+	UserID    *string   `json:"user_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Email     string    `json:"email,omitempty" example:"user@example.com"`
+	IP        string    `json:"ip" example:"203.0.113.7"`
+	UserAgent string    `json:"user_agent,omitempty" example:"Mozilla/5.0"`
+	Detail    string    `json:"detail,omitempty" example:"invalid credentials"`
+	CreatedAt time.Time `json:"created_at" example:"2025-06-07T01:11:28Z"`
+} // @name AuditEventResponse
+
+// AuditEventsListResponse represents a page of audit events
+type AuditEventsListResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	Total      int                  `json:"total" example:"42"`
+	Page       int                  `json:"page" example:"1"`
+	PerPage    int                  `json:"per_page" example:"20"`
+	TotalPages int                  `json:"total_pages" example:"3"`
+} // @name AuditEventsListResponse
+
+// UpdateRoleRequest represents a request to change a user's role
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"admin" enums:"user,admin"`
+} // @name UpdateRoleRequest
+
 // CreateSessionRequest represents request to create a research session
 type CreateSessionRequest struct {
 	Query       string   `json:"query" binding:"required" example:"What are the latest developments in AI?"`
 	Title       string   `json:"title,omitempty" example:"AI Research Session"`
 	Tags        []string `json:"tags,omitempty" example:"ai,research,technology"`
-	MaxSources  int      `json:"max_sources,omitempty" example:"10"`
-	SearchDepth string   `json:"search_depth,omitempty" example:"deep" enums:"shallow,medium,deep"`
+	MaxSources  int      `json:"max_sources,omitempty" example:"10" minimum:"1" maximum:"100"`      // Defaults to 20
+	SearchDepth string   `json:"search_depth,omitempty" example:"deep" enums:"shallow,medium,deep"` // Defaults to medium
 } // @name CreateSessionRequest
 
 // SessionResponse represents a research session response
 type SessionResponse struct {
-	ID           string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	UserID       string    `json:"user_id" example:"456e7890-e89b-12d3-a456-426614174001"`
-	Title        string    `json:"title" example:"AI Research Session"`
-	Query        string    `json:"query" example:"What are the latest developments in AI?"`
-	Status       string    `json:"status" example:"active" enums:"active,completed,failed"`
-	MessageCount int       `json:"message_count" example:"5"`
-	CreatedAt    time.Time `json:"created_at" example:"2025-06-07T01:11:28Z"`
-	UpdatedAt    time.Time `json:"updated_at" example:"2025-06-07T01:15:28Z"`
-	Tags         []string  `json:"tags" example:"ai,research,technology"`
+	ID           string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	UserID       string     `json:"user_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	Title        string     `json:"title" example:"AI Research Session"`
+	Query        string     `json:"query" example:"What are the latest developments in AI?"`
+	Status       string     `json:"status" example:"running" enums:"pending,running,completed,failed,cancelled"`
+	MessageCount int        `json:"message_count" example:"5"`
+	CreatedAt    time.Time  `json:"created_at" example:"2025-06-07T01:11:28Z"`
+	UpdatedAt    time.Time  `json:"updated_at" example:"2025-06-07T01:15:28Z"`
+	StartedAt    *time.Time `json:"started_at,omitempty" example:"2025-06-07T01:12:00Z"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" example:"2025-06-07T01:14:10Z"`
+	Tags         []string   `json:"tags" example:"ai,research,technology"`
+	MaxSources   int        `json:"max_sources" example:"10"`
+	SearchDepth  string     `json:"search_depth" example:"deep" enums:"shallow,medium,deep"`
 } // @name SessionResponse
 
+// SessionStatsResponse represents the number of sessions in each status
+type SessionStatsResponse struct {
+	Total     int64 `json:"total" example:"25"`
+	Active    int64 `json:"active" example:"3"` // Pending or running
+	Pending   int64 `json:"pending" example:"1"`
+	Running   int64 `json:"running" example:"2"`
+	Completed int64 `json:"completed" example:"19"`
+	Failed    int64 `json:"failed" example:"2"`
+	Cancelled int64 `json:"cancelled" example:"1"`
+} // @name SessionStatsResponse
+
 // SessionsListResponse represents list of sessions response
 type SessionsListResponse struct {
 	Sessions   []SessionResponse `json:"sessions"`
@@ -82,4 +234,165 @@ type ResearchProgressEvent struct {
 	Timestamp string `json:"timestamp" example:"2025-06-07T01:11:28Z"`
 	Sources   int    `json:"sources,omitempty" example:"3"`
 	Status    string `json:"status" example:"processing" enums:"processing,completed,error"`
+	Type      string `json:"type,omitempty" example:"searching" enums:"searching,analyzing,synthesizing,validating,completed,error"`
 } // @name ResearchProgressEvent
+
+// SummaryResponse represents a stored summary of a research session
+type SummaryResponse struct {
+	ID              string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	SessionID       string    `json:"session_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	Type            string    `json:"type" example:"key_points" enums:"overview,detailed,key_points,conclusion"`
+	Title           string    `json:"title" example:"Key points"`
+	Content         string    `json:"content" example:"- Surface codes tolerate error rates near 1% [1]"`
+	KeyPoints       []string  `json:"key_points" example:"Surface codes tolerate error rates near 1% [1]"`
+	SourcesUsed     int       `json:"sources_used" example:"6"`
+	ConfidenceScore float64   `json:"confidence_score" example:"0.82"`
+	GeneratedBy     string    `json:"generated_by" example:"ai" enums:"ai,extractive,human"`
+	GeneratedAt     time.Time `json:"generated_at" example:"2025-06-07T01:14:10Z"`
+} // @name SummaryResponse
+
+// SummariesListResponse represents the summaries of a session
+type SummariesListResponse struct {
+	Summaries []SummaryResponse `json:"summaries"`
+} // @name SummariesListResponse
+
+// ChunkResponse represents a retrieved passage of a document
+type ChunkResponse struct {
+	ID          string  `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	DocumentID  string  `json:"document_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	SourceID    string  `json:"source_id" example:"789e0123-e89b-12d3-a456-426614174002"`
+	Title       string  `json:"title" example:"Quantum Error Correction Below the Surface Code Threshold"`
+	URL         string  `json:"url" example:"https://arxiv.org/abs/2401.00001"`
+	Index       int     `json:"index" example:"3"`
+	Content     string  `json:"content" example:"Logical error rates fell by a factor of 2.14 when the code distance increased..."`
+	StartOffset int     `json:"start_offset" example:"5120"`
+	EndOffset   int     `json:"end_offset" example:"6733"`
+	Score       float64 `json:"score" example:"0.83"`
+} // @name ChunkResponse
+
+// RetrievalResponse represents the passages retrieved for a question
+type RetrievalResponse struct {
+	Query  string          `json:"query" example:"How much did logical error rates improve?"`
+	Chunks []ChunkResponse `json:"chunks"`
+} // @name RetrievalResponse
+
+// JobResponse represents a queued or running research job
+type JobResponse struct {
+	ID              string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	SessionID       string     `json:"session_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	Status          string     `json:"status" example:"running" enums:"queued,running,succeeded,failed,cancelled"`
+	Attempts        int        `json:"attempts" example:"1"`
+	MaxAttempts     int        `json:"max_attempts" example:"3"`
+	CancelRequested bool       `json:"cancel_requested" example:"true"`
+	LastError       string     `json:"last_error,omitempty" example:"Finding relevant sources: no sources found for query"`
+	CreatedAt       time.Time  `json:"created_at" example:"2025-06-07T01:11:28Z"`
+	StartedAt       *time.Time `json:"started_at,omitempty" example:"2025-06-07T01:11:30Z"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty" example:"2025-06-07T01:12:10Z"`
+} // @name JobResponse
+
+// ResearchEventType identifies the kind of a research event
+type ResearchEventType string
+
+const (
+	ResearchEventThoughtStarted    ResearchEventType = "thought_started"
+	ResearchEventThoughtProgress   ResearchEventType = "thought_progress"
+	ResearchEventSourceFound       ResearchEventType = "source_found"
+	ResearchEventDocumentProcessed ResearchEventType = "document_processed"
+	ResearchEventToken             ResearchEventType = "token"
+	ResearchEventClaimVerified     ResearchEventType = "claim_verified"
+	ResearchEventAnswerRevised     ResearchEventType = "answer_revised"
+	ResearchEventCompleted         ResearchEventType = "completed"
+	ResearchEventRetrying          ResearchEventType = "retrying" // An attempt failed and the run will be tried again
+	ResearchEventFailed            ResearchEventType = "failed"
+)
+
+// ResearchEvent represents a typed event emitted by a session's research run.
+// IDs increase monotonically and can be sent back as Last-Event-ID to resume a stream.
+type ResearchEvent struct {
+	ID          uint64            `json:"id" example:"42"`
+	Type        ResearchEventType `json:"type" example:"thought_started" enums:"thought_started,thought_progress,source_found,document_processed,token,claim_verified,answer_revised,completed,retrying,failed"`
+	SessionID   string            `json:"session_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	MessageID   string            `json:"message_id,omitempty" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ThoughtID   string            `json:"thought_id,omitempty" example:"789e0123-e89b-12d3-a456-426614174002"`
+	ThoughtType string            `json:"thought_type,omitempty" example:"searching" enums:"searching,analyzing,synthesizing,validating,completed,error"`
+	Title       string            `json:"title,omitempty" example:"Finding relevant sources"`
+	Content     string            `json:"content,omitempty" example:"Found 8 sources"`
+	Progress    int               `json:"progress,omitempty" example:"30"`
+	SourceID    string            `json:"source_id,omitempty" example:"abc12345-e89b-12d3-a456-426614174003"`
+	DocumentID  string            `json:"document_id,omitempty" example:"def67890-e89b-12d3-a456-426614174004"`
+	URL         string            `json:"url,omitempty" example:"https://arxiv.org/abs/2401.00001"`
+	Delta       string            `json:"delta,omitempty" example:"Surface codes"`
+	Claim       string            `json:"claim,omitempty" example:"Surface codes tolerate error rates near 1%"`
+	Verdict     string            `json:"verdict,omitempty" example:"supported" enums:"supported,contradicted,unsupported"`
+	Error       string            `json:"error,omitempty" example:"no sources found for query"`
+	Timestamp   string            `json:"timestamp" example:"2025-06-07T01:11:28Z"`
+} // @name ResearchEvent
+
+// CreateMessageRequest represents a user turn in a research session
+type CreateMessageRequest struct {
+	Content string `json:"content" binding:"required" example:"How do error-correcting codes work on quantum hardware?"`
+} // @name CreateMessageRequest
+
+// ThoughtResponse represents an intermediate research step
+type ThoughtResponse struct {
+	ID          string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Type        string     `json:"type" example:"searching" enums:"searching,analyzing,synthesizing,validating,completed,error"`
+	Title       string     `json:"title" example:"Finding relevant sources"`
+	Content     string     `json:"content" example:"Found 8 sources"`
+	Status      string     `json:"status" example:"completed" enums:"processing,completed,failed"`
+	Progress    int        `json:"progress" example:"100"`
+	Metadata    string     `json:"metadata,omitempty" example:"{\"queries\":[\"quantum error correction\"]}"`
+	StartedAt   time.Time  `json:"started_at" example:"2025-06-07T01:11:28Z"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" example:"2025-06-07T01:11:30Z"`
+} // @name ThoughtResponse
+
+// SourceResponse represents a source used in a research session
+type SourceResponse struct {
+	ID          string     `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	URL         string     `json:"url" example:"https://arxiv.org/abs/2401.00001"`
+	Type        string     `json:"type" example:"academic_paper" enums:"website,pdf,academic_paper,news_article"`
+	Domain      string     `json:"domain" example:"arxiv.org"`
+	Title       string     `json:"title" example:"Quantum Error Correction Below the Surface Code Threshold"`
+	Description string     `json:"description" example:"We report an experiment..."`
+	LastCrawled *time.Time `json:"last_crawled,omitempty" example:"2025-06-07T01:11:30Z"`
+	Authors     []string   `json:"authors,omitempty" example:"Jane Smith,Sam Lee"`
+	PublishedAt *time.Time `json:"published_at,omitempty" example:"2024-12-09T00:00:00Z"`
+	DOI         string     `json:"doi,omitempty" example:"10.1038/s41586-024-08449-y"`
+	Publisher   string     `json:"publisher,omitempty" example:"Nature Publishing Group"`
+	Journal     string     `json:"journal,omitempty" example:"Nature"`
+} // @name SourceResponse
+
+// MessageResponse represents a chat message with its research trail
+type MessageResponse struct {
+	ID        string             `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	SessionID string             `json:"session_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	Type      string             `json:"type" example:"assistant" enums:"user,assistant,system"`
+	Content   string             `json:"content" example:"Surface codes protect logical qubits by... [1]"`
+	ReplyToID string             `json:"reply_to_id,omitempty" example:"789e0123-e89b-12d3-a456-426614174002"` // User message an assistant reply answers
+	Thoughts  []ThoughtResponse  `json:"thoughts"`
+	Sources   []SourceResponse   `json:"sources"`
+	Citations []CitationResponse `json:"citations"`
+	CreatedAt time.Time          `json:"created_at" example:"2025-06-07T01:11:28Z"`
+} // @name MessageResponse
+
+// CitationResponse links an inline [n] marker of a message to the passage it cites.
+// Offsets are byte offsets into the message content and the document content.
+type CitationResponse struct {
+	ID          string `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Marker      int    `json:"marker" example:"1"`
+	Position    int    `json:"position" example:"42"`
+	Claim       string `json:"claim" example:"Surface codes protect logical qubits by spreading them over many physical qubits"`
+	SourceID    string `json:"source_id" example:"456e7890-e89b-12d3-a456-426614174001"`
+	DocumentID  string `json:"document_id" example:"789e0123-e89b-12d3-a456-426614174002"`
+	ChunkID     string `json:"chunk_id,omitempty" example:"012e3456-e89b-12d3-a456-426614174003"`
+	StartOffset int    `json:"start_offset" example:"5120"`
+	EndOffset   int    `json:"end_offset" example:"5236"`
+	Quote       string `json:"quote" example:"A logical qubit is encoded in a lattice of physical qubits."`
+} // @name CitationResponse
+
+// MessagesListResponse represents a page of messages
+type MessagesListResponse struct {
+	Messages   []MessageResponse `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty" example:"MjAyNS0wNi0wN1QwMToxMToyOFp8MTIz"`
+	HasMore    bool              `json:"has_more" example:"false"`
+} // @name MessagesListResponse
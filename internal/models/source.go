@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,15 +11,20 @@ import (
 // Source represents an external source we can crawl
 type Source struct {
 	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SessionID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"session_id"`
-	URL         string     `gorm:"uniqueIndex;not null" json:"url"`
-	Type        string     `gorm:"not null" json:"type"` // website, pdf, academic_paper, news_article
+	SessionID   uuid.UUID  `gorm:"type:uuid;not null;index;uniqueIndex:idx_sources_session_url" json:"session_id"`
+	URL         string     `gorm:"uniqueIndex:idx_sources_session_url;not null" json:"url"` // Normalized URL, unique per session
+	Type        string     `gorm:"not null" json:"type"`                                    // website, pdf, academic_paper, news_article
 	Domain      string     `gorm:"index" json:"domain"`
 	Title       string     `json:"title"`
 	Description string     `gorm:"type:text" json:"description"`
 	Language    string     `gorm:"default:'en'" json:"language"`
 	IsActive    bool       `gorm:"default:true" json:"is_active"`
 	LastCrawled *time.Time `json:"last_crawled,omitempty"`
+	Authors     string     `gorm:"type:text" json:"authors"`   // JSON array of author names, as the document gives them
+	PublishedAt *time.Time `json:"published_at,omitempty"`     // Publication date declared by the document or search result
+	DOI         string     `gorm:"index" json:"doi,omitempty"` // Bare DOI such as 10.1000/182
+	Publisher   string     `json:"publisher,omitempty"`        // Publisher or site name
+	Journal     string     `json:"journal,omitempty"`          // Journal, proceedings or other container title
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 
@@ -35,3 +41,12 @@ func (s *Source) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// AuthorNames returns the authors stored in Authors
+func (s *Source) AuthorNames() []string {
+	var names []string
+	if s.Authors != "" {
+		_ = json.Unmarshal([]byte(s.Authors), &names)
+	}
+	return names
+}
@@ -7,20 +7,48 @@ import (
 	"gorm.io/gorm"
 )
 
-// Summary represents AI-generated summaries and insights
+type SummaryType string
+
+const (
+	SummaryTypeOverview   SummaryType = "overview"
+	SummaryTypeDetailed   SummaryType = "detailed"
+	SummaryTypeKeyPoints  SummaryType = "key_points"
+	SummaryTypeConclusion SummaryType = "conclusion"
+)
+
+// SummaryTypes lists every summary type
+var SummaryTypes = []SummaryType{
+	SummaryTypeOverview,
+	SummaryTypeDetailed,
+	SummaryTypeKeyPoints,
+	SummaryTypeConclusion,
+}
+
+// IsValid reports whether t is a known summary type
+func (t SummaryType) IsValid() bool {
+	for _, known := range SummaryTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary represents AI-generated summaries and insights.
+// A session keeps one summary per type; regenerating replaces it.
 type Summary struct {
-	ID              uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	SessionID       uuid.UUID `gorm:"type:uuid;not null;index" json:"session_id"`
-	Type            string    `gorm:"not null" json:"type"` // overview, detailed, key_points, conclusion
-	Title           string    `gorm:"not null" json:"title"`
-	Content         string    `gorm:"type:text;not null" json:"content"`
-	KeyPoints       string    `gorm:"type:text" json:"key_points"` // JSON array of key points
-	SourcesUsed     int       `gorm:"default:0" json:"sources_used"`
-	ConfidenceScore float64   `gorm:"default:0.0" json:"confidence_score"` // 0-1
-	GeneratedBy     string    `gorm:"default:'ai'" json:"generated_by"`    // ai, human
-	GeneratedAt     time.Time `json:"generated_at"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SessionID       uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_summaries_session_type" json:"session_id"`
+	Type            SummaryType `gorm:"not null;uniqueIndex:idx_summaries_session_type" json:"type"` // overview, detailed, key_points, conclusion
+	Title           string      `gorm:"not null" json:"title"`
+	Content         string      `gorm:"type:text;not null" json:"content"`
+	KeyPoints       string      `gorm:"type:text" json:"key_points"` // JSON array of key points
+	SourcesUsed     int         `gorm:"default:0" json:"sources_used"`
+	ConfidenceScore float64     `gorm:"default:0.0" json:"confidence_score"` // 0-1
+	GeneratedBy     string      `gorm:"default:'ai'" json:"generated_by"`    // ai, extractive, human
+	GeneratedAt     time.Time   `json:"generated_at"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
 
 	// Relationships
 	Session ResearchSession `gorm:"foreignKey:SessionID" json:"session,omitempty"`
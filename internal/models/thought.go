@@ -18,6 +18,20 @@ const (
 	ThoughtTypeError        ThoughtType = "error"
 )
 
+// ClaimVerdict is the outcome of checking a claim of an answer against its sources
+type ClaimVerdict string
+
+const (
+	ClaimSupported    ClaimVerdict = "supported"
+	ClaimContradicted ClaimVerdict = "contradicted"
+	ClaimUnsupported  ClaimVerdict = "unsupported"
+)
+
+// IsValid reports whether v is a known verdict
+func (v ClaimVerdict) IsValid() bool {
+	return v == ClaimSupported || v == ClaimContradicted || v == ClaimUnsupported
+}
+
 // Thought represents intermediate processing steps during research
 type Thought struct {
 	ID          uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -13,6 +13,19 @@ const (
 	RoleAdmin UserRole = "admin"
 )
 
+// UserRoles lists every role
+var UserRoles = []UserRole{RoleUser, RoleAdmin}
+
+// IsValid reports whether r is a known role
+func (r UserRole) IsValid() bool {
+	for _, known := range UserRoles {
+		if r == known {
+			return true
+		}
+	}
+	return false
+}
+
 // User represents a user in the system
 type User struct {
     ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -21,6 +34,9 @@ type User struct {
     Name      string    `gorm:"not null" json:"name"`
 	Roles 	  UserRole `gorm:"default:'user'" json:"roles"`
 	IsEmailVerified bool          `gorm:"default:false"`
+	TokenVersion int `gorm:"not null;default:0" json:"-"` // Bumped to invalidate every access token issued before
+	IsDisabled bool `gorm:"not null;default:false" json:"is_disabled"` // Disabled users cannot sign in
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
     CreatedAt time.Time `json:"created_at"`
     UpdatedAt time.Time `json:"updated_at"`
     DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -35,4 +51,12 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
         u.ID = uuid.New()
     }
     return nil
-}
\ No newline at end of file
+}
+
+// Role returns the user's role, treating an unset role as RoleUser
+func (u *User) Role() UserRole {
+	if u.Roles == "" {
+		return RoleUser
+	}
+	return u.Roles
+}
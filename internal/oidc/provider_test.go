@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lolzone13/DeepResearch/internal/oidc/oidctest"
+)
+
+const (
+	testClientID    = "deepresearch"
+	testRedirectURL = "https://api.example.com/auth/oidc/test/callback"
+)
+
+// newTestProvider returns a provider for a fresh mock identity provider
+func newTestProvider(t *testing.T) (*Provider, *oidctest.Server) {
+	t.Helper()
+	idp := oidctest.NewServer(t, testClientID, testRedirectURL)
+	provider, err := NewProvider(Config{
+		Issuer:      idp.URL,
+		ClientID:    testClientID,
+		RedirectURL: testRedirectURL,
+		Scopes:      []string{"email", "profile"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, idp
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	provider, idp := newTestProvider(t)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(authURL, idp.URL+"/authorize?") {
+		t.Errorf("authorization URL %s is not the provider's endpoint", authURL)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+	}
+	for param, value := range want {
+		if got := parsed.Query().Get(param); got != value {
+			t.Errorf("%s = %q, want %q", param, got, value)
+		}
+	}
+}
+
+func TestLoginFlow(t *testing.T) {
+	tests := []struct {
+		name            string
+		tamper          func(claims jwt.MapClaims)
+		exchangeWith    string // PKCE verifier sent to the token endpoint; the login's own when empty
+		verifyNonce     string // Nonce expected of the ID token; the login's own when empty
+		wantExchangeErr bool
+		wantVerifyErr   string
+	}{
+		{name: "valid login"},
+		{name: "wrong PKCE verifier", exchangeWith: "another-verifier", wantExchangeErr: true},
+		{name: "nonce mismatch", verifyNonce: "another-nonce", wantVerifyErr: "nonce mismatch"},
+		{
+			name:          "token for another client",
+			tamper:        func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+			wantVerifyErr: "invalid id token",
+		},
+		{
+			name:          "token from another issuer",
+			tamper:        func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+			wantVerifyErr: "invalid id token",
+		},
+		{
+			name:          "expired token",
+			tamper:        func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+			wantVerifyErr: "invalid id token",
+		},
+		{
+			name:          "token without a subject",
+			tamper:        func(claims jwt.MapClaims) { delete(claims, "sub") },
+			wantVerifyErr: "no subject",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			provider, idp := newTestProvider(t)
+			idp.Tamper = tt.tamper
+
+			state, nonce, verifier := "state-"+t.Name(), "nonce-"+t.Name(), "verifier-"+t.Name()
+			authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+			if err != nil {
+				t.Fatalf("AuthCodeURL: %v", err)
+			}
+			callback := idp.Authorize(t, authURL)
+			if callback.Get("state") != state {
+				t.Fatalf("provider returned state %q, want %q", callback.Get("state"), state)
+			}
+
+			if tt.exchangeWith != "" {
+				verifier = tt.exchangeWith
+			}
+			token, err := provider.Exchange(ctx, callback.Get("code"), verifier)
+			if tt.wantExchangeErr {
+				if err == nil {
+					t.Fatal("Exchange succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+
+			// Codes work once
+			if _, err := provider.Exchange(ctx, callback.Get("code"), verifier); err == nil {
+				t.Error("second Exchange of the code succeeded")
+			}
+
+			if tt.verifyNonce != "" {
+				nonce = tt.verifyNonce
+			}
+			idToken, err := provider.VerifyIDToken(ctx, token.IDToken, nonce)
+			if tt.wantVerifyErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantVerifyErr) {
+					t.Fatalf("VerifyIDToken error = %v, want one containing %q", err, tt.wantVerifyErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyIDToken: %v", err)
+			}
+			if idToken.Issuer != idp.URL || idToken.Subject != idp.Subject || idToken.Email != idp.Email || !idToken.EmailVerified {
+				t.Errorf("claims = %+v", idToken)
+			}
+		})
+	}
+}
+
+func TestMetadataIssuerMismatch(t *testing.T) {
+	idp := oidctest.NewServer(t, testClientID, testRedirectURL)
+	provider, err := NewProvider(Config{Issuer: idp.URL + "/", ClientID: testClientID, RedirectURL: testRedirectURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.Metadata(context.Background()); err == nil {
+		t.Error("discovery document of another issuer was accepted")
+	}
+}
@@ -0,0 +1,114 @@
+// Package ranking scores documents for relevance to a research question
+package ranking
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 scores documents with the Okapi BM25 ranking function
+type BM25 struct {
+	K1 float64 // Term frequency saturation; 1.2 when zero
+	B  float64 // Length normalization; 0.75 when zero
+}
+
+// Scores returns the BM25 score of every document for the query, divided by the
+// best score so that the values fall in 0-1. IDF is computed over the given
+// documents, so scores are only comparable within one call.
+func (m BM25) Scores(query string, documents []string) []float64 {
+	k1, b := m.K1, m.B
+	if k1 <= 0 {
+		k1 = 1.2
+	}
+	if b <= 0 {
+		b = 0.75
+	}
+
+	scores := make([]float64, len(documents))
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 || len(documents) == 0 {
+		return scores
+	}
+
+	frequencies := make([]map[string]int, len(documents))
+	lengths := make([]int, len(documents))
+	totalLength := 0
+	containing := make(map[string]int, len(terms))
+	for i, doc := range documents {
+		tokens := Tokenize(doc)
+		lengths[i] = len(tokens)
+		totalLength += len(tokens)
+
+		frequencies[i] = make(map[string]int)
+		for _, token := range tokens {
+			frequencies[i][token]++
+		}
+		for _, term := range terms {
+			if frequencies[i][term] > 0 {
+				containing[term]++
+			}
+		}
+	}
+	if totalLength == 0 {
+		return scores
+	}
+	avgLength := float64(totalLength) / float64(len(documents))
+
+	n := float64(len(documents))
+	best := 0.0
+	for i := range documents {
+		for _, term := range terms {
+			tf := float64(frequencies[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(containing[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			scores[i] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[i])/avgLength))
+		}
+		best = math.Max(best, scores[i])
+	}
+
+	if best > 0 {
+		for i := range scores {
+			scores[i] /= best
+		}
+	}
+	return scores
+}
+
+// Tokenize returns the lower-cased words of a text, without stop words
+func Tokenize(text string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < 2 || stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	var terms []string
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true,
+	"in": true, "on": true, "at": true, "by": true, "for": true, "is": true, "are": true,
+	"was": true, "were": true, "be": true, "been": true, "it": true, "its": true, "as": true,
+	"with": true, "from": true, "that": true, "this": true, "what": true, "which": true,
+	"how": true, "why": true, "who": true, "when": true, "where": true, "does": true,
+	"do": true, "has": true, "have": true, "can": true, "about": true, "into": true,
+}
@@ -0,0 +1,48 @@
+package ranking
+
+import "strings"
+
+// DomainPriors scores how trustworthy a source's domain is, independent of the question
+type DomainPriors struct {
+	// Scores maps domains and suffixes such as ".edu" to a 0-1 prior.
+	// A domain also matches its subdomains; the longest match wins.
+	Scores  map[string]float64
+	Default float64 // Prior of unknown domains
+}
+
+// DefaultDomainPriors returns priors that favour academic, government and established news domains
+func DefaultDomainPriors() DomainPriors {
+	return DomainPriors{
+		Scores: map[string]float64{
+			".edu": 0.9, ".gov": 0.9, ".ac.uk": 0.9, ".int": 0.8,
+			"arxiv.org": 0.85, "doi.org": 0.9, "nature.com": 0.95, "science.org": 0.95,
+			"ncbi.nlm.nih.gov": 0.95, "pubmed.ncbi.nlm.nih.gov": 0.95, "sciencedirect.com": 0.9,
+			"springer.com": 0.9, "link.springer.com": 0.9, "wiley.com": 0.85, "ieee.org": 0.9,
+			"acm.org": 0.9, "jstor.org": 0.9, "plos.org": 0.85, "semanticscholar.org": 0.8,
+			"wikipedia.org": 0.7, "britannica.com": 0.75,
+			"reuters.com": 0.85, "apnews.com": 0.85, "bbc.co.uk": 0.8, "bbc.com": 0.8,
+			"nytimes.com": 0.8, "theguardian.com": 0.75, "ft.com": 0.8, "economist.com": 0.8,
+			"medium.com": 0.4, "substack.com": 0.4, "reddit.com": 0.3, "quora.com": 0.25,
+			"pinterest.com": 0.1, "facebook.com": 0.2, "twitter.com": 0.25, "x.com": 0.25,
+		},
+		Default: 0.5,
+	}
+}
+
+// Score returns the prior of a domain
+func (p DomainPriors) Score(domain string) float64 {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSuffix(domain, ".")), "www.")
+	if domain == "" {
+		return p.Default
+	}
+
+	best, bestLength := p.Default, 0
+	for key, score := range p.Scores {
+		key = strings.ToLower(key)
+		matched := domain == key || strings.HasSuffix(domain, "."+strings.TrimPrefix(key, "."))
+		if matched && len(key) > bestLength {
+			best, bestLength = score, len(key)
+		}
+	}
+	return best
+}
@@ -0,0 +1,180 @@
+package retrieval
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk window units
+const (
+	UnitTokens = "tokens"
+	UnitChars  = "chars"
+)
+
+// ChunkOptions configures how documents are split into passages
+type ChunkOptions struct {
+	Unit    string // UnitTokens (whitespace-separated words) or UnitChars
+	Size    int    // Window size in Unit
+	Overlap int    // Units shared by consecutive chunks
+}
+
+// DefaultChunkOptions returns sensible chunking defaults
+func DefaultChunkOptions() ChunkOptions {
+	return ChunkOptions{
+		Unit:    UnitTokens,
+		Size:    256,
+		Overlap: 32,
+	}
+}
+
+// Chunk is a passage of a text, located by byte offsets so that
+// text[Start:End] == Text
+type Chunk struct {
+	Index  int
+	Text   string
+	Start  int
+	End    int
+	Tokens int
+}
+
+var tokenPattern = regexp.MustCompile(`\S+`)
+
+// Split cuts text into overlapping chunks, filling unset options with defaults.
+// Character windows end at whitespace where possible so words are not cut in half.
+func Split(text string, opts ChunkOptions) []Chunk {
+	defaults := DefaultChunkOptions()
+	if opts.Unit == "" {
+		opts.Unit = defaults.Unit
+	}
+	if opts.Size <= 0 {
+		opts.Size = defaults.Size
+	}
+	if opts.Overlap < 0 || opts.Overlap >= opts.Size {
+		opts.Overlap = opts.Size / 8
+	}
+
+	var spans [][2]int
+	if opts.Unit == UnitChars {
+		spans = charWindows(text, opts.Size, opts.Overlap)
+	} else {
+		spans = tokenWindows(text, opts.Size, opts.Overlap)
+	}
+
+	chunks := make([]Chunk, 0, len(spans))
+	for _, span := range spans {
+		content := text[span[0]:span[1]]
+		chunks = append(chunks, Chunk{
+			Index:  len(chunks),
+			Text:   content,
+			Start:  span[0],
+			End:    span[1],
+			Tokens: len(tokenPattern.FindAllStringIndex(content, -1)),
+		})
+	}
+	return chunks
+}
+
+// tokenWindows returns windows of size words that share overlap words
+func tokenWindows(text string, size, overlap int) [][2]int {
+	tokens := tokenPattern.FindAllStringIndex(text, -1)
+	var spans [][2]int
+	for start := 0; start < len(tokens); start += size - overlap {
+		end := start + size
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		spans = append(spans, [2]int{tokens[start][0], tokens[end-1][1]})
+		if end == len(tokens) {
+			break
+		}
+	}
+	return spans
+}
+
+// charWindows returns windows of about size characters that share about overlap characters
+func charWindows(text string, size, overlap int) [][2]int {
+	var spans [][2]int
+	start := skipSpace(text, 0)
+	for start < len(text) {
+		end := advanceRunes(text, start, size)
+		if end < len(text) && !unicode.IsSpace(runeAt(text, end)) {
+			// Prefer ending at whitespace in the second half of the window
+			if cut := strings.LastIndexFunc(text[start:end], unicode.IsSpace); cut >= (end-start)/2 {
+				end = start + cut
+			}
+		}
+
+		span := [2]int{start, trimSpaceEnd(text, start, end)}
+		if span[1] > span[0] {
+			spans = append(spans, span)
+		}
+		if end >= len(text) {
+			break
+		}
+
+		// Step back by the overlap, then forward to the start of a word
+		next := retreatRunes(text, end, overlap)
+		if next <= start {
+			next = end
+		}
+		for next > start && next < end && !unicode.IsSpace(runeBefore(text, next)) {
+			next += runeLen(text, next)
+		}
+		start = skipSpace(text, next)
+	}
+	return spans
+}
+
+func advanceRunes(text string, pos, n int) int {
+	for ; n > 0 && pos < len(text); n-- {
+		pos += runeLen(text, pos)
+	}
+	return pos
+}
+
+func retreatRunes(text string, pos, n int) int {
+	for ; n > 0 && pos > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(text[:pos])
+		pos -= size
+	}
+	return pos
+}
+
+func runeLen(text string, pos int) int {
+	_, size := utf8.DecodeRuneInString(text[pos:])
+	return size
+}
+
+func runeAt(text string, pos int) rune {
+	r, _ := utf8.DecodeRuneInString(text[pos:])
+	return r
+}
+
+func runeBefore(text string, pos int) rune {
+	r, _ := utf8.DecodeLastRuneInString(text[:pos])
+	return r
+}
+
+func skipSpace(text string, pos int) int {
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+func trimSpaceEnd(text string, start, end int) int {
+	for end > start {
+		r, size := utf8.DecodeLastRuneInString(text[:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		end -= size
+	}
+	return end
+}
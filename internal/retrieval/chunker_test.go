@@ -0,0 +1,93 @@
+package retrieval
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		opts  ChunkOptions
+		want  []string
+		check func(t *testing.T, chunks []Chunk)
+	}{
+		{
+			name: "token windows overlap",
+			text: "one two three four five six seven",
+			opts: ChunkOptions{Unit: UnitTokens, Size: 3, Overlap: 1},
+			want: []string{"one two three", "three four five", "five six seven"},
+		},
+		{
+			name: "last token window is shorter",
+			text: "one two three four",
+			opts: ChunkOptions{Unit: UnitTokens, Size: 3, Overlap: 0},
+			want: []string{"one two three", "four"},
+		},
+		{
+			name: "token windows keep the original spacing",
+			text: "  alpha\n\nbeta   gamma ",
+			opts: ChunkOptions{Unit: UnitTokens, Size: 5},
+			want: []string{"alpha\n\nbeta   gamma"},
+		},
+		{
+			name: "character windows end at whitespace",
+			text: "the quick brown fox jumps over the lazy dog",
+			opts: ChunkOptions{Unit: UnitChars, Size: 12, Overlap: 0},
+			want: []string{"the quick", "brown fox", "jumps over", "the lazy dog"},
+		},
+		{
+			name: "character windows do not split runes",
+			text: strings.Repeat("é", 30),
+			opts: ChunkOptions{Unit: UnitChars, Size: 10, Overlap: 2},
+			check: func(t *testing.T, chunks []Chunk) {
+				if len(chunks) < 3 {
+					t.Fatalf("got %d chunks, want at least 3", len(chunks))
+				}
+				for _, chunk := range chunks {
+					if !utf8.ValidString(chunk.Text) {
+						t.Errorf("chunk %d is not valid UTF-8: %q", chunk.Index, chunk.Text)
+					}
+				}
+			},
+		},
+		{
+			name: "empty text has no chunks",
+			text: " \n ",
+			opts: ChunkOptions{Unit: UnitTokens, Size: 3},
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Split(tt.text, tt.opts)
+			for i, chunk := range chunks {
+				if chunk.Index != i {
+					t.Errorf("chunk %d has index %d", i, chunk.Index)
+				}
+				if tt.text[chunk.Start:chunk.End] != chunk.Text {
+					t.Errorf("chunk %d offsets %d-%d do not locate its text", i, chunk.Start, chunk.End)
+				}
+				if chunk.Tokens != len(strings.Fields(chunk.Text)) {
+					t.Errorf("chunk %d counts %d tokens, want %d", i, chunk.Tokens, len(strings.Fields(chunk.Text)))
+				}
+			}
+			if tt.want != nil {
+				got := make([]string, len(chunks))
+				for i, chunk := range chunks {
+					got[i] = chunk.Text
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got chunks %q, want %q", got, tt.want)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, chunks)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package retrieval
+
+import (
+	"context"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PGVectorStore stores chunks in Postgres and searches them with pgvector's cosine distance
+type PGVectorStore struct {
+	db *gorm.DB
+}
+
+// NewPGVectorStore creates a store backed by the document_chunks table
+func NewPGVectorStore(db *gorm.DB) *PGVectorStore {
+	return &PGVectorStore{db: db}
+}
+
+// Add inserts chunks in batches
+func (s *PGVectorStore) Add(ctx context.Context, chunks []models.DocumentChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	return s.db.WithContext(ctx).CreateInBatches(&chunks, 100).Error
+}
+
+// Search returns the session's chunks closest to the query embedding
+func (s *PGVectorStore) Search(ctx context.Context, query Query) ([]Match, error) {
+	embedding := models.Vector(query.Embedding)
+
+	var rows []struct {
+		models.DocumentChunk
+		Score float64
+	}
+	db := s.db.WithContext(ctx).
+		Model(&models.DocumentChunk{}).
+		Select("document_chunks.*, 1 - (embedding <=> CAST(? AS vector)) AS score", embedding).
+		Where("session_id = ? AND embedding_model = ? AND vector_dims(embedding) = ?",
+			query.SessionID, query.EmbeddingModel, len(query.Embedding))
+	if len(query.DocumentIDs) > 0 {
+		db = db.Where("document_id IN ?", query.DocumentIDs)
+	}
+	if query.K > 0 {
+		db = db.Limit(query.K)
+	}
+	err := db.Order(clause.Expr{SQL: "embedding <=> CAST(? AS vector)", Vars: []interface{}{embedding}}).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, len(rows))
+	for i, row := range rows {
+		matches[i] = Match{Chunk: row.DocumentChunk, Score: row.Score}
+	}
+	return matches, nil
+}
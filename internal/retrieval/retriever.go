@@ -0,0 +1,98 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// Embedder turns texts into embedding vectors; llm.Provider implements it
+type Embedder interface {
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+}
+
+// Retriever chunks and embeds documents into a Store and finds the passages closest to a question
+type Retriever struct {
+	Store          Store
+	Embedder       Embedder
+	EmbeddingModel string // Recorded on every chunk; searches only compare chunks of the same model
+	Chunking       ChunkOptions
+	BatchSize      int // Chunks embedded per request
+}
+
+// Index splits the documents into chunks, embeds them and adds them to the store
+func (r *Retriever) Index(ctx context.Context, documents []models.Document) ([]models.DocumentChunk, error) {
+	var chunks []models.DocumentChunk
+	for _, doc := range documents {
+		for _, chunk := range Split(doc.Content, r.Chunking) {
+			chunks = append(chunks, models.DocumentChunk{
+				ID:             uuid.New(),
+				SessionID:      doc.SessionID,
+				DocumentID:     doc.ID,
+				Index:          chunk.Index,
+				Content:        chunk.Text,
+				StartOffset:    chunk.Start,
+				EndOffset:      chunk.End,
+				TokenCount:     chunk.Tokens,
+				EmbeddingModel: r.EmbeddingModel,
+			})
+		}
+	}
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+
+	batch := r.BatchSize
+	if batch <= 0 {
+		batch = 64
+	}
+	for start := 0; start < len(chunks); start += batch {
+		end := start + batch
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		inputs := make([]string, end-start)
+		for i := range inputs {
+			inputs[i] = chunks[start+i].Content
+		}
+		embeddings, err := r.Embedder.Embed(ctx, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed chunks: %w", err)
+		}
+		if len(embeddings) != len(inputs) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs), len(embeddings))
+		}
+		for i, embedding := range embeddings {
+			chunks[start+i].Embedding = embedding
+		}
+	}
+
+	if err := r.Store.Add(ctx, chunks); err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// Retrieve returns the k chunks of a session most similar to the question.
+// When documentIDs is not empty only chunks of those documents are considered.
+func (r *Retriever) Retrieve(ctx context.Context, sessionID uuid.UUID, question string, k int, documentIDs []uuid.UUID) ([]Match, error) {
+	embeddings, err := r.Embedder.Embed(ctx, []string{question})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+	if len(embeddings) != 1 || len(embeddings[0]) == 0 {
+		return nil, errors.New("embedder returned no vector for the question")
+	}
+
+	return r.Store.Search(ctx, Query{
+		SessionID:      sessionID,
+		DocumentIDs:    documentIDs,
+		EmbeddingModel: r.EmbeddingModel,
+		Embedding:      embeddings[0],
+		K:              k,
+	})
+}
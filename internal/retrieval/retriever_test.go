@@ -0,0 +1,137 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// topicEmbedder embeds a text as its counts of a few topic words, so that
+// passages about the same topic as a question score highest
+type topicEmbedder struct {
+	calls int
+}
+
+var topics = []string{"qubit", "gopher", "volcano"}
+
+func (e *topicEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	e.calls++
+	embeddings := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		embedding := make([]float32, len(topics))
+		for j, topic := range topics {
+			embedding[j] = float32(strings.Count(strings.ToLower(input), topic))
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+func TestRetriever(t *testing.T) {
+	sessionID := uuid.New()
+	physics := models.Document{ID: uuid.New(), SessionID: sessionID,
+		Content: "A qubit holds a superposition. Every qubit decoheres. Volcano eruptions are unrelated."}
+	mascots := models.Document{ID: uuid.New(), SessionID: sessionID,
+		Content: "The gopher is the Go mascot. A gopher digs. Another gopher sleeps."}
+	other := models.Document{ID: uuid.New(), SessionID: uuid.New(),
+		Content: "This gopher belongs to another session."}
+
+	embedder := &topicEmbedder{}
+	retriever := &Retriever{
+		Store:          NewMemoryStore(),
+		Embedder:       embedder,
+		EmbeddingModel: "topics",
+		Chunking:       ChunkOptions{Unit: UnitTokens, Size: 5, Overlap: 1},
+		BatchSize:      2,
+	}
+	chunks, err := retriever.Index(context.Background(), []models.Document{physics, mascots, other})
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if want := (len(chunks) + 1) / 2; embedder.calls != want {
+		t.Errorf("embedded %d chunks in %d requests, want %d", len(chunks), embedder.calls, want)
+	}
+
+	tests := []struct {
+		name        string
+		question    string
+		k           int
+		documentIDs []uuid.UUID
+		wantCount   int
+		wantDoc     uuid.UUID // Document of the best match
+		wantTopic   string    // Word the best match contains
+	}{
+		{name: "best match first", question: "what is a qubit", k: 2, wantCount: 2, wantDoc: physics.ID, wantTopic: "qubit"},
+		{name: "k limits the matches", question: "gopher", k: 1, wantCount: 1, wantDoc: mascots.ID, wantTopic: "gopher"},
+		{name: "other sessions are not searched", question: "gopher", k: 100, wantCount: countChunks(chunks, sessionID), wantDoc: mascots.ID, wantTopic: "gopher"},
+		{name: "document filter", question: "gopher", k: 1, documentIDs: []uuid.UUID{physics.ID}, wantCount: 1, wantDoc: physics.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := retriever.Retrieve(context.Background(), sessionID, tt.question, tt.k, tt.documentIDs)
+			if err != nil {
+				t.Fatalf("Retrieve: %v", err)
+			}
+			if len(matches) != tt.wantCount {
+				t.Fatalf("got %d matches, want %d", len(matches), tt.wantCount)
+			}
+			best := matches[0]
+			if best.Chunk.DocumentID != tt.wantDoc {
+				t.Errorf("best match is from document %s, want %s", best.Chunk.DocumentID, tt.wantDoc)
+			}
+			if tt.wantTopic != "" && !strings.Contains(strings.ToLower(best.Chunk.Content), tt.wantTopic) {
+				t.Errorf("best match %q does not mention %q", best.Chunk.Content, tt.wantTopic)
+			}
+			for i := 1; i < len(matches); i++ {
+				if matches[i].Score > matches[i-1].Score {
+					t.Errorf("match %d scores %f, above match %d", i, matches[i].Score, i-1)
+				}
+			}
+		})
+	}
+
+	// Chunks embedded by another model are not comparable
+	retriever.EmbeddingModel = "other-model"
+	matches, err := retriever.Retrieve(context.Background(), sessionID, "gopher", 10, nil)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if len(matches) != 0 {
+		t.Errorf("got %d matches from another embedding model, want 0", len(matches))
+	}
+}
+
+func TestCosine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{name: "same direction", a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 3}, want: 0},
+		{name: "opposite", a: []float32{1, 1}, b: []float32{-1, -1}, want: -1},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+		{name: "different lengths", a: []float32{1}, b: []float32{1, 1}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Cosine(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+				t.Errorf("Cosine = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func countChunks(chunks []models.DocumentChunk, sessionID uuid.UUID) int {
+	n := 0
+	for _, chunk := range chunks {
+		if chunk.SessionID == sessionID {
+			n++
+		}
+	}
+	return n
+}
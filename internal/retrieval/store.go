@@ -0,0 +1,102 @@
+package retrieval
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// Match is a chunk found by a similarity search
+type Match struct {
+	Chunk models.DocumentChunk
+	Score float64 // Cosine similarity to the query
+}
+
+// Query selects the chunks a similarity search considers
+type Query struct {
+	SessionID      uuid.UUID
+	DocumentIDs    []uuid.UUID // Optional; restricts the search to these documents
+	EmbeddingModel string      // Only chunks embedded by this model are comparable
+	Embedding      []float32
+	K              int
+}
+
+// Store persists embedded chunks and finds the ones most similar to a query
+type Store interface {
+	Add(ctx context.Context, chunks []models.DocumentChunk) error
+	Search(ctx context.Context, query Query) ([]Match, error)
+}
+
+// MemoryStore is a brute-force in-memory Store, for tests and single-process setups
+type MemoryStore struct {
+	mu     sync.RWMutex
+	chunks map[uuid.UUID][]models.DocumentChunk
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{chunks: make(map[uuid.UUID][]models.DocumentChunk)}
+}
+
+// Add stores chunks in memory
+func (s *MemoryStore) Add(ctx context.Context, chunks []models.DocumentChunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, chunk := range chunks {
+		if chunk.ID == uuid.Nil {
+			chunk.ID = uuid.New()
+		}
+		s.chunks[chunk.SessionID] = append(s.chunks[chunk.SessionID], chunk)
+	}
+	return nil
+}
+
+// Search compares the query with every chunk of the session
+func (s *MemoryStore) Search(ctx context.Context, query Query) ([]Match, error) {
+	documents := make(map[uuid.UUID]bool, len(query.DocumentIDs))
+	for _, id := range query.DocumentIDs {
+		documents[id] = true
+	}
+
+	s.mu.RLock()
+	var matches []Match
+	for _, chunk := range s.chunks[query.SessionID] {
+		if chunk.EmbeddingModel != query.EmbeddingModel || len(chunk.Embedding) != len(query.Embedding) {
+			continue
+		}
+		if len(documents) > 0 && !documents[chunk.DocumentID] {
+			continue
+		}
+		matches = append(matches, Match{Chunk: chunk, Score: Cosine(chunk.Embedding, query.Embedding)})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if query.K > 0 && len(matches) > query.K {
+		matches = matches[:query.K]
+	}
+	return matches, nil
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 when their lengths differ
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultBraveURL is the public Brave Search API endpoint
+const DefaultBraveURL = "https://api.search.brave.com"
+
+// Brave queries the Brave Search API, or any service using the same JSON shape
+type Brave struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewBrave creates a new Brave provider
+func NewBrave(baseURL, apiKey string, client *http.Client) (*Brave, error) {
+	if baseURL == "" {
+		baseURL = DefaultBraveURL
+	}
+	if apiKey == "" {
+		return nil, errors.New("brave API key is required")
+	}
+	return &Brave{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  client,
+	}, nil
+}
+
+// Name returns the provider name
+func (b *Brave) Name() string {
+	return "brave"
+}
+
+type braveResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			PageAge     string `json:"page_age"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+// Search runs the query against the /res/v1/web/search endpoint
+func (b *Brave) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	if limit > 0 {
+		// The API caps count at 20
+		count := limit
+		if count > 20 {
+			count = 20
+		}
+		params.Set("count", strconv.Itoa(count))
+	}
+
+	var body braveResponse
+	headers := map[string]string{"X-Subscription-Token": b.apiKey}
+	if err := getJSON(ctx, b.client, b.baseURL+"/res/v1/web/search?"+params.Encode(), headers, &body); err != nil {
+		return nil, fmt.Errorf("brave: %w", err)
+	}
+
+	var results []Result
+	for _, item := range body.Web.Results {
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+		if item.URL == "" {
+			continue
+		}
+		rank := len(results) + 1
+		results = append(results, Result{
+			Title:       item.Title,
+			URL:         item.URL,
+			Snippet:     item.Description,
+			Provider:    b.Name(),
+			Rank:        rank,
+			Score:       1 / float64(rank),
+			PublishedAt: parseDate(item.PageAge),
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Fixture serves canned results from a JSON file, for offline development and tests.
+//
+// The file maps queries to result lists; the "*" entry is used for unknown queries:
+//
+//	{
+//	  "quantum computing": [{"title": "...", "url": "https://...", "snippet": "..."}],
+//	  "*": []
+//	}
+type Fixture struct {
+	results map[string][]Result
+}
+
+// NewFixture loads a fixture provider from a JSON file
+func NewFixture(path string) (*Fixture, error) {
+	if path == "" {
+		return nil, fmt.Errorf("fixture path is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search fixture: %w", err)
+	}
+
+	var raw map[string][]Result
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse search fixture: %w", err)
+	}
+	return NewFixtureFromResults(raw), nil
+}
+
+// NewFixtureFromResults creates a fixture provider from in-memory results
+func NewFixtureFromResults(results map[string][]Result) *Fixture {
+	normalized := make(map[string][]Result, len(results))
+	for query, list := range results {
+		normalized[normalizeQuery(query)] = list
+	}
+	return &Fixture{results: normalized}
+}
+
+// Name returns the provider name
+func (f *Fixture) Name() string {
+	return "fixture"
+}
+
+// Search returns the canned results for the query
+func (f *Fixture) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	list, ok := f.results[normalizeQuery(query)]
+	if !ok {
+		list = f.results["*"]
+	}
+
+	var results []Result
+	for _, item := range list {
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+		item.Provider = f.Name()
+		item.Rank = len(results) + 1
+		if item.Score == 0 {
+			item.Score = 1 / float64(item.Rank)
+		}
+		results = append(results, item)
+	}
+	return results, nil
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,123 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFixtureSearch(t *testing.T) {
+	fixture := NewFixtureFromResults(map[string][]Result{
+		"Quantum  Computing": {
+			{Title: "Qubits", URL: "https://example.com/qubits"},
+			{Title: "Gates", URL: "https://example.com/gates", Score: 0.9},
+			{Title: "Errors", URL: "https://example.com/errors"},
+		},
+		"*": {
+			{Title: "Fallback", URL: "https://example.com/fallback"},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		query      string
+		limit      int
+		wantTitles []string
+		wantScores []float64
+	}{
+		{
+			name:       "exact query",
+			query:      "Quantum  Computing",
+			wantTitles: []string{"Qubits", "Gates", "Errors"},
+			wantScores: []float64{1, 0.9, 1.0 / 3},
+		},
+		{
+			name:       "case and spacing are normalized",
+			query:      "  quantum computing ",
+			wantTitles: []string{"Qubits", "Gates", "Errors"},
+		},
+		{
+			name:       "limit keeps the best results",
+			query:      "quantum computing",
+			limit:      2,
+			wantTitles: []string{"Qubits", "Gates"},
+		},
+		{
+			name:       "unknown query uses the fallback",
+			query:      "something else",
+			wantTitles: []string{"Fallback"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := fixture.Search(context.Background(), tt.query, tt.limit)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(results) != len(tt.wantTitles) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantTitles))
+			}
+			for i, result := range results {
+				if result.Title != tt.wantTitles[i] {
+					t.Errorf("result %d title = %q, want %q", i, result.Title, tt.wantTitles[i])
+				}
+				if result.Rank != i+1 {
+					t.Errorf("result %d rank = %d, want %d", i, result.Rank, i+1)
+				}
+				if result.Provider != "fixture" {
+					t.Errorf("result %d provider = %q, want fixture", i, result.Provider)
+				}
+				if tt.wantScores != nil && result.Score != tt.wantScores[i] {
+					t.Errorf("result %d score = %v, want %v", i, result.Score, tt.wantScores[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewFixture(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	if err := os.WriteFile(valid, []byte(`{"golang": [{"title": "Go", "url": "https://go.dev"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`[]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "valid file", path: valid},
+		{name: "missing path", path: "", wantErr: true},
+		{name: "missing file", path: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "not a query map", path: invalid, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture, err := NewFixture(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewFixture succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewFixture: %v", err)
+			}
+			results, err := fixture.Search(context.Background(), "Golang", 10)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(results) != 1 || results[0].URL != "https://go.dev" {
+				t.Errorf("got %+v, want the Go result", results)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion
+const rrfK = 60
+
+// Multi queries several providers concurrently and merges their results
+type Multi struct {
+	providers []Provider
+}
+
+// NewMulti creates a provider that fans out to all the given providers
+func NewMulti(providers ...Provider) *Multi {
+	return &Multi{providers: providers}
+}
+
+// Name returns the names of the wrapped providers
+func (m *Multi) Name() string {
+	names := make([]string, len(m.providers))
+	for i, p := range m.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Search queries every provider and merges duplicate URLs.
+// It only fails when every provider fails.
+func (m *Multi) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	lists := make([][]Result, len(m.providers))
+	errs := make([]error, len(m.providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range m.providers {
+		wg.Add(1)
+		go func(i int, provider Provider) {
+			defer wg.Done()
+			lists[i], errs[i] = provider.Search(ctx, query, limit)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(m.providers) && failed > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return Merge(limit, lists...), nil
+}
+
+// Merge combines ranked result lists, merging entries that point at the same URL.
+// Merged results are ordered by reciprocal rank fusion, so a URL returned
+// by several providers ranks above one returned by a single provider.
+func Merge(limit int, lists ...[]Result) []Result {
+	type merged struct {
+		result    Result
+		score     float64
+		providers []string
+		order     int
+	}
+
+	byURL := make(map[string]*merged)
+	var order []string
+	for _, list := range lists {
+		for i, result := range list {
+			key := NormalizeURL(result.URL)
+			if key == "" {
+				continue
+			}
+			rank := result.Rank
+			if rank <= 0 {
+				rank = i + 1
+			}
+
+			entry, ok := byURL[key]
+			if !ok {
+				result.URL = key
+				entry = &merged{result: result, order: len(order)}
+				byURL[key] = entry
+				order = append(order, key)
+			} else {
+				// Keep the most descriptive title and snippet
+				if len(result.Title) > len(entry.result.Title) {
+					entry.result.Title = result.Title
+				}
+				if len(result.Snippet) > len(entry.result.Snippet) {
+					entry.result.Snippet = result.Snippet
+				}
+				if entry.result.PublishedAt == nil {
+					entry.result.PublishedAt = result.PublishedAt
+				}
+			}
+			entry.score += 1 / float64(rrfK+rank)
+			if !containsString(entry.providers, result.Provider) && result.Provider != "" {
+				entry.providers = append(entry.providers, result.Provider)
+			}
+		}
+	}
+
+	entries := make([]*merged, 0, len(order))
+	for _, key := range order {
+		entries = append(entries, byURL[key])
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].score != entries[j].score {
+			return entries[i].score > entries[j].score
+		}
+		return entries[i].order < entries[j].order
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	results := make([]Result, len(entries))
+	for i, entry := range entries {
+		results[i] = entry.result
+		results[i].Rank = i + 1
+		results[i].Score = entry.score
+		results[i].Provider = strings.Join(entry.providers, ",")
+	}
+	return results
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
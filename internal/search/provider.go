@@ -0,0 +1,63 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Result is a single ranked search hit
+type Result struct {
+	Title       string     `json:"title"`
+	URL         string     `json:"url"`
+	Snippet     string     `json:"snippet"`
+	Provider    string     `json:"provider"`
+	Rank        int        `json:"rank"`  // 1-based position in the provider's result list
+	Score       float64    `json:"score"` // Higher is better; comparable only within one result list
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+}
+
+// Provider is a web search backend
+type Provider interface {
+	// Name returns the provider name, e.g. "searxng"
+	Name() string
+
+	// Search returns at most limit results for the query, best first
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+// Options configures the built-in providers
+type Options struct {
+	SearxNGURL  string
+	BraveURL    string
+	BraveAPIKey string
+	FixturePath string
+	Timeout     time.Duration
+}
+
+// ErrNotConfigured is returned when no provider is configured
+var ErrNotConfigured = errors.New("search provider not configured")
+
+// NewProvider creates a provider by name
+func NewProvider(name string, opts Options) (Provider, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 15 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	switch name {
+	case "":
+		return nil, ErrNotConfigured
+	case "searxng":
+		return NewSearxNG(opts.SearxNGURL, client)
+	case "brave":
+		return NewBrave(opts.BraveURL, opts.BraveAPIKey, client)
+	case "fixture":
+		return NewFixture(opts.FixturePath)
+	default:
+		return nil, fmt.Errorf("unknown search provider %q", name)
+	}
+}
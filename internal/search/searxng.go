@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SearxNG queries a SearxNG instance through its JSON API
+type SearxNG struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewSearxNG creates a new SearxNG provider.
+// The instance must have the json format enabled in its settings.
+func NewSearxNG(baseURL string, client *http.Client) (*SearxNG, error) {
+	if baseURL == "" {
+		return nil, errors.New("searxng base URL is required")
+	}
+	return &SearxNG{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}, nil
+}
+
+// Name returns the provider name
+func (s *SearxNG) Name() string {
+	return "searxng"
+}
+
+type searxngResponse struct {
+	Results []struct {
+		URL           string  `json:"url"`
+		Title         string  `json:"title"`
+		Content       string  `json:"content"`
+		Score         float64 `json:"score"`
+		PublishedDate string  `json:"publishedDate"`
+	} `json:"results"`
+}
+
+// Search runs the query against the instance's /search endpoint
+func (s *SearxNG) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "json")
+
+	var body searxngResponse
+	if err := getJSON(ctx, s.client, s.baseURL+"/search?"+params.Encode(), nil, &body); err != nil {
+		return nil, fmt.Errorf("searxng: %w", err)
+	}
+
+	var results []Result
+	for _, item := range body.Results {
+		if limit > 0 && len(results) >= limit {
+			break
+		}
+		if item.URL == "" {
+			continue
+		}
+		results = append(results, Result{
+			Title:       item.Title,
+			URL:         item.URL,
+			Snippet:     item.Content,
+			Provider:    s.Name(),
+			Rank:        len(results) + 1,
+			Score:       item.Score,
+			PublishedAt: parseDate(item.PublishedDate),
+		})
+	}
+	return results, nil
+}
+
+// getJSON performs a GET request and decodes a JSON response body
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// parseDate parses the date formats search APIs commonly return
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
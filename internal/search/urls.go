@@ -0,0 +1,121 @@
+package search
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Source types stored in models.Source.Type
+const (
+	SourceTypeWebsite       = "website"
+	SourceTypePDF           = "pdf"
+	SourceTypeAcademicPaper = "academic_paper"
+	SourceTypeNewsArticle   = "news_article"
+)
+
+// NormalizeURL returns a canonical form of an http(s) URL so that the same page
+// found by different providers compares equal. It returns "" for invalid URLs.
+func NormalizeURL(raw string) string {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ""
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := parsed.Port(); port != "" && !(parsed.Scheme == "http" && port == "80") && !(parsed.Scheme == "https" && port == "443") {
+		host += ":" + port
+	}
+	parsed.Host = host
+	parsed.Fragment = ""
+	parsed.User = nil
+
+	// Drop tracking parameters and sort the rest
+	query := parsed.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || lower == "fbclid" || lower == "gclid" || lower == "ref" {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var encoded []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			encoded = append(encoded, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	parsed.RawQuery = strings.Join(encoded, "&")
+
+	if parsed.Path != "/" {
+		parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	}
+	if parsed.Path == "/" && parsed.RawQuery == "" {
+		parsed.Path = ""
+	}
+	parsed.RawPath = ""
+
+	return parsed.String()
+}
+
+// Domain returns the host of a URL without a leading "www."
+func Domain(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+var academicDomains = []string{
+	"arxiv.org", "doi.org", "pubmed.ncbi.nlm.nih.gov", "ncbi.nlm.nih.gov", "semanticscholar.org",
+	"scholar.google.com", "acm.org", "ieee.org", "springer.com", "sciencedirect.com",
+	"nature.com", "science.org", "wiley.com", "jstor.org", "biorxiv.org", "medrxiv.org",
+	"ssrn.com", "researchgate.net", "plos.org", "openreview.net", "aclanthology.org",
+}
+
+var newsDomains = []string{
+	"reuters.com", "apnews.com", "bbc.com", "bbc.co.uk", "nytimes.com", "theguardian.com",
+	"washingtonpost.com", "wsj.com", "ft.com", "bloomberg.com", "cnn.com", "npr.org",
+	"economist.com", "aljazeera.com", "theverge.com", "techcrunch.com", "wired.com", "arstechnica.com",
+}
+
+// ClassifySource guesses the models.Source type of a URL
+func ClassifySource(raw string) string {
+	domain := Domain(raw)
+	parsed, err := url.Parse(raw)
+	if err == nil && strings.EqualFold(path.Ext(parsed.Path), ".pdf") {
+		if matchesDomain(domain, academicDomains) {
+			return SourceTypeAcademicPaper
+		}
+		return SourceTypePDF
+	}
+	if matchesDomain(domain, academicDomains) {
+		return SourceTypeAcademicPaper
+	}
+	if matchesDomain(domain, newsDomains) {
+		return SourceTypeNewsArticle
+	}
+	return SourceTypeWebsite
+}
+
+// matchesDomain reports whether domain equals or is a subdomain of any listed domain
+func matchesDomain(domain string, list []string) bool {
+	for _, candidate := range list {
+		if domain == candidate || strings.HasSuffix(domain, "."+candidate) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/mailer"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/throttle"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Account email errors
+var (
+	// ErrInvalidActionToken is returned for emailed tokens that are malformed,
+	// expired, already used or meant for another purpose
+	ErrInvalidActionToken   = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	ErrMailerNotConfigured  = errors.New("email delivery is not configured")
+	ErrWeakTokenSecret      = fmt.Errorf("account token secret must be at least %d bytes", minTokenSecretLength)
+)
+
+// minTokenSecretLength is the shortest secret emailed tokens may be signed with
+const minTokenSecretLength = 32
+
+// AccountOptions configures the emails sent by the account service
+type AccountOptions struct {
+	AppURL         string        // Base URL of the web app the emailed links open; without it the bare token is sent
+	VerifyTTL      time.Duration // Lifetime of email verification tokens
+	ResetTTL       time.Duration // Lifetime of password reset tokens
+	ResetsPerEmail int           // Password reset requests per address per hour
+	ResetsPerIP    int           // Password reset requests per client IP per hour
+}
+
+// AccountService verifies email addresses and resets forgotten passwords with
+// signed, single-use, expiring tokens sent by email
+type AccountService struct {
+	db          *gorm.DB
+	authService *AuthService
+	sender      mailer.Sender
+	secret      string
+	opts        AccountOptions
+
+	resetEmails *throttle.Limiter
+	resetIPs    *throttle.Limiter
+	emails      sync.WaitGroup // Reset emails being sent in the background
+}
+
+// NewAccountService creates a new account service. Tokens are signed with a key
+// derived from secret, which must be at least 32 bytes; sender may be nil when
+// email is not configured. Reset requests are counted in store.
+func NewAccountService(db *gorm.DB, authService *AuthService, sender mailer.Sender, store throttle.Store, secret string, opts AccountOptions) (*AccountService, error) {
+	if len(secret) < minTokenSecretLength {
+		return nil, ErrWeakTokenSecret
+	}
+	if opts.VerifyTTL <= 0 {
+		opts.VerifyTTL = 48 * time.Hour
+	}
+	if opts.ResetTTL <= 0 {
+		opts.ResetTTL = time.Hour
+	}
+	if opts.ResetsPerEmail <= 0 {
+		opts.ResetsPerEmail = 3
+	}
+	if opts.ResetsPerIP <= 0 {
+		opts.ResetsPerIP = 20
+	}
+	opts.AppURL = strings.TrimRight(opts.AppURL, "/")
+
+	// The last request of the hour locks the key for the rest of it
+	resetEmails, err := throttle.NewLimiter(store, "reset:email", throttle.Policy{
+		FreeAttempts: opts.ResetsPerEmail - 1,
+		BaseLockout:  time.Hour,
+		MaxLockout:   24 * time.Hour,
+		Window:       time.Hour,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resetIPs, err := throttle.NewLimiter(store, "reset:ip", throttle.Policy{
+		FreeAttempts: opts.ResetsPerIP - 1,
+		BaseLockout:  time.Hour,
+		MaxLockout:   24 * time.Hour,
+		Window:       time.Hour,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountService{
+		db:          db,
+		authService: authService,
+		sender:      sender,
+		secret:      secret,
+		opts:        opts,
+		resetEmails: resetEmails,
+		resetIPs:    resetIPs,
+	}, nil
+}
+
+// SendVerification emails the user a link that verifies their address
+func (s *AccountService) SendVerification(ctx context.Context, user *models.User) error {
+	if user.IsEmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	if s.sender == nil {
+		return ErrMailerNotConfigured
+	}
+
+	token, err := s.issueToken(user, models.PurposeVerifyEmail, s.opts.VerifyTTL)
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address to finish setting up your account:\n\n%s\n\n"+
+			"This expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.Name, s.actionLink("/verify-email", token), describeTTL(s.opts.VerifyTTL)),
+	})
+}
+
+// VerifyEmail uses up a verification token and marks the address it was sent to as verified
+func (s *AccountService) VerifyEmail(token string) (*models.User, error) {
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeToken(tx, token, models.PurposeVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", record.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidActionToken
+			}
+			return err
+		}
+		if !strings.EqualFold(user.Email, record.Email) {
+			return ErrInvalidActionToken
+		}
+
+		user.IsEmailVerified = true
+		return tx.Model(&user).Update("is_email_verified", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequestPasswordReset emails a password reset link to the account with the
+// given address, requested from the client IP. It returns how long to wait
+// when the address or the IP has made too many requests. The email is sent in
+// the background, and unknown and disabled accounts are skipped silently, so
+// that callers cannot tell which addresses are registered, not even by timing.
+func (s *AccountService) RequestPasswordReset(ctx context.Context, email, ip string) (time.Duration, error) {
+	if s.sender == nil {
+		return 0, ErrMailerNotConfigured
+	}
+
+	// Requests count whether or not the address is registered
+	_, wait, err := s.resetEmails.Reserve(ctx, accountKey(email))
+	if err != nil {
+		log.Printf("Failed to check password reset throttle: %v", err)
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+	_, wait, err = s.resetIPs.Reserve(ctx, ip)
+	if err != nil {
+		log.Printf("Failed to check password reset throttle: %v", err)
+	}
+	if wait > 0 {
+		return wait, nil
+	}
+
+	s.emails.Add(1)
+	go func() {
+		defer s.emails.Done()
+		if err := s.sendPasswordReset(context.WithoutCancel(ctx), email); err != nil {
+			log.Printf("Failed to send password reset email: %v", err)
+		}
+	}()
+	return 0, nil
+}
+
+// Wait blocks until the reset emails being sent in the background are sent
+func (s *AccountService) Wait() {
+	s.emails.Wait()
+}
+
+// sendPasswordReset emails a reset link to the enabled account with the address
+func (s *AccountService) sendPasswordReset(ctx context.Context, email string) error {
+	var user models.User
+	err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.IsDisabled {
+		return nil
+	}
+
+	token, err := s.issueToken(&user, models.PurposeResetPassword, s.opts.ResetTTL)
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. Choose a new password here:\n\n%s\n\n"+
+			"This expires in %s. If it was not you, you can ignore this email and your password stays the same.\n",
+			user.Name, s.actionLink("/reset-password", token), describeTTL(s.opts.ResetTTL)),
+	})
+}
+
+// ResetPassword uses up a reset token, sets the new password, revokes the
+// user's API keys and signs them out of every device, since whoever knew the
+// old password may have created keys or sessions. Other outstanding reset
+// tokens stop working.
+func (s *AccountService) ResetPassword(token, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeToken(tx, token, models.PurposeResetPassword)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", record.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidActionToken
+			}
+			return err
+		}
+		if !strings.EqualFold(user.Email, record.Email) {
+			return ErrInvalidActionToken
+		}
+		if user.IsDisabled {
+			return ErrAccountDisabled
+		}
+
+		// Following the link proves the user owns the address
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash":     string(hashedPassword),
+			"is_email_verified": true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ActionToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, models.PurposeResetPassword).
+			Update("used_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.authService.LogoutAll(user.ID.String())
+}
+
+// actionClaims are the claims of an emailed token; the audience is its purpose
+type actionClaims struct {
+	jwt.RegisteredClaims
+}
+
+// issueToken records a single-use token for the user and returns it signed
+func (s *AccountService) issueToken(user *models.User, purpose models.TokenPurpose, ttl time.Duration) (string, error) {
+	now := time.Now()
+	record := models.ActionToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.ActionToken{}).Error; err != nil {
+		return "", err
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return "", err
+	}
+
+	claims := actionClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        record.ID.String(),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{string(purpose)},
+			ExpiresAt: jwt.NewNumericDate(record.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey(purpose))
+}
+
+// consumeToken checks a token's signature and purpose and marks its record
+// used, failing when it was used before or has expired
+func (s *AccountService) consumeToken(tx *gorm.DB, token string, purpose models.TokenPurpose) (*models.ActionToken, error) {
+	var claims actionClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.signingKey(purpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(string(purpose)), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidActionToken
+	}
+
+	result := tx.Model(&models.ActionToken{}).
+		Where("id = ? AND user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", id, claims.Subject, purpose, time.Now()).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidActionToken
+	}
+
+	var record models.ActionToken
+	if err := tx.Where("id = ?", id).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// signingKey derives a key per purpose from the secret, so that neither access
+// tokens nor tokens of another purpose verify in place of an emailed token
+func (s *AccountService) signingKey(purpose models.TokenPurpose) []byte {
+	mac := hmac.New(sha256.New, []byte(s.secret))
+	mac.Write([]byte("action-token:" + string(purpose)))
+	return mac.Sum(nil)
+}
+
+// actionLink returns the web app link that submits the token, or the bare
+// token when no app URL is configured
+func (s *AccountService) actionLink(path, token string) string {
+	if s.opts.AppURL == "" {
+		return token
+	}
+	return s.opts.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// describeTTL words a token lifetime for an email
+func describeTTL(ttl time.Duration) string {
+	switch {
+	case ttl >= 48*time.Hour && ttl%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", int(ttl/(24*time.Hour)))
+	case ttl >= 2*time.Hour && ttl%time.Hour == 0:
+		return fmt.Sprintf("%d hours", int(ttl/time.Hour))
+	case ttl == time.Hour:
+		return "1 hour"
+	default:
+		return fmt.Sprintf("%d minutes", int(ttl.Round(time.Minute)/time.Minute))
+	}
+}
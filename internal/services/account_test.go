@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lolzone13/DeepResearch/internal/mailer"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/signing"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+	"github.com/lolzone13/DeepResearch/internal/throttle"
+	"gorm.io/gorm"
+)
+
+const testTokenSecret = "test-secret-that-is-long-enough-for-tokens"
+
+// linkToken matches the token of an emailed link
+var linkToken = regexp.MustCompile(`\?token=(\S+)`)
+
+// newTestAccountService returns an account service that captures its emails
+func newTestAccountService(t *testing.T, db *gorm.DB, opts AccountOptions) (*AccountService, *mailer.Memory) {
+	t.Helper()
+	opts.AppURL = "https://app.example.com"
+	outbox := mailer.NewMemory()
+	authService := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	accounts, err := NewAccountService(db, authService, outbox, throttle.NewMemory(), testTokenSecret, opts)
+	if err != nil {
+		t.Fatalf("NewAccountService: %v", err)
+	}
+	return accounts, outbox
+}
+
+// emailedToken returns the token of the link last emailed to the address
+func emailedToken(t *testing.T, outbox *mailer.Memory, to string) string {
+	t.Helper()
+	msg, ok := outbox.Last(to)
+	if !ok {
+		t.Fatalf("no email was sent to %s", to)
+	}
+	match := linkToken.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("email to %s has no link: %q", to, msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestNewAccountServiceSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "empty", secret: "", wantErr: true},
+		{name: "short", secret: "your-jwt-secret", wantErr: true},
+		{name: "32 bytes", secret: strings.Repeat("k", 32)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAccountService(nil, nil, nil, throttle.NewMemory(), tt.secret, AccountOptions{})
+			if tt.wantErr != errors.Is(err, ErrWeakTokenSecret) {
+				t.Errorf("err = %v, want weak secret error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	db := testdb.Open(t)
+	accounts, outbox := newTestAccountService(t, db, AccountOptions{})
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T, user *models.User) string
+		valid bool
+	}{
+		{
+			name: "emailed token",
+			token: func(t *testing.T, user *models.User) string {
+				if err := accounts.SendVerification(context.Background(), user); err != nil {
+					t.Fatalf("SendVerification: %v", err)
+				}
+				return emailedToken(t, outbox, user.Email)
+			},
+			valid: true,
+		},
+		{
+			name: "password reset token",
+			token: func(t *testing.T, user *models.User) string {
+				token, err := accounts.issueToken(user, models.PurposeResetPassword, time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T, user *models.User) string {
+				token, err := accounts.issueToken(user, models.PurposeVerifyEmail, -time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+		},
+		{
+			name: "token signed with another secret",
+			token: func(t *testing.T, user *models.User) string {
+				other, err := NewAccountService(db, accounts.authService, outbox, throttle.NewMemory(), strings.Repeat("x", 32), AccountOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				token, err := other.issueToken(user, models.PurposeVerifyEmail, time.Hour)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := accounts.authService.Register(strings.Repeat("v", i+1)+"@example.com", "password123", "Test")
+			if err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			token := tt.token(t, user)
+
+			verified, err := accounts.VerifyEmail(token)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidActionToken) {
+					t.Fatalf("VerifyEmail error = %v, want ErrInvalidActionToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyEmail: %v", err)
+			}
+			if !verified.IsEmailVerified {
+				t.Error("user is not verified")
+			}
+
+			// Tokens work once
+			if _, err := accounts.VerifyEmail(token); !errors.Is(err, ErrInvalidActionToken) {
+				t.Errorf("second VerifyEmail error = %v, want ErrInvalidActionToken", err)
+			}
+		})
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	accounts, outbox := newTestAccountService(t, db, AccountOptions{ResetsPerEmail: 3})
+
+	user, err := accounts.authService.Register("reset@example.com", "old-password", "Test")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	request := func(email string) time.Duration {
+		t.Helper()
+		wait, err := accounts.RequestPasswordReset(ctx, email, "203.0.113.7")
+		if err != nil {
+			t.Fatalf("RequestPasswordReset: %v", err)
+		}
+		accounts.Wait()
+		return wait
+	}
+
+	_, apiKey, err := NewAPIKeyService(db).Create(user, "script", []string{string(models.ScopeRead)}, nil)
+	if err != nil {
+		t.Fatalf("Create API key: %v", err)
+	}
+
+	request(user.Email)
+	earlier := emailedToken(t, outbox, user.Email)
+	request(user.Email)
+	token := emailedToken(t, outbox, user.Email)
+
+	if err := accounts.ResetPassword(token, "new-password"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if _, _, err := accounts.authService.Login(user.Email, "new-password"); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+	if _, _, err := accounts.authService.Login(user.Email, "old-password"); err == nil {
+		t.Error("the old password still works")
+	}
+	if _, _, err := accounts.authService.AuthenticateAPIKey(apiKey); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("API key after the reset: error = %v, want ErrAPIKeyRevoked", err)
+	}
+
+	// The used token and every other outstanding reset token stop working
+	for name, used := range map[string]string{"used": token, "earlier": earlier} {
+		if err := accounts.ResetPassword(used, "another-password"); !errors.Is(err, ErrInvalidActionToken) {
+			t.Errorf("%s token: ResetPassword error = %v, want ErrInvalidActionToken", name, err)
+		}
+	}
+
+	// Unknown addresses get no email, but count towards the limit all the same
+	outbox.Reset()
+	for i := range 3 {
+		if wait := request("nobody@example.com"); wait != 0 {
+			t.Fatalf("request %d waits %s, want 0", i+1, wait)
+		}
+	}
+	if len(outbox.Messages()) != 0 {
+		t.Errorf("%d emails were sent to an unknown address", len(outbox.Messages()))
+	}
+	if wait := request("Nobody@example.com"); wait <= 0 {
+		t.Error("fourth request in an hour was not throttled")
+	}
+}
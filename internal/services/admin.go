@@ -0,0 +1,246 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastAdmin is returned when a change would leave no enabled admin
+var ErrLastAdmin = errors.New("cannot remove the last enabled admin")
+
+// AdminService handles system-wide user and session management
+type AdminService struct {
+	db          *gorm.DB
+	authService *AuthService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(db *gorm.DB, authService *AuthService) *AdminService {
+	return &AdminService{
+		db:          db,
+		authService: authService,
+	}
+}
+
+// ListUsers returns a page of users, optionally filtered by role and by a
+// search term matched against email and name
+func (s *AdminService) ListUsers(page, perPage int, role, search string) ([]models.User, int64, error) {
+	query := s.db.Model(&models.User{})
+	if role != "" {
+		if !models.UserRole(role).IsValid() {
+			return nil, 0, errors.New("invalid role")
+		}
+		query = query.Where("roles = ?", role)
+	}
+	if search = strings.TrimSpace(search); search != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(search) + "%"
+		query = query.Where("email ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.Offset((page - 1) * perPage).Limit(perPage).Order("created_at DESC").Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SetDisabled disables or re-enables a user. Disabling also signs the user
+// out of every device. Admins cannot disable themselves or the last enabled admin.
+func (s *AdminService) SetDisabled(actorID, userID string, disabled bool) (*models.User, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if disabled && user.ID.String() == actorID {
+		return nil, errors.New("cannot disable your own account")
+	}
+
+	var disabledAt *time.Time
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if disabled {
+			if err := keepAnAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(user).Updates(map[string]interface{}{
+			"is_disabled": disabled,
+			"disabled_at": disabledAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.authService.LogoutAll(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.findUser(userID)
+}
+
+// SetRole changes a user's role. Admins cannot change their own role, so the
+// last admin cannot demote themselves by accident, nor demote the last enabled admin.
+func (s *AdminService) SetRole(actorID, userID, role string) (*models.User, error) {
+	if !models.UserRole(role).IsValid() {
+		return nil, errors.New("invalid role")
+	}
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID.String() == actorID {
+		return nil, errors.New("cannot change your own role")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if models.UserRole(role) != models.RoleAdmin {
+			if err := keepAnAdmin(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return tx.Model(user).Update("roles", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = models.UserRole(role)
+	return user, nil
+}
+
+// keepAnAdmin returns ErrLastAdmin when userID is the only enabled admin.
+// It locks the enabled admins until the transaction ends, so two admins
+// demoting each other at once cannot both succeed.
+func keepAnAdmin(tx *gorm.DB, userID uuid.UUID) error {
+	var admins []models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("roles = ? AND is_disabled = ?", models.RoleAdmin, false).
+		Find(&admins).Error
+	if err != nil {
+		return err
+	}
+
+	isAdmin := false
+	for _, admin := range admins {
+		if admin.ID != userID {
+			return nil
+		}
+		isAdmin = true
+	}
+	if isAdmin {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// PromoteAdmins gives the admin role to the existing users with the given
+// emails, so that a deployment can bootstrap its first administrators
+func (s *AdminService) PromoteAdmins(emails []string) error {
+	var normalized []string
+	for _, email := range emails {
+		if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+			normalized = append(normalized, email)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil
+	}
+	return s.db.Model(&models.User{}).
+		Where("LOWER(email) IN ? AND roles <> ?", normalized, models.RoleAdmin).
+		Update("roles", models.RoleAdmin).Error
+}
+
+// ListSessions returns a page of the sessions of every user, optionally
+// filtered by status and owner
+func (s *AdminService) ListSessions(page, perPage int, status, userID string) ([]models.ResearchSession, int64, error) {
+	query := s.db.Model(&models.ResearchSession{})
+	if status != "" {
+		if !models.SessionStatus(status).IsValid() {
+			return nil, 0, models.ErrInvalidSessionStatus
+		}
+		query = query.Where("status = ?", status)
+	}
+	if userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, 0, errors.New("invalid user ID")
+		}
+		query = query.Where("user_id = ?", userUUID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var sessions []models.ResearchSession
+	if err := query.Offset((page - 1) * perPage).Limit(perPage).Order("created_at DESC").Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+// ListAuditEvents returns a page of audit events, newest first, optionally
+// filtered by action, user and email
+func (s *AdminService) ListAuditEvents(page, perPage int, action, userID, email string) ([]models.AuditEvent, int64, error) {
+	query := s.db.Model(&models.AuditEvent{})
+	if action != "" {
+		if !models.AuditAction(action).IsValid() {
+			return nil, 0, errors.New("invalid action")
+		}
+		query = query.Where("action = ?", action)
+	}
+	if userID != "" {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, 0, errors.New("invalid user ID")
+		}
+		query = query.Where("user_id = ?", userUUID)
+	}
+	if email = strings.TrimSpace(email); email != "" {
+		query = query.Where("LOWER(email) = ?", strings.ToLower(email))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []models.AuditEvent
+	if err := query.Offset((page - 1) * perPage).Limit(perPage).Order("created_at DESC").Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (s *AdminService) findUser(userID string) (*models.User, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var user models.User
+	if err := s.db.Where("id = ?", userUUID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
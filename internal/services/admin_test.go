@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/signing"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+func TestLastAdmin(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	admins := NewAdminService(db, auth)
+
+	users := make(map[string]string)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user, err := auth.Register(name+"@example.com", "password123", name)
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		users[name] = user.ID.String()
+	}
+	if err := admins.PromoteAdmins([]string{"alice@example.com", "bob@example.com"}); err != nil {
+		t.Fatalf("PromoteAdmins: %v", err)
+	}
+	alice, bob, carol := users["alice"], users["bob"], users["carol"]
+
+	// Each step runs in order; the actor is whoever the request came from
+	steps := []struct {
+		name    string
+		change  func() error
+		wantErr error
+	}{
+		{name: "demote one of two admins", change: func() error {
+			_, err := admins.SetRole(alice, bob, string(models.RoleUser))
+			return err
+		}},
+		{name: "demote the last admin", wantErr: ErrLastAdmin, change: func() error {
+			_, err := admins.SetRole(carol, alice, string(models.RoleUser))
+			return err
+		}},
+		{name: "disable the last admin", wantErr: ErrLastAdmin, change: func() error {
+			_, err := admins.SetDisabled(carol, alice, true)
+			return err
+		}},
+		{name: "promote a second admin", change: func() error {
+			_, err := admins.SetRole(alice, bob, string(models.RoleAdmin))
+			return err
+		}},
+		{name: "disable one of two admins", change: func() error {
+			_, err := admins.SetDisabled(alice, bob, true)
+			return err
+		}},
+		{name: "disabled admins do not count", wantErr: ErrLastAdmin, change: func() error {
+			_, err := admins.SetRole(carol, alice, string(models.RoleUser))
+			return err
+		}},
+		{name: "regular users are unaffected", change: func() error {
+			_, err := admins.SetDisabled(alice, carol, true)
+			return err
+		}},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+
+	var stored models.User
+	if err := db.Where("id = ?", alice).Take(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Role() != models.RoleAdmin || stored.IsDisabled {
+		t.Errorf("last admin is now role %s, disabled %v", stored.Role(), stored.IsDisabled)
+	}
+}
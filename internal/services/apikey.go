@@ -0,0 +1,238 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+)
+
+// apiKeyPrefix starts every API key so that leaked keys are easy to recognize
+const apiKeyPrefix = "dr_"
+
+// maxAPIKeysPerUser bounds the active keys of one user
+const maxAPIKeysPerUser = 50
+
+// lastUsedInterval is how stale LastUsedAt may get before a request updates it
+const lastUsedInterval = time.Minute
+
+// API key errors
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyExpired = errors.New("API key expired")
+	ErrAPIKeyRevoked = errors.New("API key revoked")
+)
+
+// APIKeyService manages the personal API keys of users
+type APIKeyService struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+// Create issues a new key for the user and returns its record and the key
+// itself, which is not stored and cannot be shown again. Keys get the read and
+// write scopes when none are given.
+func (s *APIKeyService) Create(user *models.User, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+	if len(scopes) == 0 {
+		scopes = []string{string(models.ScopeRead), string(models.ScopeWrite)}
+	}
+	scopesJSON, err := apiKeyScopes(user, scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+
+	var active int64
+	if err := s.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", user.ID, time.Now()).
+		Count(&active).Error; err != nil {
+		return nil, "", err
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, "", errors.New("too many API keys")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	apiKey := models.APIKey{
+		UserID:    user.ID,
+		Name:      name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		KeyHash:   hashToken(key),
+		Scopes:    scopesJSON,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.Create(&apiKey).Error; err != nil {
+		return nil, "", err
+	}
+	return &apiKey, key, nil
+}
+
+// List returns every key of a user, newest first
+func (s *APIKeyService) List(userID string) ([]models.APIKey, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var keys []models.APIKey
+	err = s.db.Where("user_id = ?", userUUID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// Get returns one key of a user
+func (s *APIKeyService) Get(keyID, userID string) (*models.APIKey, error) {
+	keyUUID, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, errors.New("invalid API key ID")
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var apiKey models.APIKey
+	err = s.db.Where("id = ? AND user_id = ?", keyUUID, userUUID).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("API key not found")
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Update renames a key and, when scopes is not empty, replaces its scopes
+func (s *APIKeyService) Update(keyID string, user *models.User, name string, scopes []string) (*models.APIKey, error) {
+	apiKey, err := s.Get(keyID, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	updates := map[string]interface{}{}
+	if name = strings.TrimSpace(name); name != "" {
+		updates["name"] = name
+	}
+	if len(scopes) > 0 {
+		scopesJSON, err := apiKeyScopes(user, scopes)
+		if err != nil {
+			return nil, err
+		}
+		updates["scopes"] = scopesJSON
+	}
+	if len(updates) == 0 {
+		return apiKey, nil
+	}
+
+	if err := s.db.Model(apiKey).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	if name, ok := updates["name"].(string); ok {
+		apiKey.Name = name
+	}
+	if scopes, ok := updates["scopes"].(string); ok {
+		apiKey.Scopes = scopes
+	}
+	return apiKey, nil
+}
+
+// Revoke permanently disables a key; revoking a revoked key does nothing
+func (s *APIKeyService) Revoke(keyID, userID string) error {
+	apiKey, err := s.Get(keyID, userID)
+	if err != nil {
+		return err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+	return s.db.Model(apiKey).Update("revoked_at", time.Now()).Error
+}
+
+// apiKeyScopes validates requested scopes and returns them as stored
+func apiKeyScopes(user *models.User, scopes []string) (string, error) {
+	var valid []models.APIKeyScope
+	seen := make(map[models.APIKeyScope]bool)
+	for _, name := range scopes {
+		scope := models.APIKeyScope(strings.TrimSpace(name))
+		if !scope.IsValid() {
+			return "", errors.New("invalid scope")
+		}
+		if scope == models.ScopeAdmin && user.Role() != models.RoleAdmin {
+			return "", errors.New("admin scope requires the admin role")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+
+	data, err := json.Marshal(valid)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// AuthenticateAPIKey returns the user and record of an API key, recording
+// when the key was last used
+func (s *AuthService) AuthenticateAPIKey(key string) (*models.User, *models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var apiKey models.APIKey
+	err := s.db.Where("key_hash = ?", hashToken(key)).First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if apiKey.RevokedAt != nil {
+		return nil, nil, ErrAPIKeyRevoked
+	}
+	if !apiKey.IsActive(now) {
+		return nil, nil, ErrAPIKeyExpired
+	}
+
+	user, err := s.GetUserByID(apiKey.UserID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+
+	// Busy scripts would otherwise write on every request
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedInterval {
+		if err := s.db.Model(&apiKey).UpdateColumn("last_used_at", now).Error; err != nil {
+			return nil, nil, err
+		}
+	}
+	return user, &apiKey, nil
+}
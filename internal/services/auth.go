@@ -1,38 +1,78 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/signing"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Refresh token errors
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token expired")
+	// ErrRefreshTokenReused is returned when a refresh token is presented after it
+	// was exchanged or revoked; every token of its family is revoked in response
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrTokenRevoked is returned for access tokens revoked by a logout
+	ErrTokenRevoked = errors.New("token has been revoked")
+	// ErrAccountDisabled is returned when a disabled user signs in or refreshes a token
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrInvalidCredentials is returned for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
 // AuthService handles authentication operations
 type AuthService struct {
-	db        *gorm.DB
-	jwtSecret string
-	jwtExpiry time.Duration
+	db         *gorm.DB
+	keys       *signing.Keyring
+	accessTTL  time.Duration
+	refreshTTL time.Duration
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(db *gorm.DB, jwtSecret string, expiryHours int) *AuthService {
+// NewAuthService creates a new authentication service issuing access tokens
+// signed with keys and valid for accessTTL, and refresh tokens valid for refreshTTL
+func NewAuthService(db *gorm.DB, keys *signing.Keyring, accessTTL, refreshTTL time.Duration) *AuthService {
+	if accessTTL <= 0 {
+		accessTTL = 15 * time.Minute
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = 30 * 24 * time.Hour
+	}
 	return &AuthService{
-		db:        db,
-		jwtSecret: jwtSecret,
-		jwtExpiry: time.Duration(expiryHours) * time.Hour,
+		db:         db,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
 	}
 }
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       string `json:"user_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	TokenVersion int    `json:"ver"` // Must match the user's TokenVersion
 	jwt.RegisteredClaims
 }
 
+// TokenPair is an access token and the refresh token that renews it
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
 // Register creates a new user account
 func (s *AuthService) Register(email, password, name string) (*models.User, error) {
 	// Check if user already exists
@@ -65,55 +105,257 @@ func (s *AuthService) Register(email, password, name string) (*models.User, erro
 	return &user, nil
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *AuthService) Login(email, password string) (*models.User, string, error) {
+// Login authenticates a user and issues a new token pair
+func (s *AuthService) Login(email, password string) (*models.User, *TokenPair, error) {
 	// Find user by email
 	var user models.User
 	err := s.db.Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", errors.New("invalid credentials")
+			return nil, nil, ErrInvalidCredentials
 		}
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Check password
 	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, nil, ErrInvalidCredentials
+	}
+	if user.IsDisabled {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	tokens, err := s.IssueTokens(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &user, tokens, nil
+}
+
+// IssueTokens starts a new refresh token family for a user, as on login
+func (s *AuthService) IssueTokens(user *models.User) (*TokenPair, error) {
+	tokens, stored, err := s.newTokenPair(user, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(stored).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// newTokenPair creates an access token and a refresh token of the given family,
+// returning the unsaved record of the refresh token
+func (s *AuthService) newTokenPair(user *models.User, familyID uuid.UUID) (*TokenPair, *models.RefreshToken, error) {
+	accessToken, accessExpiresAt, err := s.GenerateToken(user)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Generate JWT token
-	token, err := s.GenerateToken(&user)
+	refreshToken, stored, err := s.newRefreshToken(user.ID, familyID)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return &user, token, nil
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, stored, nil
 }
 
-// GenerateToken creates a JWT token for a user
-func (s *AuthService) GenerateToken(user *models.User) (string, error) {
+// newRefreshToken returns a random refresh token and the record that stores its hash
+func (s *AuthService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	return token, &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}, nil
+}
+
+// hashToken returns the hex SHA-256 digest under which a refresh token is stored
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is used up; presenting it again revokes every token of its family.
+func (s *AuthService) Refresh(refreshToken string) (*models.User, *TokenPair, error) {
+	var stored models.RefreshToken
+	err := s.db.Where("token_hash = ?", hashToken(refreshToken)).First(&stored).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	if stored.UsedAt != nil || stored.RevokedAt != nil {
+		if err := s.revokeFamily(s.db, stored.FamilyID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, ErrRefreshTokenExpired
+	}
+
+	user, err := s.GetUserByID(stored.UserID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if user.IsDisabled {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	tokens, next, err := s.newTokenPair(user, stored.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Claim the token atomically so that two concurrent refreshes cannot both succeed
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", stored.ID).
+			Updates(map[string]interface{}{"used_at": time.Now(), "replaced_by_id": next.ID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+		return tx.Create(next).Error
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := s.revokeFamily(s.db, stored.FamilyID); revokeErr != nil {
+			return nil, nil, errors.Join(err, revokeErr)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// revokeFamily revokes every live token rotated from the same login
+func (s *AuthService) revokeFamily(tx *gorm.DB, familyID uuid.UUID) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Logout revokes the access token described by claims and, when given, the
+// refresh token family it was issued with
+func (s *AuthService) Logout(claims *JWTClaims, refreshToken string) error {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return errors.New("invalid token")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if claims.ID != "" && claims.ExpiresAt != nil {
+			revoked := models.RevokedToken{
+				JTI:       claims.ID,
+				UserID:    userID,
+				ExpiresAt: claims.ExpiresAt.Time,
+			}
+			if err := tx.Where(models.RevokedToken{JTI: claims.ID}).FirstOrCreate(&revoked).Error; err != nil {
+				return err
+			}
+		}
+
+		if refreshToken != "" {
+			var stored models.RefreshToken
+			err := tx.Where("token_hash = ? AND user_id = ?", hashToken(refreshToken), userID).First(&stored).Error
+			switch {
+			case err == nil:
+				if err := s.revokeFamily(tx, stored.FamilyID); err != nil {
+					return err
+				}
+			case !errors.Is(err, gorm.ErrRecordNotFound):
+				return err
+			}
+		}
+
+		return s.purgeExpired(tx)
+	})
+}
+
+// LogoutAll signs a user out of every device by invalidating all of their
+// access tokens and revoking all of their refresh tokens. API keys are not
+// affected; they are revoked one by one, or all at once by a password reset.
+func (s *AuthService) LogoutAll(userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ?", userID).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return s.purgeExpired(tx)
+	})
+}
+
+// purgeExpired deletes revocations and refresh tokens that have expired anyway
+func (s *AuthService) purgeExpired(tx *gorm.DB) error {
+	now := time.Now()
+	if err := tx.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error
+}
+
+// GenerateToken creates a short-lived access token for a user
+func (s *AuthService) GenerateToken(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.accessTTL)
 	claims := JWTClaims{
-		UserID: user.ID.String(),
-		Email:  user.Email,
+		UserID:       user.ID.String(),
+		Email:        user.Email,
+		Role:         string(user.Role()),
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtExpiry)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	signed, err := s.keys.Sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
 }
 
-// ValidateToken validates a JWT token and returns the claims
+// ValidateToken validates a JWT token and returns the claims. Tokens must be
+// signed by a key of the keyring with that key's algorithm.
 func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(s.jwtSecret), nil
-	})
-
+	token, err := s.keys.Parse(tokenString, &JWTClaims{})
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +367,26 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	return nil, errors.New("invalid token")
 }
 
+// CheckRevoked returns ErrTokenRevoked when the access token was revoked by a
+// logout or issued before the user logged out of all devices
+func (s *AuthService) CheckRevoked(claims *JWTClaims, user *models.User) error {
+	if claims.TokenVersion != user.TokenVersion {
+		return ErrTokenRevoked
+	}
+	if claims.ID == "" {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
 // GetUserByID retrieves a user by ID
 func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 	var user models.User
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/signing"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+// accessClaims validates an access token and checks it against the stored user
+func accessClaims(t *testing.T, auth *AuthService, token string) error {
+	t.Helper()
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+	user, err := auth.GetUserByID(claims.UserID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return auth.CheckRevoked(claims, user)
+}
+
+func TestRefreshRotation(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	if _, err := auth.Register("rotation@example.com", "password123", "Test"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	_, first, err := auth.Login("rotation@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	_, other, err := auth.Login("rotation@example.com", "password123")
+	if err != nil {
+		t.Fatalf("second Login: %v", err)
+	}
+
+	// Every refresh issues a new pair and uses up the token it was given
+	_, second, err := auth.Refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Error("Refresh returned the tokens it was given")
+	}
+	_, third, err := auth.Refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh of the rotated token: %v", err)
+	}
+
+	// Replaying a used token means it leaked, so the whole family is revoked
+	if _, _, err := auth.Refresh(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("replayed Refresh error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, _, err := auth.Refresh(third.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("Refresh of the family's latest token error = %v, want ErrRefreshTokenReused", err)
+	}
+
+	// Other logins are separate families
+	if _, _, err := auth.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("Refresh of another login: %v", err)
+	}
+	if _, _, err := auth.Refresh("not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	user, err := auth.Register("logout@example.com", "password123", "Test")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Two devices
+	_, laptop, err := auth.Login("logout@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	_, phone, err := auth.Login("logout@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	for _, tokens := range []*TokenPair{laptop, phone} {
+		if err := accessClaims(t, auth, tokens.AccessToken); err != nil {
+			t.Fatalf("CheckRevoked before logout: %v", err)
+		}
+	}
+
+	if err := auth.LogoutAll(user.ID.String()); err != nil {
+		t.Fatalf("LogoutAll: %v", err)
+	}
+	for name, tokens := range map[string]*TokenPair{"laptop": laptop, "phone": phone} {
+		if err := accessClaims(t, auth, tokens.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("%s access token: CheckRevoked = %v, want ErrTokenRevoked", name, err)
+		}
+		if _, _, err := auth.Refresh(tokens.RefreshToken); err == nil {
+			t.Errorf("%s refresh token still works", name)
+		}
+	}
+
+	// Signing in again works
+	_, fresh, err := auth.Login("logout@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login after LogoutAll: %v", err)
+	}
+	if err := accessClaims(t, auth, fresh.AccessToken); err != nil {
+		t.Errorf("new access token: CheckRevoked = %v", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	db := testdb.Open(t)
+	auth := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	if _, err := auth.Register("single@example.com", "password123", "Test"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	_, laptop, err := auth.Login("single@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	_, phone, err := auth.Login("single@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	claims, err := auth.ValidateToken(laptop.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := auth.Logout(claims, laptop.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	// Only the device that logged out is signed out
+	if err := accessClaims(t, auth, laptop.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("logged out access token: CheckRevoked = %v, want ErrTokenRevoked", err)
+	}
+	if _, _, err := auth.Refresh(laptop.RefreshToken); err == nil {
+		t.Error("logged out refresh token still works")
+	}
+	if err := accessClaims(t, auth, phone.AccessToken); err != nil {
+		t.Errorf("other device: CheckRevoked = %v", err)
+	}
+}
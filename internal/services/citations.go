@@ -0,0 +1,117 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/retrieval"
+)
+
+// maxQuoteLength caps the text stored with a citation
+const maxQuoteLength = 1000
+
+// citationPattern matches inline markers such as [1] and [1, 3]
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// resolveCitations turns the [n] markers of an answer into citations of the
+// grounding documents the answer was synthesized from, where [n] refers to
+// grounding[n-1]. When the answer was grounded in retrieved passages,
+// passages[n-1] is the chunk behind grounding[n-1]. Each citation points at the
+// sentence of the cited text that best matches the claim in front of the marker.
+// Markers that start a line, such as a trailing source list, cite no claim and
+// are skipped, as are numbers outside the grounding.
+func resolveCitations(messageID uuid.UUID, answer string, grounding []models.Document, passages []retrieval.Match) []models.Citation {
+	var citations []models.Citation
+	for _, match := range citationPattern.FindAllStringSubmatchIndex(answer, -1) {
+		claim := claimBefore(answer, match[0])
+		if claim == "" {
+			continue
+		}
+
+		for _, number := range strings.Split(answer[match[2]:match[3]], ",") {
+			marker, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil || marker < 1 || marker > len(grounding) {
+				continue
+			}
+			doc := grounding[marker-1]
+
+			citation := models.Citation{
+				MessageID:  messageID,
+				Marker:     marker,
+				Position:   match[0],
+				Claim:      claim,
+				SourceID:   doc.SourceID,
+				DocumentID: doc.ID,
+			}
+
+			// Offsets within the cited text are shifted to offsets within the whole document
+			base := 0
+			if len(passages) == len(grounding) {
+				chunk := passages[marker-1].Chunk
+				chunkID := chunk.ID
+				citation.ChunkID = &chunkID
+				base = chunk.StartOffset
+			}
+			start, end := locateClaim(doc.Content, claim)
+			citation.StartOffset = base + start
+			citation.EndOffset = base + end
+			citation.Quote = truncateText(doc.Content[start:end], maxQuoteLength)
+
+			citations = append(citations, citation)
+		}
+	}
+	return citations
+}
+
+// claimBefore returns the sentence that ends at the marker at pos, without other markers
+func claimBefore(answer string, pos int) string {
+	line := answer[:pos]
+	if i := strings.LastIndexByte(line, '\n'); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.TrimSpace(citationPattern.ReplaceAllString(line, ""))
+
+	// A marker may follow the sentence's full stop, so the last character is not a boundary
+	if len(line) > 1 {
+		if i := strings.LastIndexAny(line[:len(line)-1], ".!?"); i >= 0 {
+			line = line[i+1:]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+// locateClaim returns the byte span of content that best supports the claim:
+// the claim itself when it is quoted verbatim, otherwise the sentence sharing
+// the most terms with it, otherwise all of content
+func locateClaim(content, claim string) (int, int) {
+	quoted := strings.TrimRight(claim, ".!?:; ")
+	if quoted != "" {
+		if i := strings.Index(content, quoted); i >= 0 {
+			return i, i + len(quoted)
+		}
+	}
+
+	terms := queryTerms(claim)
+	bestStart, bestEnd, bestScore := 0, len(content), 0
+	for _, span := range sentencePattern.FindAllStringIndex(content, -1) {
+		lower := strings.ToLower(content[span[0]:span[1]])
+		score := 0
+		for _, term := range terms {
+			if strings.Contains(lower, term) {
+				score++
+			}
+		}
+		if score > bestScore {
+			bestStart, bestEnd, bestScore = span[0], span[1], score
+		}
+	}
+
+	// Sentence matches start with the whitespace that followed the previous sentence
+	for bestStart < bestEnd && strings.ContainsRune(" \t\r\n", rune(content[bestStart])) {
+		bestStart++
+	}
+	return bestStart, bestEnd
+}
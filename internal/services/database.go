@@ -57,20 +57,30 @@ func NewDatabaseService(cfg *config.Config) (*DatabaseService, error) {
 
 	// Auto-migrate the schema (this process checks and creates tables/indexes)
 	// First startup will be slower as it creates the schema
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.ResearchSession{},
-		&models.Message{},
-		&models.Source{},
-		&models.Document{},
-	)
+	err = db.AutoMigrate(models.AllModels()...)
 	if err != nil {
 		return nil, err
 	}
 
+	// Source URLs used to be globally unique; they are now unique per session
+	if db.Migrator().HasIndex(&models.Source{}, "idx_sources_url") {
+		if err := db.Migrator().DropIndex(&models.Source{}, "idx_sources_url"); err != nil {
+			return nil, err
+		}
+	}
+
 	return &DatabaseService{db: db}, nil
 }
 
+// EnableVectorStore creates the pgvector extension and migrates the document
+// chunks, whose embeddings live in a vector column
+func (s *DatabaseService) EnableVectorStore() error {
+	if err := s.db.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		return err
+	}
+	return s.db.AutoMigrate(models.VectorModels()...)
+}
+
 // GetDB returns the underlying GORM database instance
 func (s *DatabaseService) GetDB() *gorm.DB {
 	return s.db
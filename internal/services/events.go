@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultEventLogSize is the number of events replayed to a reconnecting subscriber
+	defaultEventLogSize = 1000
+	// defaultEventRetention is how long stored events are kept for replay
+	defaultEventRetention = 24 * time.Hour
+	// subscriberBuffer is the number of events a slow subscriber may fall behind
+	subscriberBuffer = 256
+	// eventChannel is the Postgres NOTIFY channel events are broadcast on
+	eventChannel = "research_events"
+	// maxNotifyPayload keeps notifications under Postgres' 8000 byte limit;
+	// larger events are sent by ID and loaded by the listeners
+	maxNotifyPayload = 7500
+	// listenRetry is the delay before reconnecting a lost listener
+	listenRetry = 5 * time.Second
+)
+
+// EventBroker fans research events out to session subscribers across every
+// process sharing the database. Events are stored in session_events, whose
+// sequence gives them IDs that are unique across processes, and broadcast with
+// NOTIFY; every broker LISTENs and forwards them to its own subscribers.
+// Reconnecting clients replay what they missed from the table. Token events
+// are only broadcast, with ID 0, since the final answer is stored anyway.
+type EventBroker struct {
+	db        *gorm.DB
+	dsn       string
+	node      string // Identifies this broker's notifications, which it delivers directly
+	logSize   int
+	retention time.Duration
+
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*eventSubscriber]struct{}
+}
+
+// eventSubscriber is a live subscription to one session
+type eventSubscriber struct {
+	ch      chan models.ResearchEvent
+	lastID  uint64
+	ready   bool                   // Replay is loaded; events go to ch
+	pending []models.ResearchEvent // Events received while the replay loads
+}
+
+// eventNotification is the NOTIFY payload of an event. Events too large for a
+// notification carry only their stored ID.
+type eventNotification struct {
+	Node      string                `json:"node"`
+	SessionID uuid.UUID             `json:"session_id"`
+	EventID   uint64                `json:"event_id,omitempty"`
+	Event     *models.ResearchEvent `json:"event,omitempty"`
+}
+
+// NewEventBroker creates a new event broker that stores events in db and
+// listens for other processes' events on a dedicated connection to dsn.
+// Non-positive sizes and retentions fall back to the defaults.
+func NewEventBroker(db *gorm.DB, dsn string, logSize int, retention time.Duration) *EventBroker {
+	if logSize <= 0 {
+		logSize = defaultEventLogSize
+	}
+	if retention <= 0 {
+		retention = defaultEventRetention
+	}
+	return &EventBroker{
+		db:          db,
+		dsn:         dsn,
+		node:        uuid.NewString(),
+		logSize:     logSize,
+		retention:   retention,
+		subscribers: make(map[uuid.UUID]map[*eventSubscriber]struct{}),
+	}
+}
+
+// Start listens for events published by other processes and prunes expired
+// events until ctx is cancelled
+func (b *EventBroker) Start(ctx context.Context) {
+	go b.listen(ctx)
+	go b.pruneLoop(ctx)
+}
+
+// Publish stores an event, assigning its ID, delivers it to this process'
+// subscribers and broadcasts it to the others. Subscribers that cannot keep up
+// are disconnected; they can resume with their last event ID. When the event
+// cannot be stored it is still delivered live, with ID 0.
+func (b *EventBroker) Publish(sessionID uuid.UUID, event models.ResearchEvent) models.ResearchEvent {
+	event.ID = 0
+	event.SessionID = sessionID.String()
+	if event.Timestamp == "" {
+		event.Timestamp = time.Now().Format(time.RFC3339)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode research event: %v", err)
+		return event
+	}
+	if event.Type != models.ResearchEventToken {
+		stored := models.SessionEvent{SessionID: sessionID, Type: event.Type, Payload: string(payload)}
+		if err := b.db.Create(&stored).Error; err != nil {
+			log.Printf("Failed to store research event of session %s: %v", sessionID, err)
+		} else {
+			event.ID = stored.ID
+		}
+	}
+
+	b.deliver(sessionID, event)
+
+	notification := eventNotification{Node: b.node, SessionID: sessionID, Event: &event}
+	data, err := json.Marshal(notification)
+	if err == nil && len(data) > maxNotifyPayload && event.ID != 0 {
+		notification.Event, notification.EventID = nil, event.ID
+		data, err = json.Marshal(notification)
+	}
+	if err == nil && len(data) <= maxNotifyPayload {
+		err = b.db.Exec("SELECT pg_notify(?, ?)", eventChannel, string(data)).Error
+	}
+	if err != nil {
+		log.Printf("Failed to broadcast research event of session %s: %v", sessionID, err)
+	}
+	return event
+}
+
+// LastEventID returns the ID of the session's most recently stored event
+func (b *EventBroker) LastEventID(sessionID uuid.UUID) (uint64, error) {
+	var lastID uint64
+	err := b.db.Model(&models.SessionEvent{}).
+		Where("session_id = ?", sessionID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&lastID).Error
+	return lastID, err
+}
+
+// Subscribe returns the stored events after lastEventID, at most the broker's
+// log size, and a channel of live events that follow them.
+// The channel is closed when the subscriber falls behind; call cancel to unsubscribe.
+func (b *EventBroker) Subscribe(sessionID uuid.UUID, lastEventID uint64) ([]models.ResearchEvent, <-chan models.ResearchEvent, func(), error) {
+	sub := &eventSubscriber{
+		ch:     make(chan models.ResearchEvent, subscriberBuffer),
+		lastID: lastEventID,
+	}
+
+	// Register before loading the replay so nothing published meanwhile is lost
+	b.mu.Lock()
+	subs, ok := b.subscribers[sessionID]
+	if !ok {
+		subs = make(map[*eventSubscriber]struct{})
+		b.subscribers[sessionID] = subs
+	}
+	subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.remove(sessionID, sub)
+		})
+	}
+
+	missed, err := b.load(sessionID, lastEventID, 0)
+	if err != nil {
+		cancel()
+		return nil, nil, nil, err
+	}
+
+	b.mu.Lock()
+	if len(missed) > 0 {
+		sub.lastID = max(sub.lastID, missed[len(missed)-1].ID)
+	}
+	sub.ready = true
+	pending := sub.pending
+	sub.pending = nil
+	for _, event := range pending {
+		b.send(sessionID, sub, event)
+	}
+	b.mu.Unlock()
+
+	return missed, sub.ch, cancel, nil
+}
+
+// deliver sends an event to this process' subscribers of the session
+func (b *EventBroker) deliver(sessionID uuid.UUID, event models.ResearchEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers[sessionID] {
+		b.send(sessionID, sub, event)
+	}
+}
+
+// send delivers an event to a subscriber unless it has already seen it,
+// disconnecting subscribers that fall behind. Callers must hold b.mu.
+func (b *EventBroker) send(sessionID uuid.UUID, sub *eventSubscriber, event models.ResearchEvent) {
+	if !sub.ready {
+		sub.pending = append(sub.pending, event)
+		return
+	}
+	if event.ID != 0 {
+		if event.ID <= sub.lastID {
+			return
+		}
+		sub.lastID = event.ID
+	}
+	select {
+	case sub.ch <- event:
+	default:
+		b.remove(sessionID, sub)
+	}
+}
+
+// remove unsubscribes and closes a subscriber. Callers must hold b.mu.
+func (b *EventBroker) remove(sessionID uuid.UUID, sub *eventSubscriber) {
+	subs := b.subscribers[sessionID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.ch)
+	if len(subs) == 0 {
+		delete(b.subscribers, sessionID)
+	}
+}
+
+// load returns the session's stored events after afterID, oldest first. It
+// returns the newest log size events, or the one event upTo when it is set.
+func (b *EventBroker) load(sessionID uuid.UUID, afterID, upTo uint64) ([]models.ResearchEvent, error) {
+	query := b.db.Where("session_id = ? AND id > ?", sessionID, afterID)
+	if upTo != 0 {
+		query = query.Where("id <= ?", upTo)
+	}
+	var stored []models.SessionEvent
+	if err := query.Order("id DESC").Limit(b.logSize).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	slices.Reverse(stored)
+
+	events := make([]models.ResearchEvent, 0, len(stored))
+	for _, row := range stored {
+		var event models.ResearchEvent
+		if err := json.Unmarshal([]byte(row.Payload), &event); err != nil {
+			log.Printf("Failed to decode stored research event %d: %v", row.ID, err)
+			continue
+		}
+		event.ID = row.ID
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// listen forwards the events other processes broadcast, reconnecting when the
+// connection is lost. After every reconnect subscribers catch up from the table.
+func (b *EventBroker) listen(ctx context.Context) {
+	for {
+		err := b.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Research event listener disconnected: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetry):
+		}
+	}
+}
+
+// listenOnce listens on one connection until it fails or ctx is cancelled
+func (b *EventBroker) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventChannel); err != nil {
+		return err
+	}
+	b.catchUp()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var note eventNotification
+		if err := json.Unmarshal([]byte(notification.Payload), &note); err != nil {
+			log.Printf("Failed to decode research event notification: %v", err)
+			continue
+		}
+		if note.Node == b.node {
+			continue
+		}
+		if note.Event != nil {
+			b.deliver(note.SessionID, *note.Event)
+			continue
+		}
+		b.mu.Lock()
+		_, subscribed := b.subscribers[note.SessionID]
+		b.mu.Unlock()
+		if !subscribed {
+			continue
+		}
+		events, err := b.load(note.SessionID, note.EventID-1, note.EventID)
+		if err != nil {
+			log.Printf("Failed to load research event %d: %v", note.EventID, err)
+			continue
+		}
+		for _, event := range events {
+			b.deliver(note.SessionID, event)
+		}
+	}
+}
+
+// catchUp delivers the stored events subscribers missed while no listener was connected
+func (b *EventBroker) catchUp() {
+	b.mu.Lock()
+	after := make(map[uuid.UUID]uint64, len(b.subscribers))
+	for sessionID, subs := range b.subscribers {
+		first := true
+		for sub := range subs {
+			if first || sub.lastID < after[sessionID] {
+				after[sessionID] = sub.lastID
+				first = false
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	for sessionID, lastID := range after {
+		events, err := b.load(sessionID, lastID, 0)
+		if err != nil {
+			log.Printf("Failed to replay research events of session %s: %v", sessionID, err)
+			continue
+		}
+		for _, event := range events {
+			b.deliver(sessionID, event)
+		}
+	}
+}
+
+// pruneLoop periodically deletes events older than the retention period
+func (b *EventBroker) pruneLoop(ctx context.Context) {
+	ticker := time.NewTicker(min(b.retention, 10*time.Minute))
+	defer ticker.Stop()
+
+	for {
+		err := b.db.WithContext(ctx).
+			Where("created_at < ?", time.Now().Add(-b.retention)).
+			Delete(&models.SessionEvent{}).Error
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to prune research events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
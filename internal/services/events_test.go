@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+func TestEventBrokerAcrossProcesses(t *testing.T) {
+	db, dsn := testdb.OpenWithDSN(t)
+	session := createTestSession(t, db, "When was Go released?")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Two brokers on one database stand in for two server processes
+	publisher := NewEventBroker(db, dsn, 0, 0)
+	listener := NewEventBroker(db, dsn, 0, 0)
+	listener.Start(ctx)
+
+	first := publisher.Publish(session.ID, models.ResearchEvent{Type: models.ResearchEventThoughtStarted, Title: "Planning research"})
+	if first.ID == 0 {
+		t.Fatal("stored event has no ID")
+	}
+
+	missed, live, unsubscribe, err := listener.Subscribe(session.ID, 0)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer unsubscribe()
+	if len(missed) != 1 || missed[0].ID != first.ID || missed[0].Title != "Planning research" {
+		t.Fatalf("replayed %+v, want the first event", missed)
+	}
+
+	// Give the listener time to connect before publishing live events
+	time.Sleep(500 * time.Millisecond)
+	published := []models.ResearchEvent{
+		publisher.Publish(session.ID, models.ResearchEvent{Type: models.ResearchEventToken, Delta: "Go"}),
+		publisher.Publish(session.ID, models.ResearchEvent{Type: models.ResearchEventCompleted, Content: "done"}),
+	}
+
+	tests := []struct {
+		name   string
+		wantID uint64
+		want   models.ResearchEventType
+	}{
+		{name: "token events are live only", wantID: 0, want: models.ResearchEventToken},
+		{name: "stored events keep their ID", wantID: published[1].ID, want: models.ResearchEventCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			select {
+			case event := <-live:
+				if event.ID != tt.wantID || event.Type != tt.want {
+					t.Errorf("got event %d %s, want %d %s", event.ID, event.Type, tt.wantID, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no event received from the other broker")
+			}
+		})
+	}
+
+	lastID, err := listener.LastEventID(session.ID)
+	if err != nil || lastID != published[1].ID {
+		t.Errorf("LastEventID = %d, %v, want %d", lastID, err, published[1].ID)
+	}
+	if published[1].ID <= first.ID {
+		t.Errorf("event IDs do not increase: %d after %d", published[1].ID, first.ID)
+	}
+}
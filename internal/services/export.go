@@ -0,0 +1,241 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/export"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+)
+
+// ExportService builds exportable reports of research sessions
+type ExportService struct {
+	db *gorm.DB
+}
+
+// NewExportService creates a new export service
+func NewExportService(db *gorm.DB) *ExportService {
+	return &ExportService{
+		db: db,
+	}
+}
+
+// BuildReport collects a session's summaries, its final answer and the sources
+// that answer cites from stored data only, so exporting twice gives the same report.
+// The answer's markers are renumbered to match the bibliography: sources cited by
+// the answer come first in order of first citation, followed by the other sources
+// the answer was written from.
+func (s *ExportService) BuildReport(sessionID, userID string) (*export.Report, error) {
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &export.Report{
+		Title:     session.Title,
+		Query:     session.Query,
+		CreatedAt: session.CreatedAt,
+		Summaries: []export.Summary{},
+		Sources:   []export.Source{},
+	}
+
+	var summaries []models.Summary
+	if err := s.db.Where("session_id = ?", session.ID).Find(&summaries).Error; err != nil {
+		return nil, err
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		return summaryOrder(summaries[i].Type) < summaryOrder(summaries[j].Type)
+	})
+	for _, summary := range summaries {
+		report.Summaries = append(report.Summaries, exportSummary(summary))
+	}
+
+	var message models.Message
+	err = s.db.Preload("Sources").Preload("Citations").
+		Where("session_id = ? AND type = ? AND is_visible = ? AND content <> ''", session.ID, models.MessageTypeAssistant, true).
+		Order("created_at DESC").
+		Take(&message).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return report, nil
+	case err != nil:
+		return nil, err
+	}
+
+	// Markers of the stored answer number the documents it was grounded in
+	markerSources := make(map[int]uuid.UUID)
+	for _, citation := range message.Citations {
+		markerSources[citation.Marker] = citation.SourceID
+	}
+	sources := make(map[uuid.UUID]models.Source, len(message.Sources))
+	for _, source := range message.Sources {
+		sources[source.ID] = source
+	}
+
+	numbers := make(map[uuid.UUID]int)
+	var order []uuid.UUID
+	report.Answer = renumberCitations(stripSourceList(message.Content), func(marker int) int {
+		sourceID, ok := markerSources[marker]
+		if !ok {
+			return 0
+		}
+		if _, ok := sources[sourceID]; !ok {
+			return 0
+		}
+		if _, ok := numbers[sourceID]; !ok {
+			order = append(order, sourceID)
+			numbers[sourceID] = len(order)
+		}
+		return numbers[sourceID]
+	})
+
+	uncited := make([]models.Source, 0, len(message.Sources))
+	for _, source := range message.Sources {
+		if _, ok := numbers[source.ID]; !ok {
+			uncited = append(uncited, source)
+		}
+	}
+	sort.Slice(uncited, func(i, j int) bool {
+		if uncited[i].Title != uncited[j].Title {
+			return uncited[i].Title < uncited[j].Title
+		}
+		return uncited[i].URL < uncited[j].URL
+	})
+	for _, source := range uncited {
+		order = append(order, source.ID)
+	}
+
+	for i, sourceID := range order {
+		report.Sources = append(report.Sources, exportSource(i+1, sources[sourceID]))
+	}
+	return report, nil
+}
+
+// Bibliography returns every source of a session, in the order they were found
+func (s *ExportService) Bibliography(sessionID, userID string) ([]export.Source, error) {
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []models.Source
+	err = s.db.Where("session_id = ?", session.ID).
+		Order("created_at ASC, url ASC").
+		Find(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]export.Source, len(sources))
+	for i, source := range sources {
+		entries[i] = exportSource(i+1, source)
+	}
+	return entries, nil
+}
+
+// exportSource converts a stored source into bibliography entry number
+func exportSource(number int, source models.Source) export.Source {
+	return export.Source{
+		Number:      number,
+		Title:       source.Title,
+		URL:         source.URL,
+		Domain:      source.Domain,
+		Type:        source.Type,
+		Authors:     source.AuthorNames(),
+		PublishedAt: source.PublishedAt,
+		DOI:         source.DOI,
+		Publisher:   source.Publisher,
+		Journal:     source.Journal,
+		AccessedAt:  source.LastCrawled,
+	}
+}
+
+// summaryOrder returns the position of a summary type in models.SummaryTypes
+func summaryOrder(summaryType models.SummaryType) int {
+	for i, known := range models.SummaryTypes {
+		if summaryType == known {
+			return i
+		}
+	}
+	return len(models.SummaryTypes)
+}
+
+// exportSummary converts a stored summary. Its citation markers number the
+// documents it was written from, which are not stored, so they are removed.
+// Key points already listed as the summary's content are not repeated.
+func exportSummary(summary models.Summary) export.Summary {
+	var points []string
+	if summary.KeyPoints != "" {
+		_ = json.Unmarshal([]byte(summary.KeyPoints), &points)
+	}
+	if len(points) > 0 && strings.TrimSpace(summary.Content) == "- "+strings.Join(points, "\n- ") {
+		points = nil
+	}
+
+	uncited := func(int) int { return 0 }
+	result := export.Summary{
+		Type:      string(summary.Type),
+		Title:     summary.Title,
+		Content:   renumberCitations(summary.Content, uncited),
+		KeyPoints: make([]string, 0, len(points)),
+	}
+	for _, point := range points {
+		result.KeyPoints = append(result.KeyPoints, renumberCitations(point, uncited))
+	}
+	return result
+}
+
+// stripSourceList removes a trailing source list such as the one extractive answers end with
+func stripSourceList(answer string) string {
+	lines := strings.Split(answer, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.EqualFold(trimmed, "Sources:") || strings.EqualFold(trimmed, "References:") {
+			lines = lines[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// renumberCitations rewrites every [n] marker of text with the numbers number
+// returns for it. Numbers of 0 are left out, as are markers left empty, together
+// with the space in front of them unless another marker follows.
+func renumberCitations(text string, number func(marker int) int) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range citationPattern.FindAllStringSubmatchIndex(text, -1) {
+		var numbers []string
+		seen := make(map[int]bool)
+		for _, part := range strings.Split(text[match[2]:match[3]], ",") {
+			marker, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			if n := number(marker); n > 0 && !seen[n] {
+				seen[n] = true
+				numbers = append(numbers, strconv.Itoa(n))
+			}
+		}
+
+		if len(numbers) == 0 {
+			before := text[last:match[0]]
+			if !strings.HasPrefix(text[match[1]:], "[") {
+				before = strings.TrimRight(before, " \t")
+			}
+			b.WriteString(before)
+		} else {
+			b.WriteString(text[last:match[0]])
+			fmt.Fprintf(&b, "[%s]", strings.Join(numbers, ", "))
+		}
+		last = match[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,603 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobQueueOptions configures the research job queue
+type JobQueueOptions struct {
+	Workers           int           // Jobs run concurrently by this process
+	PollInterval      time.Duration // How often idle workers look for new jobs
+	MaxAttempts       int
+	RetryBackoff      time.Duration // Delay before the first retry, doubled on every further attempt
+	MaxBackoff        time.Duration
+	HeartbeatInterval time.Duration
+	StaleAfter        time.Duration // Running jobs without a heartbeat for this long are recovered
+}
+
+// DefaultJobQueueOptions returns sensible job queue defaults
+func DefaultJobQueueOptions() JobQueueOptions {
+	return JobQueueOptions{
+		Workers:           2,
+		PollInterval:      2 * time.Second,
+		MaxAttempts:       3,
+		RetryBackoff:      10 * time.Second,
+		MaxBackoff:        5 * time.Minute,
+		HeartbeatInterval: 10 * time.Second,
+		StaleAfter:        time.Minute,
+	}
+}
+
+// ownedJobQuery matches a job while the attempt that claimed it still owns it.
+// Recovery and a new claim change the worker or the attempt count.
+const ownedJobQuery = "id = ? AND locked_by = ? AND attempts = ?"
+
+// activeJobStatuses are the statuses of jobs that still have to run or finish
+var activeJobStatuses = []models.JobStatus{models.JobStatusQueued, models.JobStatusRunning}
+
+// errCancelRequested is the cancellation cause of runs stopped by their user
+var errCancelRequested = errors.New("research run cancelled")
+
+// errLeaseLost is the cancellation cause of runs whose job was recovered and
+// claimed again, so that another attempt now owns it
+var errLeaseLost = errors.New("research job was claimed by another attempt")
+
+// JobQueue runs research jobs stored in Postgres on a pool of background workers.
+// Several processes can share the table: jobs are claimed with SELECT ... FOR UPDATE
+// SKIP LOCKED, running jobs send heartbeats, and jobs whose worker stopped
+// heartbeating are queued again.
+type JobQueue struct {
+	db       *gorm.DB
+	engine   *ResearchEngine
+	opts     JobQueueOptions
+	workerID string
+
+	mu      sync.Mutex
+	running map[uuid.UUID]context.CancelCauseFunc
+	workers sync.WaitGroup
+}
+
+// NewJobQueue creates a new job queue, filling unset options with defaults
+func NewJobQueue(db *gorm.DB, engine *ResearchEngine, opts JobQueueOptions) *JobQueue {
+	defaults := DefaultJobQueueOptions()
+	if opts.Workers <= 0 {
+		opts.Workers = defaults.Workers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaults.PollInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaults.MaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaults.RetryBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaults.MaxBackoff
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = defaults.HeartbeatInterval
+	}
+	if opts.StaleAfter <= opts.HeartbeatInterval {
+		opts.StaleAfter = 6 * opts.HeartbeatInterval
+	}
+
+	hostname, _ := os.Hostname()
+	return &JobQueue{
+		db:       db,
+		engine:   engine,
+		opts:     opts,
+		workerID: fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.NewString()[:8]),
+		running:  make(map[uuid.UUID]context.CancelCauseFunc),
+	}
+}
+
+// Start launches the workers and the stale job recovery loop.
+// They stop when ctx is cancelled; interrupted jobs are queued again.
+func (q *JobQueue) Start(ctx context.Context) {
+	q.workers.Add(q.opts.Workers + 1)
+	for i := 0; i < q.opts.Workers; i++ {
+		go func() {
+			defer q.workers.Done()
+			q.work(ctx)
+		}()
+	}
+	go func() {
+		defer q.workers.Done()
+		q.recoverLoop(ctx)
+	}()
+}
+
+// Wait blocks until the workers started by Start have stopped and recorded
+// the outcome of their jobs
+func (q *JobQueue) Wait() {
+	q.workers.Wait()
+}
+
+// Enqueue queues a research run for the session and moves the session back to pending
+func (q *JobQueue) Enqueue(session *models.ResearchSession, query string) (*models.ResearchJob, error) {
+	var job *models.ResearchJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		job, err = q.enqueue(tx, session, query, nil)
+		return err
+	})
+	return job, err
+}
+
+// enqueue queues a research run inside the caller's transaction, together with
+// the hidden reply that answers messageID once the run succeeds.
+// It fails while the session already has an active job.
+func (q *JobQueue) enqueue(tx *gorm.DB, session *models.ResearchSession, query string, messageID *uuid.UUID) (*models.ResearchJob, error) {
+	// Lock the session row so concurrent turns for the same session are serialized
+	var locked models.ResearchSession
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "status").
+		Where("id = ?", session.ID).
+		Take(&locked).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+
+	var active int64
+	err = tx.Model(&models.ResearchJob{}).
+		Where("session_id = ? AND status IN ?", session.ID, activeJobStatuses).
+		Count(&active).Error
+	if err != nil {
+		return nil, err
+	}
+	if active > 0 {
+		return nil, errors.New("research is already running")
+	}
+
+	session.Status = locked.Status
+	if err := queueSession(tx, session); err != nil {
+		if errors.Is(err, models.ErrInvalidStatusTransition) {
+			return nil, errors.New("research is already running")
+		}
+		return nil, err
+	}
+
+	reply, err := createReply(tx, session.ID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.ResearchJob{
+		SessionID:   session.ID,
+		MessageID:   messageID,
+		ReplyID:     &reply.ID,
+		Query:       query,
+		MaxAttempts: q.opts.MaxAttempts,
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Cancel stops the session's active job. A queued job is cancelled right away;
+// a running job is flagged and stopped by the worker that runs it.
+func (q *JobQueue) Cancel(sessionID uuid.UUID) (*models.ResearchJob, error) {
+	var job models.ResearchJob
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("session_id = ? AND status IN ?", sessionID, activeJobStatuses).
+			Take(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("no research run to cancel")
+			}
+			return err
+		}
+
+		job.CancelRequested = true
+		if job.Status == models.JobStatusRunning {
+			return tx.Model(&job).Update("cancel_requested", true).Error
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusCancelled
+		job.FinishedAt = &now
+		err = tx.Model(&job).Updates(map[string]interface{}{
+			"status":           job.Status,
+			"cancel_requested": true,
+			"finished_at":      now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		// The run never started, so the session is normally still pending
+		if err := transitionSession(tx, sessionID, models.SessionStatusCancelled); err != nil &&
+			!errors.Is(err, models.ErrInvalidStatusTransition) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop the run immediately when this process is running it; other
+	// processes notice the flag on their next heartbeat
+	q.mu.Lock()
+	if cancel, ok := q.running[job.ID]; ok {
+		cancel(errCancelRequested)
+	}
+	q.mu.Unlock()
+
+	return &job, nil
+}
+
+// work claims and runs jobs until ctx is cancelled
+func (q *JobQueue) work(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting for the next poll
+		for ctx.Err() == nil {
+			job, err := q.claim(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Failed to claim research job: %v", err)
+				}
+				break
+			}
+			if job == nil {
+				break
+			}
+			q.process(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks the oldest due job, skipping jobs other workers hold, and marks it running
+func (q *JobQueue) claim(ctx context.Context) (*models.ResearchJob, error) {
+	var job models.ResearchJob
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", models.JobStatusQueued, time.Now()).
+			Order("run_at ASC").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedBy = q.workerID
+		job.HeartbeatAt = &now
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		return tx.Model(&job).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"locked_by":    job.LockedBy,
+			"heartbeat_at": now,
+			"started_at":   job.StartedAt,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// process runs a claimed job with its own cancellable context and records the outcome
+func (q *JobQueue) process(ctx context.Context, job *models.ResearchJob) {
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	q.mu.Lock()
+	q.running[job.ID] = cancel
+	q.mu.Unlock()
+	defer func() {
+		q.mu.Lock()
+		delete(q.running, job.ID)
+		q.mu.Unlock()
+	}()
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		q.heartbeat(jobCtx, job, cancel)
+	}()
+
+	err := q.runJob(jobCtx, job, func(error) bool {
+		return q.retries(ctx, job, context.Cause(jobCtx))
+	})
+	cause := context.Cause(jobCtx)
+	cancel(nil)
+	<-heartbeatDone
+
+	// The attempt that owns the job now records its outcome
+	if errors.Is(cause, errLeaseLost) {
+		log.Printf("Research job %s for session %s was claimed by another attempt; stopped attempt %d",
+			job.ID, job.SessionID, job.Attempts)
+		return
+	}
+	if err != nil {
+		log.Printf("Research job %s for session %s failed (attempt %d/%d): %v",
+			job.ID, job.SessionID, job.Attempts, job.MaxAttempts, err)
+	}
+	if err := q.finish(ctx, job, err, cause); err != nil {
+		log.Printf("Failed to record outcome of research job %s: %v", job.ID, err)
+	}
+}
+
+// runJob executes the research run of a job; willRetry reports whether a failed
+// attempt will run again
+func (q *JobQueue) runJob(ctx context.Context, job *models.ResearchJob, willRetry func(err error) bool) error {
+	var session models.ResearchSession
+	if err := q.db.WithContext(ctx).Where("id = ?", job.SessionID).Take(&session).Error; err != nil {
+		return err
+	}
+	// Retries and recovered jobs find the session failed or cancelled
+	if err := queueSession(q.db.WithContext(ctx), &session); err != nil {
+		return err
+	}
+	reply, err := q.reply(ctx, job)
+	if err != nil {
+		return err
+	}
+	_, err = q.engine.RunReply(ctx, &session, job.Query, reply, willRetry)
+	return err
+}
+
+// reply loads the message the job's attempts write to. Jobs queued without
+// one get it now, so that their retries reuse it too.
+func (q *JobQueue) reply(ctx context.Context, job *models.ResearchJob) (*models.Message, error) {
+	if job.ReplyID != nil {
+		var reply models.Message
+		err := q.db.WithContext(ctx).Where("id = ?", *job.ReplyID).Take(&reply).Error
+		if err == nil {
+			return &reply, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	var reply *models.Message
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if reply, err = createReply(tx, job.SessionID, job.MessageID); err != nil {
+			return err
+		}
+		return tx.Model(job).Update("reply_id", reply.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	job.ReplyID = &reply.ID
+	return reply, nil
+}
+
+// heartbeat keeps a running job's lease alive and stops the run when a cancel is
+// requested, or when the job was recovered and this attempt no longer owns it
+func (q *JobQueue) heartbeat(ctx context.Context, job *models.ResearchJob, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(q.opts.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		result := q.db.WithContext(ctx).Model(&models.ResearchJob{}).
+			Where(ownedJobQuery, job.ID, job.LockedBy, job.Attempts).
+			Update("heartbeat_at", time.Now())
+		if result.Error != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to record heartbeat of research job %s: %v", job.ID, result.Error)
+			}
+			continue
+		}
+		if result.RowsAffected == 0 {
+			cancel(errLeaseLost)
+			return
+		}
+
+		var current models.ResearchJob
+		err := q.db.WithContext(ctx).Select("cancel_requested").Where("id = ?", job.ID).Take(&current).Error
+		if err == nil && current.CancelRequested {
+			cancel(errCancelRequested)
+			return
+		}
+	}
+}
+
+// finish records the outcome of a run: success, cancellation, a retry with backoff,
+// or a permanent failure once the attempts are used up. It returns errLeaseLost
+// and records nothing when another attempt owns the job by now.
+func (q *JobQueue) finish(ctx context.Context, job *models.ResearchJob, runErr, cause error) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"locked_by":    "",
+		"heartbeat_at": nil,
+	}
+	requeue := false
+
+	switch {
+	case runErr == nil:
+		updates["status"] = models.JobStatusSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case errors.Is(cause, errCancelRequested):
+		updates["status"] = models.JobStatusCancelled
+		updates["finished_at"] = now
+	case ctx.Err() != nil:
+		// The queue is shutting down; the interrupted attempt does not count
+		updates["status"] = models.JobStatusQueued
+		updates["run_at"] = now
+		updates["attempts"] = gorm.Expr("attempts - 1")
+		requeue = true
+	case job.Attempts < job.MaxAttempts:
+		updates["status"] = models.JobStatusQueued
+		updates["run_at"] = now.Add(q.backoff(job.Attempts))
+		updates["last_error"] = runErr.Error()
+		requeue = true
+	default:
+		updates["status"] = models.JobStatusFailed
+		updates["finished_at"] = now
+		updates["last_error"] = runErr.Error()
+	}
+
+	// Use a fresh context so the outcome is recorded even during shutdown
+	return q.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ResearchJob{}).
+			Where(ownedJobQuery, job.ID, job.LockedBy, job.Attempts).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errLeaseLost
+		}
+		if requeue {
+			return requeueSession(tx, job.SessionID)
+		}
+		return nil
+	})
+}
+
+// retries reports whether a failed attempt of the job runs again: always while
+// the queue shuts down or once another attempt owns the job, and otherwise
+// while attempts are left, unless the user cancelled the run
+func (q *JobQueue) retries(ctx context.Context, job *models.ResearchJob, cause error) bool {
+	if errors.Is(cause, errCancelRequested) {
+		return false
+	}
+	return ctx.Err() != nil || errors.Is(cause, errLeaseLost) || job.Attempts < job.MaxAttempts
+}
+
+// backoff returns the delay before retrying after the given attempt
+func (q *JobQueue) backoff(attempt int) time.Duration {
+	delay := q.opts.RetryBackoff
+	for i := 1; i < attempt && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		delay = q.opts.MaxBackoff
+	}
+	return delay
+}
+
+// recoverLoop periodically recovers jobs whose worker stopped heartbeating
+func (q *JobQueue) recoverLoop(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.StaleAfter / 2)
+	defer ticker.Stop()
+
+	for {
+		if err := q.recoverStale(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to recover stale research jobs: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverStale requeues running jobs left behind by a crashed worker, or fails
+// them once their attempts are used up, and releases their sessions
+func (q *JobQueue) recoverStale(ctx context.Context) error {
+	cutoff := time.Now().Add(-q.opts.StaleAfter)
+	staleQuery := "status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)"
+
+	var stale []models.ResearchJob
+	if err := q.db.WithContext(ctx).Where(staleQuery, models.JobStatusRunning, cutoff).Find(&stale).Error; err != nil {
+		return err
+	}
+
+	for _, job := range stale {
+		now := time.Now()
+		updates := map[string]interface{}{
+			"locked_by":    "",
+			"heartbeat_at": nil,
+			"last_error":   "worker stopped responding",
+		}
+		requeue := false
+		switch {
+		case job.CancelRequested:
+			updates["status"] = models.JobStatusCancelled
+			updates["finished_at"] = now
+		case job.Attempts < job.MaxAttempts:
+			updates["status"] = models.JobStatusQueued
+			updates["run_at"] = now
+			requeue = true
+		default:
+			updates["status"] = models.JobStatusFailed
+			updates["finished_at"] = now
+		}
+
+		err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// Re-check staleness so a job that just sent a heartbeat is left alone
+			result := tx.Model(&models.ResearchJob{}).
+				Where("id = ?", job.ID).
+				Where(staleQuery, models.JobStatusRunning, cutoff).
+				Updates(updates)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			// The crashed run never finished, so its session is still running
+			status := models.SessionStatusFailed
+			if job.CancelRequested {
+				status = models.SessionStatusCancelled
+			}
+			if err := transitionSession(tx, job.SessionID, status); err != nil &&
+				!errors.Is(err, models.ErrInvalidStatusTransition) {
+				return err
+			}
+			if requeue {
+				return requeueSession(tx, job.SessionID)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Recovered stale research job %s for session %s", job.ID, job.SessionID)
+	}
+	return nil
+}
+
+// requeueSession moves a finished session back to pending while its job waits for another attempt
+func requeueSession(tx *gorm.DB, sessionID uuid.UUID) error {
+	err := transitionSession(tx, sessionID, models.SessionStatusPending)
+	if err != nil && !errors.Is(err, models.ErrInvalidStatusTransition) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lolzone13/DeepResearch/internal/crawler"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/search"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+func TestJobRetriesReuseReply(t *testing.T) {
+	db := testdb.Open(t)
+	server := newPageServer(t)
+
+	model := &fakeLLM{
+		plan: `{"sub_questions": [{"question": "When was Go released?", "queries": ["go release year"]}]}`,
+		err:  errors.New("model unavailable"),
+	}
+	engine, err := NewResearchEngine(db, ResearchStages{
+		Planner: LLMPlanner{Provider: model},
+		Searcher: ProviderSearcher{Provider: search.NewFixtureFromResults(map[string][]search.Result{
+			"*": {{Title: "Go", URL: server.URL + "/go"}},
+		})},
+		Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+		Analyzer:    LexicalAnalyzer{},
+		Synthesizer: LLMSynthesizer{Provider: model},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewJobQueue(db, engine, JobQueueOptions{RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	session := createTestSession(t, db, "When was Go released?")
+	messages := NewMessageService(db, queue)
+	question, err := messages.CreateUserMessage(session.ID.String(), session.UserID.String(), "When was Go released?")
+	if err != nil {
+		t.Fatalf("CreateUserMessage: %v", err)
+	}
+
+	// The first attempt fails, the second succeeds
+	for attempt, fail := range []bool{true, false} {
+		if !fail {
+			model.err = nil
+			model.answer = "Go was released in 2009 [1]."
+		}
+		time.Sleep(5 * time.Millisecond)
+		job, err := queue.claim(context.Background())
+		if err != nil || job == nil {
+			t.Fatalf("attempt %d: claim = %v, %v", attempt+1, job, err)
+		}
+		queue.process(context.Background(), job)
+
+		var replies []models.Message
+		if err := db.Where("session_id = ? AND type = ?", session.ID, models.MessageTypeAssistant).Find(&replies).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(replies) != 1 {
+			t.Fatalf("attempt %d: got %d assistant messages, want 1", attempt+1, len(replies))
+		}
+		reply := replies[0]
+		if reply.ReplyToID == nil || *reply.ReplyToID != question.ID {
+			t.Errorf("attempt %d: reply answers %v, want %s", attempt+1, reply.ReplyToID, question.ID)
+		}
+		if reply.IsVisible == fail {
+			t.Errorf("attempt %d: reply visible = %v, want %v", attempt+1, reply.IsVisible, !fail)
+		}
+
+		var failed int64
+		db.Model(&models.Thought{}).Where("message_id = ? AND status = ?", reply.ID, "failed").Count(&failed)
+		if fail && failed == 0 {
+			t.Errorf("attempt %d: no failed thoughts recorded", attempt+1)
+		}
+		if !fail && failed != 0 {
+			t.Errorf("attempt %d: %d failed thoughts of the earlier attempt were kept", attempt+1, failed)
+		}
+	}
+
+	var job models.ResearchJob
+	if err := db.Where("session_id = ?", session.ID).Take(&job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != models.JobStatusSucceeded || job.Attempts != 2 {
+		t.Errorf("job status = %s after %d attempts, want succeeded after 2", job.Status, job.Attempts)
+	}
+}
+
+func TestJobFailureEvents(t *testing.T) {
+	db := testdb.Open(t)
+	server := newPageServer(t)
+
+	model := &fakeLLM{
+		plan: `{"sub_questions": [{"question": "When was Go released?", "queries": ["go release year"]}]}`,
+		err:  errors.New("model unavailable"),
+	}
+	events := NewEventBroker(db, "", 0, 0)
+	engine, err := NewResearchEngine(db, ResearchStages{
+		Planner: LLMPlanner{Provider: model},
+		Searcher: ProviderSearcher{Provider: search.NewFixtureFromResults(map[string][]search.Result{
+			"*": {{Title: "Go", URL: server.URL + "/go"}},
+		})},
+		Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+		Analyzer:    LexicalAnalyzer{},
+		Synthesizer: LLMSynthesizer{Provider: model},
+	}, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewJobQueue(db, engine, JobQueueOptions{MaxAttempts: 2, RetryBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	session := createTestSession(t, db, "When was Go released?")
+	if _, err := queue.Enqueue(session, "When was Go released?"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	// Only the last attempt announces that the run failed
+	for attempt, want := range []models.ResearchEventType{models.ResearchEventRetrying, models.ResearchEventFailed} {
+		after, err := events.LastEventID(session.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+		job, err := queue.claim(context.Background())
+		if err != nil || job == nil {
+			t.Fatalf("attempt %d: claim = %v, %v", attempt+1, job, err)
+		}
+		queue.process(context.Background(), job)
+
+		published, _, unsubscribe, err := events.Subscribe(session.ID, after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unsubscribe()
+		var outcomes []models.ResearchEventType
+		for _, event := range published {
+			switch event.Type {
+			case models.ResearchEventRetrying, models.ResearchEventFailed, models.ResearchEventCompleted:
+				outcomes = append(outcomes, event.Type)
+			}
+		}
+		if len(outcomes) != 1 || outcomes[0] != want {
+			t.Errorf("attempt %d: published %v, want only %s", attempt+1, outcomes, want)
+		}
+	}
+}
+
+func TestJobLeaseLost(t *testing.T) {
+	db := testdb.Open(t)
+	engine, err := NewResearchEngine(db, ResearchStages{
+		Planner:     QueryPlanner{},
+		Searcher:    SeedURLSearcher{},
+		Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+		Analyzer:    LexicalAnalyzer{},
+		Synthesizer: ExtractiveSynthesizer{},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewJobQueue(db, engine, JobQueueOptions{HeartbeatInterval: 10 * time.Millisecond})
+
+	session := createTestSession(t, db, "When was Go released?")
+	if _, err := queue.Enqueue(session, "When was Go released?"); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	job, err := queue.claim(context.Background())
+	if err != nil || job == nil {
+		t.Fatalf("claim = %v, %v", job, err)
+	}
+
+	// The job is recovered and claimed by another worker while this attempt still runs
+	err = db.Model(&models.ResearchJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"locked_by": "another-worker",
+		"attempts":  job.Attempts + 1,
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		queue.heartbeat(ctx, job, cancel)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeat kept running after the job was claimed again")
+	}
+	if cause := context.Cause(ctx); !errors.Is(cause, errLeaseLost) {
+		t.Errorf("run cancelled with %v, want errLeaseLost", cause)
+	}
+
+	if err := queue.finish(context.Background(), job, nil, nil); !errors.Is(err, errLeaseLost) {
+		t.Errorf("finish error = %v, want errLeaseLost", err)
+	}
+	var stored models.ResearchJob
+	if err := db.Where("id = ?", job.ID).Take(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.JobStatusRunning || stored.LockedBy != "another-worker" {
+		t.Errorf("stale attempt overwrote the job: status %s, locked by %q", stored.Status, stored.LockedBy)
+	}
+}
+
+func TestJobQueueRetries(t *testing.T) {
+	queue := NewJobQueue(nil, nil, JobQueueOptions{})
+	stopped, stop := context.WithCancel(context.Background())
+	stop()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		attempts int
+		cause    error
+		want     bool
+	}{
+		{name: "attempts left", ctx: context.Background(), attempts: 1, want: true},
+		{name: "last attempt", ctx: context.Background(), attempts: 3},
+		{name: "cancelled by the user", ctx: context.Background(), attempts: 1, cause: errCancelRequested},
+		{name: "queue shutting down", ctx: stopped, attempts: 3, cause: context.Canceled, want: true},
+		{name: "claimed by another attempt", ctx: context.Background(), attempts: 3, cause: errLeaseLost, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &models.ResearchJob{Attempts: tt.attempts, MaxAttempts: 3}
+			if got := queue.retries(tt.ctx, job, tt.cause); got != tt.want {
+				t.Errorf("retries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobQueueWaitsForWorkers(t *testing.T) {
+	db := testdb.Open(t)
+	engine, err := NewResearchEngine(db, ResearchStages{
+		Planner:     QueryPlanner{},
+		Searcher:    SeedURLSearcher{},
+		Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+		Analyzer:    LexicalAnalyzer{},
+		Synthesizer: ExtractiveSynthesizer{},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queue := NewJobQueue(db, engine, JobQueueOptions{Workers: 3, PollInterval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queue.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		queue.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("workers did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/throttle"
+	"gorm.io/gorm"
+)
+
+// LoginGuardOptions configures the brute-force protection of password logins
+type LoginGuardOptions struct {
+	AccountAttempts int           // Failures per email before lockouts start
+	IPAttempts      int           // Failures per client IP before lockouts start
+	BaseLockout     time.Duration // First lockout; doubled for every further failure
+	MaxLockout      time.Duration
+	Window          time.Duration // Failures are forgotten this long after the latest one
+}
+
+// LoginAttempt describes a login for throttling and auditing
+type LoginAttempt struct {
+	Method    string // How the user signs in; models.LoginMethodPassword when empty
+	Email     string
+	IP        string
+	UserAgent string
+
+	account throttle.Reservation // Reserved by LoginGuard.Reserve
+	ip      throttle.Reservation
+}
+
+// LoginGuard locks out emails and client IPs that keep failing to sign in,
+// for exponentially longer the more they fail, and records every attempt in
+// the audit log, including identity provider logins, which are not
+// throttled. Store errors are logged and let the attempt through, so an
+// unavailable store does not lock everyone out.
+type LoginGuard struct {
+	db       *gorm.DB
+	accounts *throttle.Limiter
+	ips      *throttle.Limiter
+}
+
+// NewLoginGuard creates a login guard that keeps its counters in store
+func NewLoginGuard(db *gorm.DB, store throttle.Store, opts LoginGuardOptions) (*LoginGuard, error) {
+	if opts.AccountAttempts <= 0 {
+		opts.AccountAttempts = 5
+	}
+	if opts.IPAttempts <= 0 {
+		opts.IPAttempts = 20
+	}
+	if opts.BaseLockout <= 0 {
+		opts.BaseLockout = 30 * time.Second
+	}
+	if opts.MaxLockout <= 0 {
+		opts.MaxLockout = time.Hour
+	}
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+
+	accounts, err := throttle.NewLimiter(store, "login:account", throttle.Policy{
+		FreeAttempts: opts.AccountAttempts,
+		BaseLockout:  opts.BaseLockout,
+		MaxLockout:   opts.MaxLockout,
+		Window:       opts.Window,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ips, err := throttle.NewLimiter(store, "login:ip", throttle.Policy{
+		FreeAttempts: opts.IPAttempts,
+		BaseLockout:  opts.BaseLockout,
+		MaxLockout:   opts.MaxLockout,
+		Window:       opts.Window,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginGuard{db: db, accounts: accounts, ips: ips}, nil
+}
+
+// Reserve counts the attempt against its email and IP before the password is
+// checked, so that concurrent guesses cannot slip past a lockout. It returns how
+// long the email or IP stays locked out, or 0 when the attempt may go ahead and
+// must then be finished with Failed or Succeeded. Rejected attempts are audited.
+func (g *LoginGuard) Reserve(ctx context.Context, attempt *LoginAttempt) time.Duration {
+	account, wait, err := g.accounts.Reserve(ctx, accountKey(attempt.Email))
+	if err != nil {
+		log.Printf("Failed to check login throttle: %v", err)
+	}
+	if wait == 0 {
+		var ip throttle.Reservation
+		ip, wait, err = g.ips.Reserve(ctx, attempt.IP)
+		if err != nil {
+			log.Printf("Failed to check login throttle: %v", err)
+		}
+		if wait > 0 {
+			g.release(ctx, g.accounts, account)
+		} else {
+			attempt.account, attempt.ip = account, ip
+		}
+	}
+
+	if wait > 0 {
+		g.audit(ctx, models.AuditLoginLocked, nil, attempt, fmt.Sprintf("locked out for %s", wait.Round(time.Second)))
+	}
+	return wait
+}
+
+// Failed audits a failed attempt and returns the lockout it started, if any.
+// Only wrong credentials count towards lockouts; other failures release the
+// attempt's reservations.
+func (g *LoginGuard) Failed(ctx context.Context, attempt *LoginAttempt, reason error) time.Duration {
+	var lockout time.Duration
+	if errors.Is(reason, ErrInvalidCredentials) {
+		lockout = max(attempt.account.Lockout, attempt.ip.Lockout)
+	} else {
+		g.release(ctx, g.accounts, attempt.account)
+		g.release(ctx, g.ips, attempt.ip)
+	}
+	attempt.account, attempt.ip = throttle.Reservation{}, throttle.Reservation{}
+
+	g.audit(ctx, models.AuditLoginFailed, nil, attempt, reason.Error())
+	return lockout
+}
+
+// Succeeded audits a successful attempt and clears the failures of its email.
+// Earlier failures of the IP are kept so that signing in to one account does
+// not reset the count of guesses at others.
+func (g *LoginGuard) Succeeded(ctx context.Context, attempt *LoginAttempt, user *models.User) {
+	if attempt.account.Attempt != 0 {
+		if err := g.accounts.Reset(ctx, attempt.account.Key); err != nil {
+			log.Printf("Failed to reset login throttle: %v", err)
+		}
+	}
+	g.release(ctx, g.ips, attempt.ip)
+	attempt.account, attempt.ip = throttle.Reservation{}, throttle.Reservation{}
+
+	if attempt.Email == "" {
+		attempt.Email = user.Email
+	}
+	g.audit(ctx, models.AuditLoginSucceeded, &user.ID, attempt, "")
+}
+
+// release uncounts a reserved attempt that did not fail
+func (g *LoginGuard) release(ctx context.Context, limiter *throttle.Limiter, reservation throttle.Reservation) {
+	if err := limiter.Release(ctx, reservation); err != nil {
+		log.Printf("Failed to release login throttle: %v", err)
+	}
+}
+
+// audit records an event for the attempt
+func (g *LoginGuard) audit(ctx context.Context, action models.AuditAction, userID *uuid.UUID, attempt *LoginAttempt, detail string) {
+	method := attempt.Method
+	if method == "" {
+		method = models.LoginMethodPassword
+	}
+	event := models.AuditEvent{
+		Action:    action,
+		Method:    method,
+		UserID:    userID,
+		Email:     attempt.Email,
+		IP:        attempt.IP,
+		UserAgent: truncateText(attempt.UserAgent, 512),
+		Detail:    detail,
+	}
+	if err := g.db.WithContext(ctx).Create(&event).Error; err != nil {
+		log.Printf("Failed to record audit event %s: %v", action, err)
+	}
+}
+
+// accountKey returns the throttle key of an email, so that differently cased
+// spellings of one address share a counter
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+)
+
+// MessageService handles chat turns within research sessions
+type MessageService struct {
+	db   *gorm.DB
+	jobs *JobQueue
+}
+
+// NewMessageService creates a new message service
+func NewMessageService(db *gorm.DB, jobs *JobQueue) *MessageService {
+	return &MessageService{
+		db:   db,
+		jobs: jobs,
+	}
+}
+
+// CreateUserMessage stores a user turn and queues a research job for it.
+// The assistant reply is stored as a separate message that becomes visible when the job finishes.
+// Only one turn per session is researched at a time.
+func (s *MessageService) CreateUserMessage(sessionID, userID, content string) (*models.Message, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("message content is required")
+	}
+
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	message := models.Message{
+		SessionID: session.ID,
+		Type:      models.MessageTypeUser,
+		Content:   content,
+		IsVisible: true,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		if _, err := s.jobs.enqueue(tx, session, content, &message.ID); err != nil {
+			return err
+		}
+		return tx.Model(&models.ResearchSession{}).
+			Where("id = ?", session.ID).
+			UpdateColumn("message_count", gorm.Expr("message_count + ?", 1)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// ListMessages returns a page of a session's messages in chronological order,
+// with their thoughts, cited sources and inline citations, the cursor of the next
+// page and whether more messages follow. The cursor points after the last message
+// of the page, so polling with it returns replies published later. When the page
+// is empty the given cursor is returned unchanged.
+func (s *MessageService) ListMessages(sessionID, userID, cursor string, limit int) ([]models.Message, string, bool, error) {
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	// Replies still being researched are hidden until their answer is final
+	query := s.db.Where("session_id = ? AND is_visible = ?", session.ID, true)
+	if cursor != "" {
+		createdAt, id, err := decodeMessageCursor(cursor)
+		if err != nil {
+			return nil, "", false, err
+		}
+		query = query.Where("(created_at, id) > (?, ?)", createdAt, id)
+	}
+
+	// Fetch one extra row to know whether another page exists
+	var messages []models.Message
+	err = query.
+		Preload("Thoughts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("started_at ASC")
+		}).
+		Preload("Sources").
+		Preload("Citations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC, marker ASC")
+		}).
+		Order("created_at ASC, id ASC").
+		Limit(limit + 1).
+		Find(&messages).Error
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	nextCursor := cursor
+	if len(messages) > 0 {
+		last := messages[len(messages)-1]
+		nextCursor = encodeMessageCursor(last.CreatedAt, last.ID)
+	}
+
+	return messages, nextCursor, hasMore, nil
+}
+
+// findOwnedSession loads a session that belongs to the user
+func findOwnedSession(db *gorm.DB, sessionID, userID string) (*models.ResearchSession, error) {
+	sessionUUID, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, errors.New("invalid session ID")
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var session models.ResearchSession
+	err = db.Where("id = ? AND user_id = ?", sessionUUID, userUUID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("session not found")
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// encodeMessageCursor builds an opaque cursor from a message's sort key
+func encodeMessageCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeMessageCursor parses a cursor built by encodeMessageCursor
+func decodeMessageCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	createdAtPart, idPart, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	id, err := uuid.Parse(idPart)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	return createdAt, id, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+func TestListMessagesHidesPendingReplies(t *testing.T) {
+	db := testdb.Open(t)
+	session := createTestSession(t, db, "When was Go released?")
+	service := NewMessageService(db, nil)
+
+	question := models.Message{SessionID: session.ID, Type: models.MessageTypeUser, Content: "When was Go released?", IsVisible: true}
+	if err := db.Create(&question).Error; err != nil {
+		t.Fatal(err)
+	}
+	reply := models.Message{SessionID: session.ID, Type: models.MessageTypeAssistant, IsVisible: false}
+	if err := db.Select("*").Create(&reply).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(cursor string) ([]models.Message, string) {
+		t.Helper()
+		messages, next, _, err := service.ListMessages(session.ID.String(), session.UserID.String(), cursor, 20)
+		if err != nil {
+			t.Fatalf("ListMessages: %v", err)
+		}
+		return messages, next
+	}
+
+	messages, cursor := list("")
+	if len(messages) != 1 || messages[0].ID != question.ID {
+		t.Fatalf("got %d messages, want only the question", len(messages))
+	}
+
+	// Polling before the reply is published returns nothing and keeps the cursor
+	messages, next := list(cursor)
+	if len(messages) != 0 || next != cursor {
+		t.Fatalf("got %d messages and cursor %q, want none and %q", len(messages), next, cursor)
+	}
+
+	if err := db.Model(&reply).Updates(map[string]interface{}{
+		"content":    "In 2009 [1].",
+		"is_visible": true,
+		"created_at": time.Now(),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	messages, _ = list(cursor)
+	if len(messages) != 1 || messages[0].ID != reply.ID || messages[0].Content != "In 2009 [1]." {
+		t.Fatalf("got %+v, want the published reply", messages)
+	}
+}
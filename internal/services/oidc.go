@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/oidc"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// OIDCStateTTL is how long a user has to sign in at the identity provider
+	OIDCStateTTL = 10 * time.Minute
+	// oidcLoginCodeTTL is how long the web app has to redeem a login code
+	oidcLoginCodeTTL = time.Minute
+)
+
+// Identity provider login errors
+var (
+	ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+	ErrInvalidOIDCState    = errors.New("invalid or expired login state")
+	ErrInvalidLoginCode    = errors.New("invalid or expired login code")
+	// ErrOIDCLoginFailed wraps failures to exchange the code or verify the ID token
+	ErrOIDCLoginFailed     = errors.New("identity provider login failed")
+	ErrOIDCNoEmail         = errors.New("identity provider did not return an email address")
+	ErrOIDCEmailUnverified = errors.New("identity provider has not verified the email address of an existing account")
+	ErrOIDCEmailNotAllowed = errors.New("email domain is not allowed")
+	ErrOIDCSignupDisabled  = errors.New("no account is linked to this identity and sign-up is disabled")
+)
+
+// OIDCProviderOptions configures sign-in through one identity provider
+type OIDCProviderOptions struct {
+	Name           string // Used in the login URLs
+	Provider       *oidc.Provider
+	AutoProvision  bool     // Create accounts for users without one
+	AllowedDomains []string // Email domains that may sign in; empty allows every domain
+}
+
+// OIDCService signs users in through OpenID Connect identity providers with
+// the authorization code flow and PKCE, then issues our own tokens. Logins are
+// bound to the client that started them: Begin returns a secret the client
+// keeps, in a cookie for browsers, and the callback must present it.
+type OIDCService struct {
+	db          *gorm.DB
+	authService *AuthService
+	providers   map[string]OIDCProviderOptions
+}
+
+// NewOIDCService creates a new OIDC service
+func NewOIDCService(db *gorm.DB, authService *AuthService, providers []OIDCProviderOptions) (*OIDCService, error) {
+	byName := make(map[string]OIDCProviderOptions, len(providers))
+	for _, provider := range providers {
+		if provider.Name == "" || provider.Provider == nil {
+			return nil, errors.New("identity providers need a name and a provider")
+		}
+		if _, exists := byName[provider.Name]; exists {
+			return nil, fmt.Errorf("identity provider %q is configured twice", provider.Name)
+		}
+		byName[provider.Name] = provider
+	}
+	return &OIDCService{
+		db:          db,
+		authService: authService,
+		providers:   byName,
+	}, nil
+}
+
+// Providers returns the names of the configured identity providers
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Begin starts a login and returns the provider URL the user signs in at and
+// the binding secret the callback must present
+func (s *OIDCService) Begin(ctx context.Context, providerName string) (authURL, binding string, err error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownOIDCProvider
+	}
+
+	state := models.OIDCLoginState{
+		Provider:  providerName,
+		ExpiresAt: time.Now().Add(OIDCStateTTL),
+	}
+	if state.State, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	if state.Nonce, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	if state.CodeVerifier, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	if binding, err = oidc.RandomString(); err != nil {
+		return "", "", err
+	}
+	state.BindingHash = hashToken(binding)
+
+	authURL, err = provider.Provider.AuthCodeURL(ctx, state.State, state.Nonce, state.CodeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		return "", "", err
+	}
+	if err := s.db.Create(&state).Error; err != nil {
+		return "", "", err
+	}
+	return authURL, binding, nil
+}
+
+// Complete finishes a login with the code the provider returned, links or
+// provisions the user and issues a new token pair
+func (s *OIDCService) Complete(ctx context.Context, providerName, state, code, binding string) (*models.User, *TokenPair, error) {
+	user, err := s.Authenticate(ctx, providerName, state, code, binding)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := s.authService.IssueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Authenticate finishes a login with the code the provider returned and
+// returns the linked or provisioned user without issuing tokens
+func (s *OIDCService) Authenticate(ctx context.Context, providerName, state, code, binding string) (*models.User, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+
+	login, err := s.consumeState(providerName, state, binding)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := provider.Provider.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	idToken, err := provider.Provider.VerifyIDToken(ctx, token.IDToken, login.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	user, err := s.linkUser(provider, idToken)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
+}
+
+// IssueLoginCode returns a single-use code the web app redeems for the user's tokens
+func (s *OIDCService) IssueLoginCode(user *models.User) (string, error) {
+	code, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginCode{}).Error; err != nil {
+		return "", err
+	}
+	record := models.OIDCLoginCode{
+		CodeHash:  hashToken(code),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(oidcLoginCodeTTL),
+	}
+	if err := s.db.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// RedeemLoginCode uses up a login code and issues a new token pair for its user
+func (s *OIDCService) RedeemLoginCode(code string) (*models.User, *TokenPair, error) {
+	if code == "" {
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	var record models.OIDCLoginCode
+	result := s.db.Clauses(clause.Returning{}).
+		Where("code_hash = ? AND expires_at > ?", hashToken(code), time.Now()).
+		Delete(&record)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil, ErrInvalidLoginCode
+	}
+
+	user, err := s.authService.GetUserByID(record.UserID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidLoginCode
+		}
+		return nil, nil, err
+	}
+	if user.IsDisabled {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	tokens, err := s.authService.IssueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// consumeState deletes and returns a pending login so that it cannot be
+// replayed. The binding must be the one Begin returned for the state.
+func (s *OIDCService) consumeState(providerName, state, binding string) (*models.OIDCLoginState, error) {
+	if state == "" || binding == "" {
+		return nil, ErrInvalidOIDCState
+	}
+
+	var login models.OIDCLoginState
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("state = ? AND provider = ? AND expires_at > ?", state, providerName, time.Now()).First(&login).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidOIDCState
+			}
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(login.BindingHash), []byte(hashToken(binding))) != 1 {
+			return ErrInvalidOIDCState
+		}
+
+		result := tx.Where("state = ?", state).Delete(&models.OIDCLoginState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidOIDCState
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// linkUser returns the user of an identity. Identities seen before sign in as
+// their linked user; new ones are linked to the account with the same email,
+// when the provider verified it, or get a new account when provisioning is on.
+func (s *OIDCService) linkUser(provider OIDCProviderOptions, idToken *oidc.IDToken) (*models.User, error) {
+	if len(provider.AllowedDomains) > 0 && !emailDomainAllowed(idToken.Email, provider.AllowedDomains) {
+		return nil, ErrOIDCEmailNotAllowed
+	}
+
+	var user models.User
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var identity models.UserIdentity
+		err := tx.Where("issuer = ? AND subject = ?", idToken.Issuer, idToken.Subject).First(&identity).Error
+		if err == nil {
+			if err := tx.Where("id = ?", identity.UserID).First(&user).Error; err != nil {
+				return err
+			}
+			return tx.Model(&identity).Updates(map[string]interface{}{
+				"email":         idToken.Email,
+				"last_login_at": time.Now(),
+			}).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if idToken.Email == "" {
+			return ErrOIDCNoEmail
+		}
+		err = tx.Where("LOWER(email) = LOWER(?)", idToken.Email).First(&user).Error
+		switch {
+		case err == nil:
+			// Linking to an existing account is only safe when the provider vouches for the address
+			if !idToken.EmailVerified {
+				return ErrOIDCEmailUnverified
+			}
+			if !user.IsEmailVerified {
+				user.IsEmailVerified = true
+				if err := tx.Model(&user).Update("is_email_verified", true).Error; err != nil {
+					return err
+				}
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if !provider.AutoProvision {
+				return ErrOIDCSignupDisabled
+			}
+			name := strings.TrimSpace(idToken.Name)
+			if name == "" {
+				name = idToken.Email[:strings.IndexByte(idToken.Email+"@", '@')]
+			}
+			// Provisioned accounts have no password until the user resets one
+			user = models.User{
+				Email:           idToken.Email,
+				Name:            name,
+				IsEmailVerified: idToken.EmailVerified,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:      user.ID,
+			Provider:    provider.Name,
+			Issuer:      idToken.Issuer,
+			Subject:     idToken.Subject,
+			Email:       idToken.Email,
+			LastLoginAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// emailDomainAllowed reports whether the domain of email is one of domains
+func emailDomainAllowed(email string, domains []string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range domains {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+	return false
+}
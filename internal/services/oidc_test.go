@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/oidc"
+	"github.com/lolzone13/DeepResearch/internal/oidc/oidctest"
+	"github.com/lolzone13/DeepResearch/internal/signing"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	const redirectURL = "https://api.example.com/auth/oidc/test/callback"
+	idp := oidctest.NewServer(t, "deepresearch", redirectURL)
+	idp.Email = "sso@example.com"
+	provider, err := oidc.NewProvider(oidc.Config{Issuer: idp.URL, ClientID: "deepresearch", RedirectURL: redirectURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authService := NewAuthService(db, signing.NewHMACKeyring(testTokenSecret), 0, 0)
+	service, err := NewOIDCService(db, authService, []OIDCProviderOptions{{Name: "test", Provider: provider, AutoProvision: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, binding, err := service.Begin(ctx, "test")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	callback := idp.Authorize(t, authURL)
+	state, code := callback.Get("state"), callback.Get("code")
+
+	// Only the client that started the login can finish it
+	for name, other := range map[string]string{"no binding": "", "another binding": "not-" + binding} {
+		if _, err := service.Authenticate(ctx, "test", state, code, other); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Errorf("%s: Authenticate error = %v, want ErrInvalidOIDCState", name, err)
+		}
+	}
+	if _, err := service.Authenticate(ctx, "other", state, code, binding); !errors.Is(err, ErrUnknownOIDCProvider) {
+		t.Errorf("unknown provider: Authenticate error = %v, want ErrUnknownOIDCProvider", err)
+	}
+
+	user, err := service.Authenticate(ctx, "test", state, code, binding)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Email != idp.Email || !user.IsEmailVerified {
+		t.Errorf("provisioned user = %+v", user)
+	}
+
+	// States work once
+	if _, err := service.Authenticate(ctx, "test", state, code, binding); !errors.Is(err, ErrInvalidOIDCState) {
+		t.Errorf("replayed state: Authenticate error = %v, want ErrInvalidOIDCState", err)
+	}
+
+	loginCode, err := service.IssueLoginCode(user)
+	if err != nil {
+		t.Fatalf("IssueLoginCode: %v", err)
+	}
+	redeemed, tokens, err := service.RedeemLoginCode(loginCode)
+	if err != nil {
+		t.Fatalf("RedeemLoginCode: %v", err)
+	}
+	if redeemed.ID != user.ID || tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Errorf("redeemed user %s with tokens %+v", redeemed.ID, tokens)
+	}
+	if _, _, err := service.RedeemLoginCode(loginCode); !errors.Is(err, ErrInvalidLoginCode) {
+		t.Errorf("second RedeemLoginCode error = %v, want ErrInvalidLoginCode", err)
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// LLMPlanner breaks the research question into sub-questions with a language
+// model and checks what was read for gaps after every search round
+type LLMPlanner struct {
+	Provider           llm.Provider
+	QueriesPerQuestion int // Search queries written per sub-question
+	MaxDocumentChars   int // Per-document budget of the gap check
+}
+
+// Plan asks the model for sub-questions and the searches that answer each of them.
+// The number of sub-questions follows the session's search depth.
+func (p LLMPlanner) Plan(ctx context.Context, session *models.ResearchSession, query string) (*ResearchPlan, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("empty research query")
+	}
+
+	var plan ResearchPlan
+	err := p.ask(ctx, &plan,
+		fmt.Sprintf("You plan web research. Break the question into at most %d sub-questions that together answer it, "+
+			"and write up to %d concise web search queries for each. ", session.SearchDepth.Profile().SubQuestions, p.queriesPerQuestion())+
+			`Reply with a JSON object: {"sub_questions": [{"question": string, "queries": [string]}]}.`,
+		fmt.Sprintf("Question: %s", query))
+	if err != nil {
+		return nil, err
+	}
+
+	p.trim(&plan, session.SearchDepth.Profile().SubQuestions)
+	if len(plan.Queries()) == 0 {
+		// The question itself is always a reasonable search
+		plan.SubQuestions = []SubQuestion{{Question: query, Queries: []string{query}}}
+	}
+	return &plan, nil
+}
+
+// Expand adds related queries the same way QueryPlanner does
+func (p LLMPlanner) Expand(ctx context.Context, session *models.ResearchSession, query string, queries []string, sources []models.Source) ([]string, error) {
+	return QueryPlanner{}.Expand(ctx, session, query, queries, sources)
+}
+
+// FollowUp shows the model the plan and what was read, and asks which parts are
+// still unanswered and what to search for next
+func (p LLMPlanner) FollowUp(ctx context.Context, session *models.ResearchSession, query string, plan *ResearchPlan, documents []models.Document) (*ResearchPlan, error) {
+	limit := p.MaxDocumentChars
+	if limit <= 0 {
+		limit = 600
+	}
+
+	var planned strings.Builder
+	for i, sub := range plan.SubQuestions {
+		fmt.Fprintf(&planned, "%d. %s (searched: %s)\n", i+1, sub.Question, strings.Join(sub.Queries, "; "))
+	}
+	var findings strings.Builder
+	for i, doc := range documents {
+		fmt.Fprintf(&findings, "[%d] %s\n%s\n\n", i+1, doc.Title, truncateText(documentSummaryText(doc), limit))
+	}
+
+	var next ResearchPlan
+	err := p.ask(ctx, &next,
+		"You review web research in progress. Compare the sub-questions with the documents read so far "+
+			"and list the gaps: sub-questions or aspects of the question the documents do not answer. "+
+			fmt.Sprintf("For each gap worth another search, add a sub-question with up to %d new search queries that differ from those already searched. ", p.queriesPerQuestion())+
+			`Set "done" to true when the documents answer the question well enough. `+
+			`Reply with a JSON object: {"gaps": [string], "sub_questions": [{"question": string, "queries": [string]}], "done": boolean}.`,
+		fmt.Sprintf("Question: %s\n\nSub-questions:\n%s\nDocuments read:\n\n%s", query, planned.String(), findings.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	// Queries that were already searched would find the same sources again
+	searched := make(map[string]bool)
+	for _, query := range plan.Queries() {
+		searched[strings.ToLower(query)] = true
+	}
+	for i := range next.SubQuestions {
+		var fresh []string
+		for _, query := range next.SubQuestions[i].Queries {
+			if !searched[strings.ToLower(strings.TrimSpace(query))] {
+				fresh = append(fresh, query)
+			}
+		}
+		next.SubQuestions[i].Queries = fresh
+	}
+	p.trim(&next, session.SearchDepth.Profile().SubQuestions)
+	return &next, nil
+}
+
+// ask sends a JSON mode request and decodes the reply into out
+func (p LLMPlanner) ask(ctx context.Context, out interface{}, instructions, prompt string) error {
+	resp, err := p.Provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: instructions},
+			{Role: llm.RoleUser, Content: prompt},
+		},
+		Temperature: 0.2,
+		JSONMode:    true,
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(jsonObject(resp.Content)), out); err != nil {
+		return fmt.Errorf("language model returned an invalid plan: %w", err)
+	}
+	return nil
+}
+
+// trim drops empty sub-questions and limits the plan to maxQuestions sub-questions
+// of at most QueriesPerQuestion queries each
+func (p LLMPlanner) trim(plan *ResearchPlan, maxQuestions int) {
+	var kept []SubQuestion
+	for _, sub := range plan.SubQuestions {
+		sub.Question = strings.TrimSpace(sub.Question)
+		var queries []string
+		for _, query := range sub.Queries {
+			if query = strings.TrimSpace(query); query != "" && len(queries) < p.queriesPerQuestion() {
+				queries = append(queries, query)
+			}
+		}
+		sub.Queries = queries
+		if sub.Question == "" || len(sub.Queries) == 0 {
+			continue
+		}
+		if maxQuestions > 0 && len(kept) == maxQuestions {
+			break
+		}
+		kept = append(kept, sub)
+	}
+	plan.SubQuestions = kept
+}
+
+func (p LLMPlanner) queriesPerQuestion() int {
+	if p.QueriesPerQuestion <= 0 {
+		return 2
+	}
+	return p.QueriesPerQuestion
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/crawler"
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/search"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+)
+
+// gapLLM is a fakeLLM whose gap checks report a gap, with a new search, for
+// the first gapRounds checks and no gaps after that
+type gapLLM struct {
+	fakeLLM
+	gapRounds int
+	checks    int
+}
+
+func (g *gapLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	if !req.JSONMode || !strings.Contains(req.Messages[0].Content, "review web research") {
+		return g.fakeLLM.Chat(ctx, req)
+	}
+	g.checks++
+	if g.checks > g.gapRounds {
+		return &llm.ChatResponse{Content: `{"gaps": [], "sub_questions": [], "done": true}`}, nil
+	}
+	return &llm.ChatResponse{Content: fmt.Sprintf(
+		`{"gaps": ["who designed Go"], "sub_questions": [{"question": "Who designed Go?", "queries": ["go designers %d"]}], "done": false}`,
+		g.checks)}, nil
+}
+
+// subQuestionsPlan returns a plan of n sub-questions with one query each
+func subQuestionsPlan(n int) string {
+	subs := make([]string, n)
+	for i := range subs {
+		subs[i] = fmt.Sprintf(`{"question": "Question %d?", "queries": ["query %d"]}`, i+1, i+1)
+	}
+	return `{"sub_questions": [` + strings.Join(subs, ", ") + `]}`
+}
+
+func TestLLMPlannerPlan(t *testing.T) {
+	tests := []struct {
+		depth models.SearchDepth
+		want  int
+	}{
+		{depth: models.SearchDepthShallow, want: 2},
+		{depth: models.SearchDepthMedium, want: 3},
+		{depth: models.SearchDepthDeep, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.depth), func(t *testing.T) {
+			planner := LLMPlanner{Provider: &fakeLLM{plan: subQuestionsPlan(8)}}
+			plan, err := planner.Plan(context.Background(), &models.ResearchSession{SearchDepth: tt.depth}, "When was Go released?")
+			if err != nil {
+				t.Fatalf("Plan: %v", err)
+			}
+			if len(plan.SubQuestions) != tt.want {
+				t.Errorf("got %d sub-questions, want %d", len(plan.SubQuestions), tt.want)
+			}
+		})
+	}
+
+	// An empty plan falls back to searching the question itself
+	planner := LLMPlanner{Provider: &fakeLLM{plan: `{"sub_questions": []}`}}
+	plan, err := planner.Plan(context.Background(), &models.ResearchSession{}, " When was Go released? ")
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if queries := plan.Queries(); len(queries) != 1 || queries[0] != "When was Go released?" {
+		t.Errorf("fallback queries = %q", queries)
+	}
+}
+
+func TestLLMPlannerFollowUpSkipsSearchedQueries(t *testing.T) {
+	planner := LLMPlanner{Provider: &fakeLLM{
+		plan: `{"gaps": ["mascot"], "sub_questions": [{"question": "Who drew the gopher?", "queries": ["Go Release Year", "go gopher artist"]}]}`,
+	}}
+	plan := &ResearchPlan{SubQuestions: []SubQuestion{{Question: "When was Go released?", Queries: []string{"go release year"}}}}
+
+	next, err := planner.FollowUp(context.Background(), &models.ResearchSession{}, "Tell me about Go", plan, nil)
+	if err != nil {
+		t.Fatalf("FollowUp: %v", err)
+	}
+	if queries := next.Queries(); len(queries) != 1 || queries[0] != "go gopher artist" {
+		t.Errorf("follow-up queries = %q, want only the new one", queries)
+	}
+}
+
+func TestFollowUpRounds(t *testing.T) {
+	db := testdb.Open(t)
+	server := newPageServer(t)
+	searcher := search.NewFixtureFromResults(map[string][]search.Result{
+		"*": {{Title: "Go", URL: server.URL + "/go"}, {Title: "Gopher", URL: server.URL + "/gopher"}},
+	})
+
+	tests := []struct {
+		name       string
+		depth      models.SearchDepth
+		gapRounds  int
+		wantChecks int
+	}{
+		{name: "shallow research does not follow up", depth: models.SearchDepthShallow, gapRounds: 10, wantChecks: 0},
+		{name: "medium research follows up once", depth: models.SearchDepthMedium, gapRounds: 10, wantChecks: 1},
+		{name: "deep research follows up three times", depth: models.SearchDepthDeep, gapRounds: 10, wantChecks: 3},
+		{name: "no gaps stops at the first check", depth: models.SearchDepthDeep, gapRounds: 0, wantChecks: 1},
+		{name: "stops once the gaps are filled", depth: models.SearchDepthDeep, gapRounds: 1, wantChecks: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := &gapLLM{
+				fakeLLM: fakeLLM{
+					plan:   `{"sub_questions": [{"question": "When was Go released?", "queries": ["go release year"]}]}`,
+					answer: "Go was released in 2009 [1].",
+				},
+				gapRounds: tt.gapRounds,
+			}
+			engine, err := NewResearchEngine(db, ResearchStages{
+				Planner:     LLMPlanner{Provider: model},
+				Searcher:    ProviderSearcher{Provider: searcher},
+				Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+				Analyzer:    LexicalAnalyzer{},
+				Synthesizer: LLMSynthesizer{Provider: model},
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			session := createTestSession(t, db, "When was Go released?")
+			session.SearchDepth = tt.depth
+			if err := db.Model(session).Update("search_depth", tt.depth).Error; err != nil {
+				t.Fatal(err)
+			}
+			message, err := engine.Run(context.Background(), session, "")
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			if model.checks != tt.wantChecks {
+				t.Errorf("ran %d gap checks, want %d", model.checks, tt.wantChecks)
+			}
+			var rounds int64
+			db.Model(&models.Thought{}).Where("message_id = ? AND title LIKE ?", message.ID, "Checking for gaps%").Count(&rounds)
+			if int(rounds) != tt.wantChecks {
+				t.Errorf("recorded %d gap check thoughts, want %d", rounds, tt.wantChecks)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/ranking"
+	"github.com/lolzone13/DeepResearch/internal/retrieval"
+)
+
+// Relevance score components, as recorded in Document.Scores
+const (
+	scoreBM25      = "bm25"
+	scoreEmbedding = "embedding"
+	scoreLLM       = "llm"
+	scoreDomain    = "domain"
+)
+
+// RankingWeights sets how much each component contributes to a document's relevance
+type RankingWeights struct {
+	BM25      float64
+	Embedding float64
+	LLM       float64
+	Domain    float64
+}
+
+// DefaultRankingWeights returns weights suited to general research
+func DefaultRankingWeights() RankingWeights {
+	return RankingWeights{BM25: 0.35, Embedding: 0.35, LLM: 0.2, Domain: 0.1}
+}
+
+// DefaultRankingThreshold is used when the configuration sets none. With the
+// default weights a trusted domain alone contributes at most 0.1, so documents
+// only pass when their text matches the question too.
+const DefaultRankingThreshold = 0.2
+
+// RankingAnalyzer combines a BM25 lexical score, embedding similarity to the
+// session query, an optional language model judgement and a domain prior into
+// a single 0-1 relevance. Components without a weight or a backing provider are
+// skipped and the remaining weights are rescaled.
+type RankingAnalyzer struct {
+	Weights   RankingWeights
+	Threshold float64 // Documents below it are dropped; 0 keeps every document with a score
+
+	BM25    ranking.BM25
+	Domains ranking.DomainPriors
+
+	Embedder      retrieval.Embedder // Optional; enables the embedding component
+	Judge         llm.Provider       // Optional; enables the LLM component
+	MaxInputChars int                // Per-document text budget for embeddings and the judge
+}
+
+// documentScores are the components of one document's relevance
+type documentScores struct {
+	Components  map[string]float64 `json:"components"`
+	Weights     map[string]float64 `json:"weights"`
+	JudgeReason string             `json:"judge_reason,omitempty"`
+	Errors      map[string]string  `json:"errors,omitempty"`
+}
+
+// Analyze scores every document, records its components in Scores and sets
+// DropReason on those below the threshold. Documents are returned best first.
+func (a RankingAnalyzer) Analyze(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) ([]models.Document, error) {
+	if len(documents) == 0 {
+		return documents, nil
+	}
+	limit := a.MaxInputChars
+	if limit <= 0 {
+		limit = 4000
+	}
+
+	scores := make([]documentScores, len(documents))
+	texts := make([]string, len(documents))
+	for i, doc := range documents {
+		scores[i] = documentScores{
+			Components: make(map[string]float64),
+			Weights:    make(map[string]float64),
+			Errors:     make(map[string]string),
+		}
+		texts[i] = doc.Title + "\n" + doc.Content
+	}
+
+	if a.Weights.BM25 > 0 {
+		for i, score := range a.BM25.Scores(query, texts) {
+			scores[i].set(scoreBM25, score, a.Weights.BM25)
+		}
+	}
+
+	// Similarity is measured against the session's research topic, which
+	// follow-up questions in the same session refine rather than replace
+	topic := session.Query
+	if topic == "" {
+		topic = query
+	}
+	if a.Weights.Embedding > 0 && a.Embedder != nil {
+		inputs := make([]string, len(documents))
+		for i, doc := range documents {
+			inputs[i] = truncateText(doc.Title+"\n"+documentSummaryText(doc), limit)
+		}
+		similarities, err := a.similarities(ctx, topic, inputs)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			for i := range scores {
+				scores[i].Errors[scoreEmbedding] = err.Error()
+			}
+		} else {
+			for i, similarity := range similarities {
+				scores[i].set(scoreEmbedding, similarity, a.Weights.Embedding)
+			}
+		}
+	}
+
+	if a.Weights.LLM > 0 && a.Judge != nil {
+		for i, doc := range documents {
+			score, reason, err := a.judge(ctx, query, doc, limit)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				scores[i].Errors[scoreLLM] = err.Error()
+				continue
+			}
+			scores[i].set(scoreLLM, score, a.Weights.LLM)
+			scores[i].JudgeReason = reason
+		}
+	}
+
+	if a.Weights.Domain > 0 {
+		for i, doc := range documents {
+			scores[i].set(scoreDomain, a.Domains.Score(documentDomain(doc)), a.Weights.Domain)
+		}
+	}
+
+	ranked := make([]models.Document, len(documents))
+	for i, doc := range documents {
+		doc.Relevance = scores[i].combined()
+		doc.DropReason = ""
+		switch {
+		case strings.TrimSpace(doc.Content) == "":
+			doc.DropReason = "document has no text"
+		case len(scores[i].Weights) == 0:
+			doc.DropReason = "no relevance score could be computed"
+		case doc.Relevance < a.Threshold:
+			doc.DropReason = fmt.Sprintf("relevance %.2f is below the threshold of %.2f (%s)",
+				doc.Relevance, a.Threshold, scores[i].describe())
+		}
+
+		if len(scores[i].Errors) == 0 {
+			scores[i].Errors = nil
+		}
+		data, err := json.Marshal(scores[i])
+		if err != nil {
+			return nil, err
+		}
+		doc.Scores = string(data)
+		ranked[i] = doc
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Relevance > ranked[j].Relevance
+	})
+	return ranked, nil
+}
+
+// similarities returns the cosine similarity of every input to the topic, clamped to 0-1
+func (a RankingAnalyzer) similarities(ctx context.Context, topic string, inputs []string) ([]float64, error) {
+	embeddings, err := a.Embedder.Embed(ctx, append([]string{topic}, inputs...))
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) != len(inputs)+1 {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(inputs)+1, len(embeddings))
+	}
+
+	similarities := make([]float64, len(inputs))
+	for i, embedding := range embeddings[1:] {
+		similarities[i] = math.Max(0, retrieval.Cosine(embeddings[0], embedding))
+	}
+	return similarities, nil
+}
+
+// judge asks the language model how well a document answers the query
+func (a RankingAnalyzer) judge(ctx context.Context, query string, doc models.Document, limit int) (float64, string, error) {
+	resp, err := a.Judge.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{
+				Role: llm.RoleSystem,
+				Content: "You judge whether a document is useful for answering a research question. " +
+					`Reply with a JSON object: {"score": number from 0 (irrelevant) to 10 (answers the question directly), ` +
+					`"reason": one short sentence}.`,
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: fmt.Sprintf("Question: %s\n\nDocument: %s\n%s", query, doc.Title, truncateText(doc.Content, limit)),
+			},
+		},
+		Temperature: 0,
+		JSONMode:    true,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	var parsed struct {
+		Score  *float64 `json:"score"`
+		Reason string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(resp.Content)), &parsed); err != nil || parsed.Score == nil {
+		return 0, "", fmt.Errorf("language model returned an invalid judgement: %q", truncateText(resp.Content, 200))
+	}
+	return math.Max(0, math.Min(1, *parsed.Score/10)), strings.TrimSpace(parsed.Reason), nil
+}
+
+// set records a component and its weight
+func (s *documentScores) set(name string, score, weight float64) {
+	s.Components[name] = math.Round(score*1000) / 1000
+	s.Weights[name] = weight
+}
+
+// combined returns the weighted mean of the recorded components
+func (s *documentScores) combined() float64 {
+	total, weights := 0.0, 0.0
+	for name, weight := range s.Weights {
+		total += s.Components[name] * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(total/weights*1000) / 1000
+}
+
+// describe lists the components for a drop reason
+func (s *documentScores) describe() string {
+	var parts []string
+	for _, name := range []string{scoreBM25, scoreEmbedding, scoreLLM, scoreDomain} {
+		if _, ok := s.Weights[name]; ok {
+			parts = append(parts, fmt.Sprintf("%s %.2f", name, s.Components[name]))
+		}
+	}
+	description := strings.Join(parts, ", ")
+	if s.JudgeReason != "" {
+		description += "; judge: " + s.JudgeReason
+	}
+	return description
+}
+
+// documentDomain returns the domain of the document's source
+func documentDomain(doc models.Document) string {
+	if doc.Source.Domain != "" {
+		return doc.Source.Domain
+	}
+	if parsed, err := url.Parse(doc.Source.URL); err == nil {
+		return parsed.Hostname()
+	}
+	return ""
+}
+
+// documentSummaryText prefers a paper's abstract over the start of its body
+func documentSummaryText(doc models.Document) string {
+	if doc.Abstract != "" {
+		return doc.Abstract
+	}
+	return doc.Content
+}
+
+// truncateText shortens text to at most limit bytes without splitting a character
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	for limit > 0 && !utf8.RuneStart(text[limit]) {
+		limit--
+	}
+	return text[:limit]
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/ranking"
+)
+
+// fakeEmbedder embeds the inputs it knows and puts every other input at a right angle
+type fakeEmbedder map[string][]float32
+
+func (f fakeEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		embeddings[i] = []float32{0, 1}
+		if embedding, ok := f[input]; ok {
+			embeddings[i] = embedding
+		}
+	}
+	return embeddings, nil
+}
+
+func TestRankingAnalyzer(t *testing.T) {
+	const query = "When was Go released?"
+	relevant := models.Document{
+		Title:   "Go release",
+		Content: "Go was released in 2009 by Google.",
+		Source:  models.Source{URL: "https://go.dev/doc"},
+	}
+	unrelated := models.Document{
+		Title:   "Bread",
+		Content: "A recipe for sourdough bread.",
+		Source:  models.Source{Domain: "cooking.example.edu"},
+	}
+	embedder := fakeEmbedder{
+		query:                                    {1, 0},
+		relevant.Title + "\n" + relevant.Content: {1, 0},
+	}
+	judge := &fakeLLM{plan: `{"score": 5, "reason": "mentions the topic"}`}
+	domains := ranking.DomainPriors{Scores: map[string]float64{".edu": 0.9}, Default: 0.5}
+
+	tests := []struct {
+		name      string
+		analyzer  RankingAnalyzer
+		documents []models.Document
+		want      map[string]float64 // Relevance by title
+		order     []string           // Titles best first, when the order is decided
+	}{
+		{
+			name:     "lexical",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{BM25: 1}},
+			want:     map[string]float64{"Go release": 1, "Bread": 0},
+			order:    []string{"Go release", "Bread"},
+		},
+		{
+			name:     "embedding",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{Embedding: 1}, Embedder: embedder},
+			want:     map[string]float64{"Go release": 1, "Bread": 0},
+			order:    []string{"Go release", "Bread"},
+		},
+		{
+			name:     "judge",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{LLM: 1}, Judge: judge},
+			want:     map[string]float64{"Go release": 0.5, "Bread": 0.5},
+		},
+		{
+			name:     "domain",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{Domain: 1}, Domains: domains},
+			want:     map[string]float64{"Go release": 0.5, "Bread": 0.9},
+			order:    []string{"Bread", "Go release"},
+		},
+		{
+			name: "every component",
+			analyzer: RankingAnalyzer{
+				Weights:  RankingWeights{BM25: 1, Embedding: 1, LLM: 1, Domain: 1},
+				Domains:  domains,
+				Embedder: embedder,
+				Judge:    judge,
+			},
+			want:  map[string]float64{"Go release": 0.75, "Bread": 0.35},
+			order: []string{"Go release", "Bread"},
+		},
+		{
+			name:     "weights of missing providers are rescaled",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{BM25: 0.5, Embedding: 0.5, LLM: 0.5}},
+			want:     map[string]float64{"Go release": 1, "Bread": 0},
+		},
+		{
+			name:     "domain boosts an equally relevant document",
+			analyzer: RankingAnalyzer{Weights: RankingWeights{BM25: 0.9, Domain: 0.1}, Domains: domains},
+			documents: []models.Document{
+				relevant,
+				{Title: "Go history", Content: relevant.Content, Source: models.Source{Domain: "cs.example.edu"}},
+			},
+			want:  map[string]float64{"Go history": 0.99, "Go release": 0.95},
+			order: []string{"Go history", "Go release"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documents := tt.documents
+			if documents == nil {
+				documents = []models.Document{unrelated, relevant}
+			}
+			ranked, err := tt.analyzer.Analyze(context.Background(), &models.ResearchSession{Query: query}, query, documents)
+			if err != nil {
+				t.Fatalf("Analyze: %v", err)
+			}
+
+			for i, doc := range ranked {
+				if want, ok := tt.want[doc.Title]; !ok || doc.Relevance != want {
+					t.Errorf("%s: relevance = %v, want %v (scores %s)", doc.Title, doc.Relevance, want, doc.Scores)
+				}
+				if tt.order != nil && doc.Title != tt.order[i] {
+					t.Errorf("rank %d = %s, want %s", i+1, doc.Title, tt.order[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRankingAnalyzerThreshold(t *testing.T) {
+	analyzer := RankingAnalyzer{Weights: RankingWeights{BM25: 1}, Threshold: DefaultRankingThreshold}
+	ranked, err := analyzer.Analyze(context.Background(), &models.ResearchSession{}, "go release", []models.Document{
+		{Title: "Go", Content: "Go release notes"},
+		{Title: "Bread", Content: "A recipe for sourdough bread."},
+		{Title: "Empty", Content: " "},
+	})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	want := map[string]string{"Go": "", "Bread": "below the threshold", "Empty": "no text"}
+	for _, doc := range ranked {
+		if want[doc.Title] == "" && doc.DropReason != "" {
+			t.Errorf("%s dropped: %s", doc.Title, doc.DropReason)
+		}
+		if !strings.Contains(doc.DropReason, want[doc.Title]) {
+			t.Errorf("%s drop reason = %q, want one mentioning %q", doc.Title, doc.DropReason, want[doc.Title])
+		}
+	}
+}
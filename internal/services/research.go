@@ -0,0 +1,1039 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/retrieval"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubQuestion is one part of a research question and the searches that answer it
+type SubQuestion struct {
+	Question string   `json:"question"`
+	Queries  []string `json:"queries"`
+}
+
+// ResearchPlan is the outcome of a planning round
+type ResearchPlan struct {
+	SubQuestions []SubQuestion `json:"sub_questions"`
+	Gaps         []string      `json:"gaps,omitempty"` // What the findings so far leave open; set by follow-up rounds
+	Done         bool          `json:"done"`           // Set by follow-up rounds when no gap is worth another search
+}
+
+// Queries returns the search queries of every sub-question, without duplicates
+func (p *ResearchPlan) Queries() []string {
+	var queries []string
+	seen := make(map[string]bool)
+	for _, sub := range p.SubQuestions {
+		for _, query := range sub.Queries {
+			key := strings.ToLower(strings.TrimSpace(query))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			queries = append(queries, strings.TrimSpace(query))
+		}
+	}
+	return queries
+}
+
+// ResearchPlanner breaks a research question into sub-questions and their search queries
+type ResearchPlanner interface {
+	Plan(ctx context.Context, session *models.ResearchSession, query string) (*ResearchPlan, error)
+}
+
+// ResearchExpander is implemented by planners that can widen a search with
+// related queries, based on the sources found so far
+type ResearchExpander interface {
+	Expand(ctx context.Context, session *models.ResearchSession, query string, queries []string, sources []models.Source) ([]string, error)
+}
+
+// ResearchFollowUpPlanner is implemented by planners that can check the documents
+// read so far for gaps in the plan and propose sub-questions that close them
+type ResearchFollowUpPlanner interface {
+	FollowUp(ctx context.Context, session *models.ResearchSession, query string, plan *ResearchPlan, documents []models.Document) (*ResearchPlan, error)
+}
+
+// ResearchSearcher finds candidate sources for a list of search queries
+type ResearchSearcher interface {
+	Search(ctx context.Context, session *models.ResearchSession, queries []string) ([]models.Source, error)
+}
+
+// ResearchFetcher downloads sources and turns them into documents
+type ResearchFetcher interface {
+	Fetch(ctx context.Context, session *models.ResearchSession, sources []models.Source) ([]models.Document, error)
+}
+
+// ResearchAnalyzer scores documents against the query. It returns every document
+// best first, with Relevance set and DropReason explaining each one that should
+// be left out of synthesis.
+type ResearchAnalyzer interface {
+	Analyze(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) ([]models.Document, error)
+}
+
+// ResearchSynthesizer writes the final answer from the analyzed documents
+type ResearchSynthesizer interface {
+	Synthesize(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) (string, error)
+}
+
+// ResearchStreamingSynthesizer is implemented by synthesizers that can emit the answer while writing it
+type ResearchStreamingSynthesizer interface {
+	SynthesizeStream(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document, onDelta func(delta string)) (string, error)
+}
+
+// ResearchSummarizer writes a summary of one type from the analyzed documents
+type ResearchSummarizer interface {
+	Summarize(ctx context.Context, session *models.ResearchSession, query string, summaryType models.SummaryType, documents []models.Document) (*models.Summary, error)
+}
+
+// ResearchStages groups the pluggable stages of a research run
+type ResearchStages struct {
+	Planner     ResearchPlanner
+	Searcher    ResearchSearcher
+	Fetcher     ResearchFetcher
+	Analyzer    ResearchAnalyzer
+	Synthesizer ResearchSynthesizer
+
+	// Summarizer is optional; when set, every run ends by writing SummaryTypes
+	Summarizer   ResearchSummarizer
+	SummaryTypes []models.SummaryType
+
+	// Validator is optional; when set, the claims of the answer are checked
+	// against the passages it was written from and the answer is revised to match
+	Validator ResearchValidator
+
+	// Retriever is optional; when set, documents are indexed as passages and the
+	// answer is synthesized from the Passages closest to the query
+	Retriever *retrieval.Retriever
+	Passages  int
+}
+
+// ResearchEngine runs the plan → search → fetch → analyze → synthesize → validate pipeline
+type ResearchEngine struct {
+	db     *gorm.DB
+	stages ResearchStages
+	events *EventBroker
+}
+
+// NewResearchEngine creates a new research engine.
+// Runs publish their events to the broker when one is given.
+func NewResearchEngine(db *gorm.DB, stages ResearchStages, events *EventBroker) (*ResearchEngine, error) {
+	if stages.Planner == nil || stages.Searcher == nil || stages.Fetcher == nil ||
+		stages.Analyzer == nil || stages.Synthesizer == nil {
+		return nil, errors.New("all research stages must be configured")
+	}
+	return &ResearchEngine{db: db, stages: stages, events: events}, nil
+}
+
+// researchRun holds the state of a single research run
+type researchRun struct {
+	engine    *ResearchEngine
+	ctx       context.Context
+	session   *models.ResearchSession
+	message   *models.Message
+	passages  []retrieval.Match // Passages the answer was synthesized from, in citation order
+	grounding []models.Document // Documents the answer was synthesized from; [n] cites grounding[n-1]
+	willRetry func(err error) bool
+}
+
+// Run executes a research run for the given pending session and query.
+// It stores an assistant message holding the answer, with one Thought per stage,
+// that stays hidden until the answer is final, and publishes its progress as
+// research events. The session moves to running for the duration of the run and
+// to completed, failed or cancelled after it.
+func (e *ResearchEngine) Run(ctx context.Context, session *models.ResearchSession, query string) (*models.Message, error) {
+	return e.RunReply(ctx, session, query, nil, nil)
+}
+
+// RunReply is like Run but writes the answer to reply, a hidden assistant message
+// created beforehand with createReply, so that every attempt of a job fills the
+// same message. Thoughts and citations of earlier attempts are discarded.
+// A nil reply creates a new message. willRetry reports whether a failed attempt
+// will run again, in which case it publishes a retrying event instead of failed;
+// a nil willRetry treats every failure as final.
+func (e *ResearchEngine) RunReply(ctx context.Context, session *models.ResearchSession, query string, reply *models.Message, willRetry func(err error) bool) (*models.Message, error) {
+	if query == "" {
+		query = session.Query
+	}
+	if query == "" {
+		return nil, errors.New("research query is required")
+	}
+
+	if err := transitionSession(e.db.WithContext(ctx), session.ID, models.SessionStatusRunning); err != nil {
+		return nil, err
+	}
+	session.Status = models.SessionStatusRunning
+
+	message, err := e.run(ctx, session, query, reply, willRetry)
+	if errors.Is(context.Cause(ctx), errLeaseLost) {
+		// The job was recovered and claimed again, and the new attempt owns the session
+		return message, err
+	}
+	status := models.SessionStatusCompleted
+	switch {
+	case err != nil && ctx.Err() != nil:
+		status = models.SessionStatusCancelled
+	case err != nil:
+		status = models.SessionStatusFailed
+	}
+	// Use a fresh context so the outcome is recorded even when the run was cancelled
+	if statusErr := transitionSession(e.db, session.ID, status); statusErr != nil {
+		return message, errors.Join(err, statusErr)
+	}
+	session.Status = status
+	return message, err
+}
+
+// run executes the stages of a running session
+func (e *ResearchEngine) run(ctx context.Context, session *models.ResearchSession, query string, reply *models.Message, willRetry func(err error) bool) (*models.Message, error) {
+	if reply == nil {
+		created, err := createReply(e.db.WithContext(ctx), session.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		reply = created
+	} else if err := resetReply(e.db.WithContext(ctx), reply); err != nil {
+		return nil, err
+	}
+	message := *reply
+
+	run := &researchRun{
+		engine:    e,
+		ctx:       ctx,
+		session:   session,
+		message:   &message,
+		willRetry: willRetry,
+	}
+
+	answer, used, err := run.execute(query)
+	if err != nil {
+		run.fail(err)
+		return &message, err
+	}
+
+	completed := models.Thought{
+		MessageID: message.ID,
+		Type:      models.ThoughtTypeCompleted,
+		Title:     "Research complete",
+		Content:   fmt.Sprintf("Answer synthesized from %d sources", len(used)),
+		Metadata:  "{}",
+	}
+	completed.MarkCompleted()
+	citations := resolveCitations(message.ID, answer, run.grounding, run.passages)
+	publishedAt := time.Now()
+
+	// The answer, its sources and citations are stored together, and the reply
+	// only becomes visible once all of them are, so a failure part way through
+	// never shows a reply with missing citations
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(used) > 0 {
+			if err := tx.Model(&message).Association("Sources").Append(used); err != nil {
+				return err
+			}
+		}
+		if len(citations) > 0 {
+			if err := tx.CreateInBatches(&citations, 100).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&completed).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ResearchSession{}).
+			Where("id = ?", session.ID).
+			UpdateColumn("message_count", gorm.Expr("message_count + ?", 1)).Error; err != nil {
+			return err
+		}
+
+		// Publishing the reply moves it to the end of the conversation, after every
+		// message a client may already hold a cursor for
+		return tx.Model(&message).Updates(map[string]interface{}{
+			"content":    answer,
+			"is_visible": true,
+			"created_at": publishedAt,
+		}).Error
+	})
+	if err != nil {
+		return &message, err
+	}
+	message.Content = answer
+	message.IsVisible = true
+	message.CreatedAt = publishedAt
+	message.Citations = citations
+
+	run.emit(models.ResearchEvent{
+		Type:        models.ResearchEventCompleted,
+		ThoughtID:   completed.ID.String(),
+		ThoughtType: string(models.ThoughtTypeCompleted),
+		Title:       completed.Title,
+		Content:     completed.Content,
+		Progress:    100,
+	})
+	return &message, nil
+}
+
+// createReply stores the hidden assistant message a research run writes its answer to.
+// It stays hidden until the answer is final, so that clients paging through
+// messages never see it empty or half written.
+func createReply(tx *gorm.DB, sessionID uuid.UUID, replyTo *uuid.UUID) (*models.Message, error) {
+	reply := models.Message{
+		SessionID: sessionID,
+		Type:      models.MessageTypeAssistant,
+		Content:   "",
+		IsVisible: false,
+		ReplyToID: replyTo,
+	}
+	// Select every column, or gorm would replace the false IsVisible with its default
+	if err := tx.Select("*").Create(&reply).Error; err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// resetReply hides a reply again and discards what earlier attempts wrote to it
+func resetReply(tx *gorm.DB, reply *models.Message) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("message_id = ?", reply.ID).Delete(&models.Thought{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("message_id = ?", reply.ID).Delete(&models.Citation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(reply).Association("Sources").Clear(); err != nil {
+			return err
+		}
+		reply.Content = ""
+		reply.IsVisible = false
+		return tx.Model(reply).Updates(map[string]interface{}{
+			"content":    "",
+			"is_visible": false,
+		}).Error
+	})
+}
+
+// execute runs every stage in order and returns the answer and the sources it used
+func (r *researchRun) execute(query string) (string, []models.Source, error) {
+	stages := r.engine.stages
+
+	var plan *ResearchPlan
+	var queries []string
+	err := r.stage(models.ThoughtTypeAnalyzing, "Planning research", 10, func(t *models.Thought) error {
+		var err error
+		plan, err = stages.Planner.Plan(r.ctx, r.session, query)
+		if err != nil {
+			return err
+		}
+		queries = plan.Queries()
+		if len(queries) == 0 {
+			return errors.New("planner produced no search queries")
+		}
+		t.Content = fmt.Sprintf("Planned %d sub-questions and %d search queries", len(plan.SubQuestions), len(queries))
+		return setThoughtMetadata(t, map[string]interface{}{"sub_questions": plan.SubQuestions, "queries": queries})
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The session's search depth sets the number of search rounds and how many
+	// sources each round reads; max_sources caps the sources of the whole run
+	profile := r.session.SearchDepth.Profile()
+	budget := r.session.MaxSources
+	if budget <= 0 {
+		budget = models.DefaultMaxSources
+	}
+
+	var sources []models.Source
+	err = r.stage(models.ThoughtTypeSearching, "Finding relevant sources", 30, func(t *models.Thought) error {
+		var err error
+		sources, err = r.searchRound(t, queries, nil, budget)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			return errors.New("no sources found for query")
+		}
+		t.Content = fmt.Sprintf("Found %d sources", len(sources))
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if expander, ok := stages.Planner.(ResearchExpander); ok {
+		for round := 1; round <= profile.ExpansionRounds && len(sources) < budget; round++ {
+			err = r.stage(models.ThoughtTypeSearching, fmt.Sprintf("Expanding search (round %d)", round), 30+5*round, func(t *models.Thought) error {
+				expanded, err := expander.Expand(r.ctx, r.session, query, queries, sources)
+				if err == nil && len(expanded) > 0 {
+					var found []models.Source
+					found, err = r.searchRound(t, expanded, sources, budget)
+					queries = append(queries, expanded...)
+					sources = append(sources, found...)
+					t.Content = fmt.Sprintf("Searched %d related queries and found %d new sources", len(expanded), len(found))
+				}
+				// A round that adds nothing does not fail the run; the first search already found sources
+				if err != nil {
+					if r.ctx.Err() != nil {
+						return r.ctx.Err()
+					}
+					t.Content = "Search expansion failed: " + err.Error()
+				} else if len(expanded) == 0 {
+					t.Content = "No related queries to search"
+				}
+				return nil
+			})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	var documents []models.Document
+	read := make(map[uuid.UUID]bool)
+	err = r.stage(models.ThoughtTypeSearching, "Reading sources", 55, func(t *models.Thought) error {
+		var attempted int
+		var err error
+		documents, attempted, err = r.readRound(sources, read, profile.CrawlFanOut)
+		if err != nil {
+			return err
+		}
+		if len(documents) == 0 {
+			return errors.New("none of the sources could be read")
+		}
+		t.Content = fmt.Sprintf("Read %d of %d sources", len(documents), attempted)
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Follow-up rounds check the findings for gaps and search for what is missing,
+	// until the planner finds none or the depth or source budget is exhausted
+	if followUp, ok := stages.Planner.(ResearchFollowUpPlanner); ok {
+		for round := 1; round <= profile.FollowUpIterations; round++ {
+			var next *ResearchPlan
+			err = r.stage(models.ThoughtTypeAnalyzing, fmt.Sprintf("Checking for gaps (round %d)", round), 56+4*round, func(t *models.Thought) error {
+				var err error
+				next, err = followUp.FollowUp(r.ctx, r.session, query, plan, documents)
+				if err != nil {
+					if r.ctx.Err() != nil {
+						return r.ctx.Err()
+					}
+					// The documents already read are enough to continue
+					t.Content = "Gap check failed: " + err.Error()
+					next = nil
+					return nil
+				}
+				if next.Done || len(next.Queries()) == 0 {
+					t.Content = "No gaps worth another search"
+				} else {
+					t.Content = fmt.Sprintf("Found %d gaps and planned %d follow-up sub-questions", len(next.Gaps), len(next.SubQuestions))
+				}
+				return setThoughtMetadata(t, map[string]interface{}{"gaps": next.Gaps, "sub_questions": next.SubQuestions})
+			})
+			if err != nil {
+				return "", nil, err
+			}
+			if next == nil || next.Done || len(next.Queries()) == 0 {
+				break
+			}
+			plan.SubQuestions = append(plan.SubQuestions, next.SubQuestions...)
+
+			err = r.stage(models.ThoughtTypeSearching, fmt.Sprintf("Following up (round %d)", round), 58+4*round, func(t *models.Thought) error {
+				var found []models.Source
+				var err error
+				searched := 0
+				if len(sources) < budget {
+					searched = len(next.Queries())
+					found, err = r.searchRound(t, next.Queries(), sources, budget)
+					if err != nil {
+						if r.ctx.Err() != nil {
+							return r.ctx.Err()
+						}
+						t.Content = "Follow-up search failed: " + err.Error()
+						return nil
+					}
+					queries = append(queries, next.Queries()...)
+					sources = append(sources, found...)
+				}
+
+				fetched, attempted, err := r.readRound(sources, read, profile.CrawlFanOut)
+				if err != nil {
+					return err
+				}
+				documents = append(documents, fetched...)
+				t.Content = fmt.Sprintf("Searched %d follow-up queries, found %d new sources and read %d of %d",
+					searched, len(found), len(fetched), attempted)
+				return nil
+			})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	err = r.stage(models.ThoughtTypeAnalyzing, "Analyzing documents", 75, func(t *models.Thought) error {
+		// Analyzers may weigh a document by its source, such as the source's domain
+		bySource := make(map[string]models.Source, len(sources))
+		for _, source := range sources {
+			bySource[source.ID.String()] = source
+		}
+		for i := range documents {
+			documents[i].Source = bySource[documents[i].SourceID.String()]
+		}
+
+		analyzed, err := stages.Analyzer.Analyze(r.ctx, r.session, query, documents)
+		if err != nil {
+			return err
+		}
+
+		var kept []models.Document
+		dropped := []map[string]interface{}{}
+		for _, doc := range analyzed {
+			if doc.Scores == "" {
+				doc.Scores = "{}"
+			}
+			if err := r.engine.db.WithContext(r.ctx).Model(&models.Document{}).
+				Where("id = ?", doc.ID).
+				Updates(map[string]interface{}{
+					"relevance":   doc.Relevance,
+					"scores":      doc.Scores,
+					"drop_reason": doc.DropReason,
+				}).Error; err != nil {
+				return err
+			}
+			if doc.DropReason != "" {
+				dropped = append(dropped, map[string]interface{}{
+					"document_id": doc.ID,
+					"title":       doc.Title,
+					"relevance":   doc.Relevance,
+					"reason":      doc.DropReason,
+				})
+				continue
+			}
+			kept = append(kept, doc)
+		}
+		if len(kept) == 0 {
+			return errors.New("no relevant documents found")
+		}
+		t.Content = fmt.Sprintf("Kept %d of %d documents", len(kept), len(documents))
+		documents = kept
+		return setThoughtMetadata(t, map[string]interface{}{"dropped": dropped})
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	grounding := documents
+	if stages.Retriever != nil {
+		err = r.stage(models.ThoughtTypeAnalyzing, "Indexing passages", 82, func(t *models.Thought) error {
+			passages, err := r.retrievePassages(query, documents)
+			if err != nil {
+				if r.ctx.Err() != nil {
+					return r.ctx.Err()
+				}
+				// Without passages the answer is synthesized from whole documents
+				t.Content = "Passage retrieval unavailable: " + err.Error()
+				return nil
+			}
+			if len(passages) > 0 {
+				r.passages = passages
+				grounding = passageDocuments(passages, documents)
+			}
+			t.Content = fmt.Sprintf("Selected %d passages for the answer", len(passages))
+			return nil
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	r.grounding = grounding
+	var answer string
+	err = r.stage(models.ThoughtTypeSynthesizing, "Synthesizing answer", 90, func(t *models.Thought) error {
+		var err error
+		answer, err = r.synthesize(t, query, grounding)
+		if err != nil {
+			return err
+		}
+		if len(r.passages) > 0 {
+			t.Content = fmt.Sprintf("Synthesized answer from %d passages of %d documents", len(r.passages), len(documents))
+		} else {
+			t.Content = fmt.Sprintf("Synthesized answer from %d documents", len(documents))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if stages.Validator != nil {
+		err = r.stage(models.ThoughtTypeValidating, "Verifying claims", 93, func(t *models.Thought) error {
+			validation, err := stages.Validator.Validate(r.ctx, r.session, query, answer, grounding)
+			if err != nil {
+				if r.ctx.Err() != nil {
+					return r.ctx.Err()
+				}
+				// An unverified answer is still worth returning
+				t.Content = "Claim verification failed: " + err.Error()
+				return nil
+			}
+
+			counts := make(map[models.ClaimVerdict]int)
+			for _, claim := range validation.Claims {
+				counts[claim.Verdict]++
+				r.emit(models.ResearchEvent{
+					Type:        models.ResearchEventClaimVerified,
+					ThoughtID:   t.ID.String(),
+					ThoughtType: string(t.Type),
+					Claim:       claim.Claim,
+					Verdict:     string(claim.Verdict),
+					Content:     claim.Explanation,
+				})
+			}
+
+			revised := validation.Answer != "" && validation.Answer != answer
+			if revised {
+				answer = validation.Answer
+				r.emit(models.ResearchEvent{
+					Type:        models.ResearchEventAnswerRevised,
+					ThoughtID:   t.ID.String(),
+					ThoughtType: string(t.Type),
+					Content:     answer,
+				})
+			}
+
+			t.Content = fmt.Sprintf("Checked %d claims: %d supported, %d contradicted, %d unsupported",
+				len(validation.Claims), counts[models.ClaimSupported], counts[models.ClaimContradicted], counts[models.ClaimUnsupported])
+			claims := validation.Claims
+			if claims == nil {
+				claims = []VerifiedClaim{}
+			}
+			return setThoughtMetadata(t, map[string]interface{}{"claims": claims, "revised": revised})
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	if stages.Summarizer != nil && len(stages.SummaryTypes) > 0 {
+		err = r.stage(models.ThoughtTypeSynthesizing, "Writing summaries", 95, func(t *models.Thought) error {
+			// A summary that cannot be written does not fail the run; the answer is already done
+			var written []models.SummaryType
+			failures := make(map[models.SummaryType]string)
+			for _, summaryType := range stages.SummaryTypes {
+				if _, err := r.engine.Summarize(r.ctx, r.session, query, summaryType, documents); err != nil {
+					if r.ctx.Err() != nil {
+						return r.ctx.Err()
+					}
+					failures[summaryType] = err.Error()
+					continue
+				}
+				written = append(written, summaryType)
+			}
+			t.Content = fmt.Sprintf("Wrote %d of %d summaries", len(written), len(stages.SummaryTypes))
+			return setThoughtMetadata(t, map[string]interface{}{"types": written, "errors": failures})
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	return answer, usedSources(sources, grounding), nil
+}
+
+// searchRound searches for the queries and saves the sources not already known,
+// stopping at budget sources in total. It records the queries and new URLs on the thought.
+func (r *researchRun) searchRound(t *models.Thought, queries []string, known []models.Source, budget int) ([]models.Source, error) {
+	found, err := r.engine.stages.Searcher.Search(r.ctx, r.session, queries)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(known))
+	for _, source := range known {
+		seen[source.URL] = true
+	}
+	var fresh []models.Source
+	for _, source := range found {
+		if len(known)+len(fresh) >= budget {
+			break
+		}
+		if !seen[source.URL] {
+			seen[source.URL] = true
+			fresh = append(fresh, source)
+		}
+	}
+
+	saved, err := r.saveSources(fresh)
+	if err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(saved))
+	for i, source := range saved {
+		urls[i] = source.URL
+	}
+	return saved, setThoughtMetadata(t, map[string]interface{}{"queries": queries, "urls": urls})
+}
+
+// readRound fetches up to fanOut of the sources not read yet and saves their documents.
+// It returns the documents and the number of sources it tried to read.
+func (r *researchRun) readRound(sources []models.Source, read map[uuid.UUID]bool, fanOut int) ([]models.Document, int, error) {
+	var batch []models.Source
+	for _, source := range sources {
+		if fanOut > 0 && len(batch) >= fanOut {
+			break
+		}
+		if !read[source.ID] {
+			read[source.ID] = true
+			batch = append(batch, source)
+		}
+	}
+	if len(batch) == 0 {
+		return nil, 0, nil
+	}
+
+	fetched, err := r.engine.stages.Fetcher.Fetch(r.ctx, r.session, batch)
+	if err != nil {
+		return nil, len(batch), err
+	}
+	documents, err := r.saveDocuments(fetched)
+	return documents, len(batch), err
+}
+
+// retrievePassages indexes the run's documents and returns the passages closest to the query
+func (r *researchRun) retrievePassages(query string, documents []models.Document) ([]retrieval.Match, error) {
+	retriever := r.engine.stages.Retriever
+	if _, err := retriever.Index(r.ctx, documents); err != nil {
+		return nil, err
+	}
+
+	ids := make([]uuid.UUID, len(documents))
+	for i, doc := range documents {
+		ids[i] = doc.ID
+	}
+	k := r.engine.stages.Passages
+	if k <= 0 {
+		k = 12
+	}
+	return retriever.Retrieve(r.ctx, r.session.ID, query, k, ids)
+}
+
+// passageDocuments turns retrieved passages into documents for synthesis,
+// so that citation [n] refers to the n-th passage
+func passageDocuments(passages []retrieval.Match, documents []models.Document) []models.Document {
+	byID := make(map[uuid.UUID]models.Document, len(documents))
+	for _, doc := range documents {
+		byID[doc.ID] = doc
+	}
+
+	grounding := make([]models.Document, 0, len(passages))
+	for _, passage := range passages {
+		doc := byID[passage.Chunk.DocumentID]
+		doc.Content = passage.Chunk.Content
+		doc.Relevance = passage.Score
+		grounding = append(grounding, doc)
+	}
+	return grounding
+}
+
+// synthesize writes the answer, emitting it as token deltas.
+// Synthesizers that cannot stream emit the whole answer as a single delta.
+func (r *researchRun) synthesize(t *models.Thought, query string, documents []models.Document) (string, error) {
+	onDelta := func(delta string) {
+		r.emit(models.ResearchEvent{
+			Type:        models.ResearchEventToken,
+			ThoughtID:   t.ID.String(),
+			ThoughtType: string(t.Type),
+			Delta:       delta,
+		})
+	}
+
+	if streaming, ok := r.engine.stages.Synthesizer.(ResearchStreamingSynthesizer); ok {
+		return streaming.SynthesizeStream(r.ctx, r.session, query, documents, onDelta)
+	}
+
+	answer, err := r.engine.stages.Synthesizer.Synthesize(r.ctx, r.session, query, documents)
+	if err != nil {
+		return "", err
+	}
+	onDelta(answer)
+	return answer, nil
+}
+
+// Summarize writes a summary of the given type from the documents,
+// replacing the session's previous summary of that type
+func (e *ResearchEngine) Summarize(ctx context.Context, session *models.ResearchSession, query string, summaryType models.SummaryType, documents []models.Document) (*models.Summary, error) {
+	if e.stages.Summarizer == nil {
+		return nil, errors.New("summaries are not configured")
+	}
+	if len(documents) == 0 {
+		return nil, errors.New("no documents to summarize")
+	}
+
+	summary, err := e.stages.Summarizer.Summarize(ctx, session, query, summaryType, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]bool)
+	for _, doc := range documents {
+		sources[doc.SourceID.String()] = true
+	}
+	summary.SessionID = session.ID
+	summary.Type = summaryType
+	summary.SourcesUsed = len(sources)
+	summary.GeneratedAt = time.Now()
+	if summary.GeneratedBy == "" {
+		summary.GeneratedBy = "ai"
+	}
+	if summary.KeyPoints == "" {
+		summary.KeyPoints = "[]"
+	}
+
+	// Concurrent regenerations of the same summary race to insert it, so the
+	// unique index decides: the last write replaces the summary in place
+	err = e.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "session_id"}, {Name: "type"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"title", "content", "key_points", "sources_used", "confidence_score",
+				"generated_by", "generated_at", "updated_at",
+			}),
+		}).Create(summary).Error
+		if err != nil {
+			return err
+		}
+		// Reload for the ID and creation time of a summary that was replaced
+		var stored models.Summary
+		if err := tx.Where("session_id = ? AND type = ?", session.ID, summaryType).Take(&stored).Error; err != nil {
+			return err
+		}
+		*summary = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// stage wraps a pipeline step with a persisted Thought and progress reporting
+func (r *researchRun) stage(thoughtType models.ThoughtType, title string, progress int, fn func(t *models.Thought) error) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+
+	thought := models.Thought{
+		MessageID: r.message.ID,
+		Type:      thoughtType,
+		Title:     title,
+		Metadata:  "{}",
+	}
+	if err := r.engine.db.WithContext(r.ctx).Create(&thought).Error; err != nil {
+		return err
+	}
+	r.emit(models.ResearchEvent{
+		Type:        models.ResearchEventThoughtStarted,
+		ThoughtID:   thought.ID.String(),
+		ThoughtType: string(thoughtType),
+		Title:       title,
+		Progress:    progress,
+	})
+
+	if err := fn(&thought); err != nil {
+		thought.MarkFailed(err.Error())
+		// Use a fresh context so the failure is recorded even when the run was cancelled
+		if saveErr := r.engine.db.WithContext(context.Background()).Save(&thought).Error; saveErr != nil {
+			log.Printf("Failed to record failed thought %s of session %s: %v", thought.ID, r.session.ID, saveErr)
+		}
+		return fmt.Errorf("%s: %w", title, err)
+	}
+
+	thought.MarkCompleted()
+	if err := r.engine.db.WithContext(r.ctx).Save(&thought).Error; err != nil {
+		return err
+	}
+	r.emit(models.ResearchEvent{
+		Type:        models.ResearchEventThoughtProgress,
+		ThoughtID:   thought.ID.String(),
+		ThoughtType: string(thoughtType),
+		Title:       title,
+		Content:     thought.Content,
+		Progress:    progress,
+	})
+	return nil
+}
+
+// fail records a failed run on the assistant message and notifies subscribers,
+// unless the job was claimed again and the new attempt reports its outcome
+func (r *researchRun) fail(err error) {
+	if errors.Is(context.Cause(r.ctx), errLeaseLost) {
+		return
+	}
+	failed := models.Thought{
+		MessageID: r.message.ID,
+		Type:      models.ThoughtTypeError,
+		Title:     "Research failed",
+		Metadata:  "{}",
+	}
+	failed.MarkFailed(err.Error())
+	if createErr := r.engine.db.WithContext(context.Background()).Create(&failed).Error; createErr != nil {
+		log.Printf("Failed to record failure of session %s: %v", r.session.ID, createErr)
+	}
+
+	// Subscribers only hear that the run failed once it is not tried again
+	eventType := models.ResearchEventFailed
+	if r.willRetry != nil && r.willRetry(err) {
+		eventType = models.ResearchEventRetrying
+	}
+	r.emit(models.ResearchEvent{
+		Type:        eventType,
+		ThoughtID:   failed.ID.String(),
+		ThoughtType: string(models.ThoughtTypeError),
+		Title:       failed.Title,
+		Error:       err.Error(),
+		Progress:    100,
+	})
+}
+
+// emit publishes a typed event for the run's session
+func (r *researchRun) emit(event models.ResearchEvent) {
+	if r.engine.events == nil {
+		return
+	}
+	event.MessageID = r.message.ID.String()
+	r.engine.events.Publish(r.session.ID, event)
+}
+
+// saveSources persists new sources for the session.
+// A URL the session already knows is merged into the stored source instead of duplicated.
+func (r *researchRun) saveSources(found []models.Source) ([]models.Source, error) {
+	saved := make([]models.Source, 0, len(found))
+	seen := make(map[string]bool, len(found))
+	for _, source := range found {
+		if seen[source.URL] {
+			continue
+		}
+		seen[source.URL] = true
+
+		source.SessionID = r.session.ID
+		var existing models.Source
+		err := r.engine.db.WithContext(r.ctx).
+			Where("session_id = ? AND url = ?", r.session.ID, source.URL).
+			First(&existing).Error
+		switch {
+		case err == nil:
+			if existing.Description == "" && source.Description != "" {
+				existing.Description = source.Description
+				if err := r.engine.db.WithContext(r.ctx).Model(&existing).Update("description", source.Description).Error; err != nil {
+					return nil, err
+				}
+			}
+			saved = append(saved, existing)
+			r.emitSource(existing)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := r.engine.db.WithContext(r.ctx).Create(&source).Error; err != nil {
+				return nil, err
+			}
+			saved = append(saved, source)
+			r.emitSource(source)
+		default:
+			return nil, err
+		}
+	}
+	return saved, nil
+}
+
+// saveDocuments persists fetched documents for the session and marks their sources
+// as crawled, recording the metadata the fetcher found on doc.Source
+func (r *researchRun) saveDocuments(fetched []models.Document) ([]models.Document, error) {
+	saved := make([]models.Document, 0, len(fetched))
+	for _, doc := range fetched {
+		doc.SessionID = r.session.ID
+		crawled := crawledColumns(doc)
+		doc.Source = models.Source{}
+		if err := r.engine.db.WithContext(r.ctx).Create(&doc).Error; err != nil {
+			return nil, err
+		}
+		if err := r.engine.db.WithContext(r.ctx).Model(&models.Source{}).
+			Where("id = ?", doc.SourceID).
+			Updates(crawled).Error; err != nil {
+			return nil, err
+		}
+		saved = append(saved, doc)
+		r.emit(models.ResearchEvent{
+			Type:       models.ResearchEventDocumentProcessed,
+			SourceID:   doc.SourceID.String(),
+			DocumentID: doc.ID.String(),
+			Title:      doc.Title,
+		})
+	}
+	return saved, nil
+}
+
+// crawledColumns returns the source columns to update once a document was fetched.
+// Metadata the document does not declare leaves the stored value alone.
+func crawledColumns(doc models.Document) map[string]interface{} {
+	columns := map[string]interface{}{"last_crawled": doc.ProcessedAt}
+	if doc.Source.Authors != "" {
+		columns["authors"] = doc.Source.Authors
+	}
+	if doc.Source.PublishedAt != nil {
+		columns["published_at"] = doc.Source.PublishedAt
+	}
+	if doc.Source.DOI != "" {
+		columns["doi"] = doc.Source.DOI
+	}
+	if doc.Source.Publisher != "" {
+		columns["publisher"] = doc.Source.Publisher
+	}
+	if doc.Source.Journal != "" {
+		columns["journal"] = doc.Source.Journal
+	}
+	return columns
+}
+
+// emitSource publishes a source_found event
+func (r *researchRun) emitSource(source models.Source) {
+	r.emit(models.ResearchEvent{
+		Type:     models.ResearchEventSourceFound,
+		SourceID: source.ID.String(),
+		URL:      source.URL,
+		Title:    source.Title,
+	})
+}
+
+// usedSources returns the sources that back at least one of the given documents
+func usedSources(sources []models.Source, documents []models.Document) []models.Source {
+	ids := make(map[string]bool, len(documents))
+	for _, doc := range documents {
+		ids[doc.SourceID.String()] = true
+	}
+
+	var used []models.Source
+	for _, source := range sources {
+		if ids[source.ID.String()] {
+			used = append(used, source)
+		}
+	}
+	return used
+}
+
+// setThoughtMetadata stores a JSON object in the thought's metadata column
+func setThoughtMetadata(t *models.Thought, metadata map[string]interface{}) error {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	t.Metadata = string(data)
+	return nil
+}
@@ -0,0 +1,622 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/crawler"
+	"github.com/lolzone13/DeepResearch/internal/extract"
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/search"
+)
+
+// QueryPlanner searches for the research query as-is
+type QueryPlanner struct{}
+
+// Plan returns the query itself as the only sub-question and search query
+func (QueryPlanner) Plan(ctx context.Context, session *models.ResearchSession, query string) (*ResearchPlan, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("empty research query")
+	}
+	return &ResearchPlan{
+		SubQuestions: []SubQuestion{{Question: query, Queries: []string{query}}},
+	}, nil
+}
+
+// Expand adds the query combined with terms that recur in the titles and
+// snippets of the sources found so far
+func (QueryPlanner) Expand(ctx context.Context, session *models.ResearchSession, query string, queries []string, sources []models.Source) ([]string, error) {
+	texts := make([]string, len(sources))
+	for i, source := range sources {
+		texts[i] = source.Title + " " + source.Description
+	}
+	return feedbackQueries(query, queries, texts, 2), nil
+}
+
+// FollowUp searches again for the query combined with terms that recur in the
+// documents read so far. It has no notion of gaps and is done when no term recurs.
+func (QueryPlanner) FollowUp(ctx context.Context, session *models.ResearchSession, query string, plan *ResearchPlan, documents []models.Document) (*ResearchPlan, error) {
+	texts := make([]string, len(documents))
+	for i, doc := range documents {
+		texts[i] = doc.Title + " " + truncateText(doc.Content, 5000)
+	}
+	queries := feedbackQueries(query, plan.Queries(), texts, 2)
+	if len(queries) == 0 {
+		return &ResearchPlan{Done: true}, nil
+	}
+	return &ResearchPlan{
+		SubQuestions: []SubQuestion{{Question: query, Queries: queries}},
+	}, nil
+}
+
+// feedbackQueries returns up to n queries that add one term recurring in the
+// texts to the query. Terms of earlier queries are skipped, and a term counts
+// once per text so that a single long text cannot dominate.
+func feedbackQueries(query string, searched []string, texts []string, n int) []string {
+	known := make(map[string]bool)
+	for _, term := range queryTerms(query + " " + strings.Join(searched, " ")) {
+		known[term] = true
+	}
+
+	counts := make(map[string]int)
+	for _, text := range texts {
+		for _, term := range queryTerms(text) {
+			if !known[term] {
+				counts[term]++
+			}
+		}
+	}
+
+	var terms []string
+	for term, count := range counts {
+		if count >= 2 {
+			terms = append(terms, term)
+		}
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if counts[terms[i]] != counts[terms[j]] {
+			return counts[terms[i]] > counts[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+
+	var expanded []string
+	for _, term := range terms {
+		if len(expanded) == n {
+			break
+		}
+		expanded = append(expanded, strings.TrimSpace(query)+" "+term)
+	}
+	return expanded
+}
+
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"')\]]+`)
+
+// SeedURLSearcher uses the URLs mentioned in the queries as sources
+type SeedURLSearcher struct{}
+
+// Search extracts http(s) URLs from the queries
+func (SeedURLSearcher) Search(ctx context.Context, session *models.ResearchSession, queries []string) ([]models.Source, error) {
+	return sourcesFromResults(search.Merge(0, seedResults(queries))), nil
+}
+
+// ProviderSearcher finds sources with a web search provider.
+// URLs mentioned in the queries are always included.
+type ProviderSearcher struct {
+	Provider        search.Provider
+	ResultsPerQuery int
+}
+
+// Search runs every query against the provider and merges duplicate hits
+func (s ProviderSearcher) Search(ctx context.Context, session *models.ResearchSession, queries []string) ([]models.Source, error) {
+	lists := [][]search.Result{seedResults(queries)}
+
+	var errs []error
+	for _, query := range queries {
+		results, err := s.Provider.Search(ctx, query, s.ResultsPerQuery)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		lists = append(lists, results)
+	}
+	if len(errs) == len(queries) && len(lists[0]) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return sourcesFromResults(search.Merge(0, lists...)), nil
+}
+
+// seedResults turns the URLs mentioned in the queries into search results
+func seedResults(queries []string) []search.Result {
+	var results []search.Result
+	for _, query := range queries {
+		for _, raw := range urlPattern.FindAllString(query, -1) {
+			raw = strings.TrimRight(raw, ".,;:")
+			if search.NormalizeURL(raw) == "" {
+				continue
+			}
+			results = append(results, search.Result{
+				Title:    raw,
+				URL:      raw,
+				Provider: "query",
+				Rank:     len(results) + 1,
+			})
+		}
+	}
+	return results
+}
+
+// sourcesFromResults converts merged search results into unsaved sources
+func sourcesFromResults(results []search.Result) []models.Source {
+	sources := make([]models.Source, 0, len(results))
+	for _, result := range results {
+		title := result.Title
+		if title == "" {
+			title = result.URL
+		}
+		sources = append(sources, models.Source{
+			URL:         result.URL,
+			Type:        search.ClassifySource(result.URL),
+			Domain:      search.Domain(result.URL),
+			Title:       title,
+			Description: result.Snippet,
+			PublishedAt: result.PublishedAt,
+		})
+	}
+	return sources
+}
+
+// CrawlFetcher downloads sources with the concurrent crawler and extracts their readable text
+type CrawlFetcher struct {
+	Crawler *crawler.Crawler
+}
+
+// Fetch downloads every source and skips the ones that cannot be read.
+// Bibliographic metadata found in a page is reported on the document's Source.
+func (f CrawlFetcher) Fetch(ctx context.Context, session *models.ResearchSession, sources []models.Source) ([]models.Document, error) {
+	urls := make([]string, len(sources))
+	for i, source := range sources {
+		urls[i] = source.URL
+	}
+
+	pages := f.Crawler.FetchAll(ctx, urls)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var documents []models.Document
+	for i, page := range pages {
+		if page.Err != nil {
+			continue
+		}
+		extracted, err := extract.Extract(page.ContentType, page.Charset, page.Body)
+		if err != nil {
+			continue
+		}
+
+		title := extracted.Title
+		if title == "" {
+			title = sources[i].Title
+		}
+		if title == "" {
+			title = sources[i].URL
+		}
+
+		documents = append(documents, models.Document{
+			SourceID:    sources[i].ID,
+			Title:       title,
+			Content:     extracted.Text,
+			ContentType: extracted.ContentType,
+			WordCount:   extracted.WordCount,
+			PageCount:   len(extracted.Pages),
+			Abstract:    extracted.Abstract,
+			Language:    extracted.Language,
+			ProcessedAt: page.FetchedAt,
+			Source:      sourceMetadata(sources[i].ID, extracted.Metadata),
+		})
+	}
+	return documents, nil
+}
+
+// sourceMetadata returns a source carrying only the metadata extracted from it
+func sourceMetadata(sourceID uuid.UUID, metadata extract.Metadata) models.Source {
+	source := models.Source{
+		ID:          sourceID,
+		PublishedAt: metadata.PublishedAt,
+		DOI:         metadata.DOI,
+		Publisher:   metadata.Publisher,
+		Journal:     metadata.Journal,
+	}
+	if len(metadata.Authors) > 0 {
+		if authors, err := json.Marshal(metadata.Authors); err == nil {
+			source.Authors = string(authors)
+		}
+	}
+	return source
+}
+
+// LexicalAnalyzer scores documents by how many query terms they contain
+type LexicalAnalyzer struct {
+	MinRelevance float64
+}
+
+// Analyze sets Document.Relevance and drops documents at or below MinRelevance
+func (a LexicalAnalyzer) Analyze(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) ([]models.Document, error) {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return documents, nil
+	}
+
+	ranked := make([]models.Document, 0, len(documents))
+	for _, doc := range documents {
+		content := strings.ToLower(doc.Title + " " + doc.Content)
+		matched := 0
+		for _, term := range terms {
+			if strings.Contains(content, term) {
+				matched++
+			}
+		}
+		doc.Relevance = float64(matched) / float64(len(terms))
+		doc.DropReason = ""
+		if doc.Relevance <= a.MinRelevance {
+			doc.DropReason = fmt.Sprintf("matches %d of %d query terms", matched, len(terms))
+		}
+		ranked = append(ranked, doc)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Relevance > ranked[j].Relevance
+	})
+	return ranked, nil
+}
+
+var sentencePattern = regexp.MustCompile(`[^.!?\n]+[.!?]`)
+
+// ExtractiveSynthesizer answers with the sentences that best match the query
+type ExtractiveSynthesizer struct {
+	MaxSentences int
+}
+
+// Synthesize picks the highest scoring sentences and cites their documents
+func (s ExtractiveSynthesizer) Synthesize(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) (string, error) {
+	candidates := rankSentences(query, documents, nil)
+	if len(candidates) == 0 {
+		return "", errors.New("no sentences in the documents match the query")
+	}
+
+	limit := s.MaxSentences
+	if limit <= 0 || limit > len(candidates) {
+		limit = len(candidates)
+	}
+
+	var answer strings.Builder
+	for _, c := range candidates[:limit] {
+		fmt.Fprintf(&answer, "%s [%d]\n", c.text, c.doc+1)
+	}
+	answer.WriteString("\nSources:\n")
+	for i, doc := range documents {
+		fmt.Fprintf(&answer, "[%d] %s\n", i+1, doc.Title)
+	}
+	return strings.TrimSpace(answer.String()), nil
+}
+
+// rankedSentence is a document sentence scored against a query
+type rankedSentence struct {
+	text  string
+	doc   int // Index of the document the sentence comes from
+	score int
+}
+
+// rankSentences returns the sentences of the documents that match the query,
+// best first. Sentences containing one of the bonus cues score one extra point.
+func rankSentences(query string, documents []models.Document, bonus []string) []rankedSentence {
+	terms := queryTerms(query)
+	var candidates []rankedSentence
+	for i, doc := range documents {
+		for _, sentence := range sentencePattern.FindAllString(doc.Content, -1) {
+			sentence = strings.TrimSpace(sentence)
+			lower := strings.ToLower(sentence)
+			score := 0
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					score++
+				}
+			}
+			if score == 0 {
+				continue
+			}
+			for _, cue := range bonus {
+				if strings.Contains(lower, cue) {
+					score++
+					break
+				}
+			}
+			candidates = append(candidates, rankedSentence{text: sentence, doc: i, score: score})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	return candidates
+}
+
+// queryTerms returns the lower-cased words of a query that are worth matching
+func queryTerms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if len(word) < 3 || stopWords[word] || seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "what": true, "which": true,
+	"how": true, "why": true, "who": true, "when": true, "where": true, "with": true,
+	"that": true, "this": true, "from": true, "about": true, "into": true, "does": true,
+	"was": true, "were": true, "has": true, "have": true, "its": true, "can": true,
+	"http": true, "https": true, "www": true, "com": true,
+	"also": true, "more": true, "most": true, "other": true, "some": true, "such": true,
+	"than": true, "then": true, "there": true, "these": true, "they": true, "their": true,
+	"will": true, "would": true, "been": true, "only": true, "over": true, "not": true,
+	"but": true, "all": true, "our": true, "you": true, "your": true, "new": true,
+}
+
+// LLMSynthesizer writes the answer with a language model
+type LLMSynthesizer struct {
+	Provider         llm.Provider
+	MaxDocumentChars int // Per-document context budget
+}
+
+// Synthesize asks the model to answer the query from the documents, citing them as [n]
+func (s LLMSynthesizer) Synthesize(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document) (string, error) {
+	return s.SynthesizeStream(ctx, session, query, documents, nil)
+}
+
+// SynthesizeStream is like Synthesize but streams the answer to onDelta as the model writes it
+func (s LLMSynthesizer) SynthesizeStream(ctx context.Context, session *models.ResearchSession, query string, documents []models.Document, onDelta func(delta string)) (string, error) {
+	limit := s.MaxDocumentChars
+	if limit <= 0 {
+		limit = 6000
+	}
+
+	sources := documentContext(documents, limit)
+
+	req := llm.ChatRequest{
+		Messages: []llm.Message{
+			{
+				Role: llm.RoleSystem,
+				Content: "You are a careful research assistant. Answer the question using only the numbered documents. " +
+					"Cite every claim with the document number in square brackets, e.g. [1]. " +
+					"If the documents do not answer the question, say so.",
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: fmt.Sprintf("Documents:\n\n%s\nQuestion: %s", sources, query),
+			},
+		},
+		Temperature: 0.2,
+	}
+
+	var resp *llm.ChatResponse
+	var err error
+	if onDelta != nil {
+		resp, err = s.Provider.ChatStream(ctx, req, func(token string) error {
+			onDelta(token)
+			return nil
+		})
+	} else {
+		resp, err = s.Provider.Chat(ctx, req)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	answer := strings.TrimSpace(resp.Content)
+	if answer == "" {
+		return "", errors.New("language model returned an empty answer")
+	}
+	return answer, nil
+}
+
+// documentContext numbers the documents for a prompt, truncating each to limit bytes
+func documentContext(documents []models.Document, limit int) string {
+	var context strings.Builder
+	for i, doc := range documents {
+		fmt.Fprintf(&context, "[%d] %s\n%s\n\n", i+1, doc.Title, truncateText(doc.Content, limit))
+	}
+	return context.String()
+}
+
+// conclusionCues mark sentences that state a finding or conclusion
+var conclusionCues = []string{
+	"in conclusion", "we conclude", "overall", "therefore", "thus", "in summary",
+	"suggest", "results show", "findings", "demonstrate", "indicate",
+}
+
+// extractiveSummaryTitles are the titles of extractive summaries by type
+var extractiveSummaryTitles = map[models.SummaryType]string{
+	models.SummaryTypeOverview:   "Overview",
+	models.SummaryTypeDetailed:   "Detailed summary",
+	models.SummaryTypeKeyPoints:  "Key points",
+	models.SummaryTypeConclusion: "Conclusion",
+}
+
+// ExtractiveSummarizer summarizes with the document sentences that best match the query
+type ExtractiveSummarizer struct{}
+
+// Summarize picks the best matching sentences for the summary type and cites their documents
+func (ExtractiveSummarizer) Summarize(ctx context.Context, session *models.ResearchSession, query string, summaryType models.SummaryType, documents []models.Document) (*models.Summary, error) {
+	var bonus []string
+	limit := 5
+	switch summaryType {
+	case models.SummaryTypeDetailed:
+		limit = 15
+	case models.SummaryTypeKeyPoints:
+		limit = 6
+	case models.SummaryTypeConclusion:
+		bonus = conclusionCues
+		limit = 3
+	}
+
+	candidates := rankSentences(query, documents, bonus)
+	if len(candidates) == 0 {
+		return nil, errors.New("no sentences in the documents match the query")
+	}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	points := make([]string, len(candidates))
+	for i, c := range candidates {
+		points[i] = fmt.Sprintf("%s [%d]", c.text, c.doc+1)
+	}
+
+	summary := &models.Summary{
+		Title:           extractiveSummaryTitles[summaryType],
+		ConfidenceScore: meanRelevance(documents),
+		GeneratedBy:     "extractive",
+	}
+	if summaryType == models.SummaryTypeKeyPoints {
+		summary.Content = "- " + strings.Join(points, "\n- ")
+		if err := setSummaryKeyPoints(summary, points); err != nil {
+			return nil, err
+		}
+	} else {
+		summary.Content = strings.Join(points, " ")
+	}
+	return summary, nil
+}
+
+// summaryInstructions describe each summary type to the language model
+var summaryInstructions = map[models.SummaryType]string{
+	models.SummaryTypeOverview:   "Write a concise overview of what the documents say about the question in one or two paragraphs.",
+	models.SummaryTypeDetailed:   "Write a detailed summary covering every important finding in the documents, in several paragraphs.",
+	models.SummaryTypeKeyPoints:  "List the five to eight most important findings as short, self-contained key points.",
+	models.SummaryTypeConclusion: "State the conclusion the documents support, noting where the evidence is weak or conflicting.",
+}
+
+// LLMSummarizer writes summaries with a language model
+type LLMSummarizer struct {
+	Provider         llm.Provider
+	MaxDocumentChars int // Per-document context budget
+}
+
+// Summarize asks the model for a summary of the given type as JSON
+func (s LLMSummarizer) Summarize(ctx context.Context, session *models.ResearchSession, query string, summaryType models.SummaryType, documents []models.Document) (*models.Summary, error) {
+	instructions, ok := summaryInstructions[summaryType]
+	if !ok {
+		return nil, fmt.Errorf("unknown summary type %q", summaryType)
+	}
+
+	limit := s.MaxDocumentChars
+	if limit <= 0 {
+		limit = 6000
+	}
+
+	resp, err := s.Provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{
+				Role: llm.RoleSystem,
+				Content: "You are a careful research assistant. Summarize the numbered documents with respect to the question, " +
+					"using only what they say and citing document numbers in square brackets, e.g. [1]. " + instructions + " " +
+					`Reply with a JSON object: {"title": string, "content": string, "key_points": [string], ` +
+					`"confidence": number between 0 and 1 expressing how well the documents support the summary}.`,
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: fmt.Sprintf("Documents:\n\n%s\nQuestion: %s", documentContext(documents, limit), query),
+			},
+		},
+		Temperature: 0.2,
+		JSONMode:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Title      string   `json:"title"`
+		Content    string   `json:"content"`
+		KeyPoints  []string `json:"key_points"`
+		Confidence *float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(resp.Content)), &parsed); err != nil {
+		return nil, fmt.Errorf("language model returned an invalid summary: %w", err)
+	}
+
+	summary := &models.Summary{
+		Title:           strings.TrimSpace(parsed.Title),
+		Content:         strings.TrimSpace(parsed.Content),
+		ConfidenceScore: meanRelevance(documents),
+		GeneratedBy:     "ai",
+	}
+	if summary.Content == "" && len(parsed.KeyPoints) > 0 {
+		summary.Content = "- " + strings.Join(parsed.KeyPoints, "\n- ")
+	}
+	if summary.Content == "" {
+		return nil, errors.New("language model returned an empty summary")
+	}
+	if summary.Title == "" {
+		summary.Title = extractiveSummaryTitles[summaryType]
+	}
+	if parsed.Confidence != nil {
+		summary.ConfidenceScore = math.Max(0, math.Min(1, *parsed.Confidence))
+	}
+	if err := setSummaryKeyPoints(summary, parsed.KeyPoints); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// jsonObject returns the outermost JSON object in a model reply, ignoring code fences and chatter
+func jsonObject(reply string) string {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return reply
+	}
+	return reply[start : end+1]
+}
+
+// meanRelevance returns the average relevance of the documents
+func meanRelevance(documents []models.Document) float64 {
+	if len(documents) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, doc := range documents {
+		total += doc.Relevance
+	}
+	return total / float64(len(documents))
+}
+
+// setSummaryKeyPoints stores the key points as a JSON array
+func setSummaryKeyPoints(summary *models.Summary, points []string) error {
+	if points == nil {
+		points = []string{}
+	}
+	data, err := json.Marshal(points)
+	if err != nil {
+		return err
+	}
+	summary.KeyPoints = string(data)
+	return nil
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/crawler"
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/search"
+	"github.com/lolzone13/DeepResearch/internal/testdb"
+	"gorm.io/gorm"
+)
+
+// fakeLLM answers planning requests (JSON mode) with plan and every other
+// request with answer, streamed word by word
+type fakeLLM struct {
+	plan   string
+	answer string
+	err    error
+}
+
+func (f *fakeLLM) Name() string { return "fake" }
+
+func (f *fakeLLM) Chat(ctx context.Context, req llm.ChatRequest) (*llm.ChatResponse, error) {
+	return f.ChatStream(ctx, req, nil)
+}
+
+func (f *fakeLLM) ChatStream(ctx context.Context, req llm.ChatRequest, onToken llm.TokenFunc) (*llm.ChatResponse, error) {
+	if req.JSONMode {
+		return &llm.ChatResponse{Content: f.plan}, nil
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, word := range strings.SplitAfter(f.answer, " ") {
+		if onToken != nil {
+			if err := onToken(word); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &llm.ChatResponse{Content: f.answer}, nil
+}
+
+func (f *fakeLLM) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	return nil, errors.New("embeddings are not supported")
+}
+
+// newPageServer serves a few HTML pages about Go and a permissive robots.txt
+func newPageServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/go":     "<html><head><title>The Go language</title></head><body><p>Go was designed at Google and released in 2009.</p></body></html>",
+		"/gopher": "<html><head><title>The gopher</title></head><body><p>The Go gopher mascot was drawn by Renee French.</p></body></html>",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nAllow: /\n")
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResearchEngineRun(t *testing.T) {
+	db := testdb.Open(t)
+	server := newPageServer(t)
+
+	searcher := search.NewFixtureFromResults(map[string][]search.Result{
+		"*": {
+			{Title: "Go", URL: server.URL + "/go", Snippet: "The Go programming language"},
+			{Title: "Gopher", URL: server.URL + "/gopher", Snippet: "The Go mascot"},
+		},
+	})
+	plan := `{"sub_questions": [{"question": "When was Go released?", "queries": ["go release year"]}]}`
+
+	tests := []struct {
+		name          string
+		llm           *fakeLLM
+		wantStatus    models.SessionStatus
+		wantContent   string
+		wantCitations int
+		wantThought   models.ThoughtType
+		wantVisible   bool
+	}{
+		{
+			name:          "answer is stored with its citations",
+			llm:           &fakeLLM{plan: plan, answer: "Go was released in 2009 [1]."},
+			wantStatus:    models.SessionStatusCompleted,
+			wantContent:   "Go was released in 2009 [1].",
+			wantCitations: 1,
+			wantThought:   models.ThoughtTypeCompleted,
+			wantVisible:   true,
+		},
+		{
+			name:        "synthesis failure fails the session",
+			llm:         &fakeLLM{plan: plan, err: errors.New("model unavailable")},
+			wantStatus:  models.SessionStatusFailed,
+			wantThought: models.ThoughtTypeError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewResearchEngine(db, ResearchStages{
+				Planner:     LLMPlanner{Provider: tt.llm},
+				Searcher:    ProviderSearcher{Provider: searcher},
+				Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{RespectRobots: true})},
+				Analyzer:    LexicalAnalyzer{},
+				Synthesizer: LLMSynthesizer{Provider: tt.llm},
+			}, nil)
+			if err != nil {
+				t.Fatalf("NewResearchEngine: %v", err)
+			}
+
+			session := createTestSession(t, db, "When was Go released?")
+			message, err := engine.Run(context.Background(), session, "")
+			if tt.wantStatus == models.SessionStatusCompleted && err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if tt.wantStatus != models.SessionStatusCompleted && err == nil {
+				t.Fatal("Run succeeded, want an error")
+			}
+
+			var stored models.ResearchSession
+			if err := db.First(&stored, "id = ?", session.ID).Error; err != nil {
+				t.Fatalf("failed to load session: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("session status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+
+			var reply models.Message
+			if err := db.Preload("Thoughts").Preload("Citations").Preload("Sources").
+				First(&reply, "id = ?", message.ID).Error; err != nil {
+				t.Fatalf("failed to load reply: %v", err)
+			}
+			if reply.Content != tt.wantContent {
+				t.Errorf("reply content = %q, want %q", reply.Content, tt.wantContent)
+			}
+			if reply.IsVisible != tt.wantVisible {
+				t.Errorf("reply visible = %v, want %v", reply.IsVisible, tt.wantVisible)
+			}
+			if len(reply.Citations) != tt.wantCitations {
+				t.Errorf("got %d citations, want %d", len(reply.Citations), tt.wantCitations)
+			}
+			if tt.wantCitations > 0 && len(reply.Sources) == 0 {
+				t.Error("reply has no sources")
+			}
+
+			var last models.Thought
+			if err := db.Where("message_id = ?", reply.ID).Order("created_at DESC").First(&last).Error; err != nil {
+				t.Fatalf("failed to load thoughts: %v", err)
+			}
+			if last.Type != tt.wantThought {
+				t.Errorf("last thought = %s, want %s", last.Type, tt.wantThought)
+			}
+			if len(reply.Thoughts) < 4 {
+				t.Errorf("got %d thoughts, want one per stage", len(reply.Thoughts))
+			}
+		})
+	}
+}
+
+func TestSummarizeConcurrently(t *testing.T) {
+	db := testdb.Open(t)
+	engine, err := NewResearchEngine(db, ResearchStages{
+		Planner:     QueryPlanner{},
+		Searcher:    SeedURLSearcher{},
+		Fetcher:     CrawlFetcher{Crawler: crawler.New(crawler.Options{})},
+		Analyzer:    LexicalAnalyzer{},
+		Synthesizer: ExtractiveSynthesizer{},
+		Summarizer:  ExtractiveSummarizer{},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := createTestSession(t, db, "When was Go released?")
+	documents := []models.Document{{Content: "Go was released in 2009. It was designed at Google.", Relevance: 1}}
+
+	// Regenerating the same summary at once must not trip over the unique index
+	const writers = 5
+	errs := make(chan error, writers)
+	for range writers {
+		go func() {
+			_, err := engine.Summarize(context.Background(), session, session.Query, models.SummaryTypeOverview, documents)
+			errs <- err
+		}()
+	}
+	for range writers {
+		if err := <-errs; err != nil {
+			t.Errorf("Summarize: %v", err)
+		}
+	}
+
+	summary, err := engine.Summarize(context.Background(), session, session.Query, models.SummaryTypeOverview, documents)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	var stored []models.Summary
+	if err := db.Where("session_id = ?", session.ID).Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].ID != summary.ID || !strings.Contains(stored[0].Content, "2009") {
+		t.Errorf("stored %d summaries, want the one returned (%s)", len(stored), summary.ID)
+	}
+}
+
+// createTestSession stores a user and a pending session for query
+func createTestSession(t *testing.T, db *gorm.DB, query string) *models.ResearchSession {
+	t.Helper()
+	user := models.User{Email: fmt.Sprintf("%s@example.com", strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))), PasswordHash: "x", Name: "Test"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	session := models.ResearchSession{
+		UserID:      user.ID,
+		Title:       query,
+		Description: query,
+		Query:       query,
+		SearchDepth: models.SearchDepthShallow,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	return &session
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"github.com/lolzone13/DeepResearch/internal/retrieval"
+	"gorm.io/gorm"
+)
+
+// RetrievedPassage is a chunk returned by a retrieval query, with its document and source
+type RetrievedPassage struct {
+	Chunk    models.DocumentChunk
+	Document models.Document
+	Score    float64
+}
+
+// RetrievalService finds the passages of a session that best answer a question
+type RetrievalService struct {
+	db        *gorm.DB
+	retriever *retrieval.Retriever
+}
+
+// NewRetrievalService creates a new retrieval service; retriever may be nil when
+// no embedding provider is configured
+func NewRetrievalService(db *gorm.DB, retriever *retrieval.Retriever) *RetrievalService {
+	return &RetrievalService{
+		db:        db,
+		retriever: retriever,
+	}
+}
+
+// Retrieve returns the k passages of the session most similar to the question
+func (s *RetrievalService) Retrieve(ctx context.Context, sessionID, userID, question string, k int) ([]RetrievedPassage, error) {
+	if s.retriever == nil {
+		return nil, errors.New("retrieval is not configured")
+	}
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, errors.New("question is required")
+	}
+
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.retriever.Retrieve(ctx, session.ID, question, k, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return []RetrievedPassage{}, nil
+	}
+
+	ids := make([]uuid.UUID, len(matches))
+	for i, match := range matches {
+		ids[i] = match.Chunk.DocumentID
+	}
+	var documents []models.Document
+	if err := s.db.WithContext(ctx).Preload("Source").Where("id IN ?", ids).Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Document, len(documents))
+	for _, doc := range documents {
+		byID[doc.ID] = doc
+	}
+
+	passages := make([]RetrievedPassage, len(matches))
+	for i, match := range matches {
+		passages[i] = RetrievedPassage{
+			Chunk:    match.Chunk,
+			Document: byID[match.Chunk.DocumentID],
+			Score:    match.Score,
+		}
+	}
+	return passages, nil
+}
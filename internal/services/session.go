@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lolzone13/DeepResearch/internal/models"
@@ -19,14 +20,29 @@ func NewSessionService(db *gorm.DB) *SessionService {
 	return &SessionService{db: db}
 }
 
-// CreateSession creates a new research session
-func (s *SessionService) CreateSession(userID, title, query string, tags []string) (*models.ResearchSession, error) {
+// CreateSession creates a new research session.
+// A zero maxSources and an empty searchDepth select the defaults.
+func (s *SessionService) CreateSession(userID, title, query string, tags []string, maxSources int, searchDepth string) (*models.ResearchSession, error) {
 	// Convert userID string to UUID
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID")
 	}
 
+	if maxSources == 0 {
+		maxSources = models.DefaultMaxSources
+	}
+	if maxSources < 1 || maxSources > models.MaxSourcesLimit {
+		return nil, models.ErrInvalidMaxSources
+	}
+	depth := models.SearchDepth(searchDepth)
+	if depth == "" {
+		depth = models.DefaultSearchDepth
+	}
+	if !depth.IsValid() {
+		return nil, models.ErrInvalidSearchDepth
+	}
+
 	// Convert tags to JSON string
 	tagsJSON, err := json.Marshal(tags)
 	if err != nil {
@@ -39,6 +55,8 @@ func (s *SessionService) CreateSession(userID, title, query string, tags []strin
 		Query:       query,
 		Description: "Research session for: " + query,
 		Tags:        string(tagsJSON),
+		MaxSources:  maxSources,
+		SearchDepth: depth,
 	}
 
 	if err := s.db.Create(&session).Error; err != nil {
@@ -62,7 +80,7 @@ func (s *SessionService) GetSession(sessionID string, userID string) (*models.Re
 
 	var session models.ResearchSession
 	err = s.db.Where("id = ? AND user_id = ?", sessionUUID, userUUID).
-		Preload("Messages").
+		Preload("Messages", "is_visible = ?", true).
 		Preload("Sources").
 		First(&session).
 		Error
@@ -89,8 +107,12 @@ func (s *SessionService) ListSessions(userID string, page, perPage int, status s
 
 	query := s.db.Where("user_id = ?", userUUID)
 
-	// Note: Since we don't have a status field in the current model, we'll ignore the status filter for now
-	// This can be added when the model is updated
+	if status != "" {
+		if !models.SessionStatus(status).IsValid() {
+			return nil, 0, models.ErrInvalidSessionStatus
+		}
+		query = query.Where("status = ?", status)
+	}
 
 	// Count total records
 	if err := query.Model(&models.ResearchSession{}).Count(&total).Error; err != nil {
@@ -173,29 +195,45 @@ func (s *SessionService) DeleteSession(sessionID, userID string) error {
 	return nil
 }
 
-// GetSessionStats returns statistics for a user's sessions
-func (s *SessionService) GetSessionStats(userID string) (map[string]interface{}, error) {
+// GetSessionStats returns the number of a user's sessions in each status
+func (s *SessionService) GetSessionStats(userID string) (*models.SessionStatsResponse, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, errors.New("invalid user ID")
 	}
 
-	var total int64
-
-	// Count total sessions
-	if err := s.db.Model(&models.ResearchSession{}).Where("user_id = ?", userUUID).Count(&total).Error; err != nil {
+	var counts []struct {
+		Status models.SessionStatus
+		Count  int64
+	}
+	err = s.db.Model(&models.ResearchSession{}).
+		Select("status, COUNT(*) AS count").
+		Where("user_id = ?", userUUID).
+		Group("status").
+		Scan(&counts).Error
+	if err != nil {
 		return nil, err
 	}
 
-	result := map[string]interface{}{
-		"total": total,
-		// Note: Since we don't have status field, we'll just return total for now
-		"active":    0,
-		"completed": 0,
-		"pending":   0,
+	stats := &models.SessionStatsResponse{}
+	for _, c := range counts {
+		stats.Total += c.Count
+		switch c.Status {
+		case models.SessionStatusPending:
+			stats.Pending = c.Count
+		case models.SessionStatusRunning:
+			stats.Running = c.Count
+		case models.SessionStatusCompleted:
+			stats.Completed = c.Count
+		case models.SessionStatusFailed:
+			stats.Failed = c.Count
+		case models.SessionStatusCancelled:
+			stats.Cancelled = c.Count
+		}
 	}
+	stats.Active = stats.Pending + stats.Running
 
-	return result, nil
+	return stats, nil
 }
 
 // Helper function to parse tags from JSON string
@@ -207,3 +245,54 @@ func (s *SessionService) ParseTags(tagsJSON string) ([]string, error) {
 	err := json.Unmarshal([]byte(tagsJSON), &tags)
 	return tags, err
 }
+
+// queueSession moves a session to pending unless it is already waiting to run
+func queueSession(db *gorm.DB, session *models.ResearchSession) error {
+	if session.Status == models.SessionStatusPending {
+		return nil
+	}
+	if err := transitionSession(db, session.ID, models.SessionStatusPending); err != nil {
+		return err
+	}
+	session.Status = models.SessionStatusPending
+	session.StartedAt = nil
+	session.FinishedAt = nil
+	return nil
+}
+
+// transitionSession moves a session to a new status and stamps its run timestamps.
+// The update only matches sessions in a status allowed to move to the new one,
+// so concurrent callers cannot both win and illegal transitions are rejected.
+func transitionSession(db *gorm.DB, sessionID uuid.UUID, to models.SessionStatus) error {
+	updates := map[string]interface{}{"status": to}
+	now := time.Now()
+	switch {
+	case to == models.SessionStatusPending:
+		updates["started_at"] = nil
+		updates["finished_at"] = nil
+	case to == models.SessionStatusRunning:
+		updates["started_at"] = now
+		updates["finished_at"] = nil
+	case to.IsTerminal():
+		updates["finished_at"] = now
+	}
+
+	result := db.Model(&models.ResearchSession{}).
+		Where("id = ? AND status IN ?", sessionID, to.PreviousStatuses()).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var current models.ResearchSession
+	if err := db.Select("status").Where("id = ?", sessionID).First(&current).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("session not found")
+		}
+		return err
+	}
+	return models.TransitionError(current.Status, to)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+	"gorm.io/gorm"
+)
+
+// maxSummaryDocuments bounds the stored documents a regenerated summary is written from
+const maxSummaryDocuments = 20
+
+// SummaryService handles the stored summaries of research sessions
+type SummaryService struct {
+	db             *gorm.DB
+	researchEngine *ResearchEngine
+}
+
+// NewSummaryService creates a new summary service
+func NewSummaryService(db *gorm.DB, researchEngine *ResearchEngine) *SummaryService {
+	return &SummaryService{
+		db:             db,
+		researchEngine: researchEngine,
+	}
+}
+
+// ListSummaries returns a session's summaries
+func (s *SummaryService) ListSummaries(sessionID, userID string) ([]models.Summary, error) {
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []models.Summary
+	err = s.db.Where("session_id = ?", session.ID).
+		Order("created_at ASC").
+		Find(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// RegenerateSummary rewrites one summary of a session from its stored documents,
+// without searching or crawling again
+func (s *SummaryService) RegenerateSummary(ctx context.Context, sessionID, userID, summaryType string) (*models.Summary, error) {
+	if !models.SummaryType(summaryType).IsValid() {
+		return nil, errors.New("invalid summary type")
+	}
+
+	session, err := findOwnedSession(s.db, sessionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer the documents the analyzer kept; older runs may not have scored any
+	var documents []models.Document
+	err = s.db.WithContext(ctx).
+		Where("session_id = ? AND relevance > 0 AND (drop_reason IS NULL OR drop_reason = '')", session.ID).
+		Order("relevance DESC").
+		Limit(maxSummaryDocuments).
+		Find(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		err = s.db.WithContext(ctx).
+			Where("session_id = ?", session.ID).
+			Order("created_at DESC").
+			Limit(maxSummaryDocuments).
+			Find(&documents).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Summarize with respect to the latest question asked in the session
+	query := session.Query
+	var latest models.Message
+	err = s.db.WithContext(ctx).
+		Where("session_id = ? AND type = ?", session.ID, models.MessageTypeUser).
+		Order("created_at DESC").
+		Take(&latest).Error
+	switch {
+	case err == nil:
+		query = latest.Content
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	return s.researchEngine.Summarize(ctx, session, query, models.SummaryType(summaryType), documents)
+}
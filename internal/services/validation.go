@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lolzone13/DeepResearch/internal/llm"
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+// VerifiedClaim is a factual claim of a draft answer and its verdict
+type VerifiedClaim struct {
+	Claim       string              `json:"claim"`
+	Verdict     models.ClaimVerdict `json:"verdict"`
+	Evidence    []int               `json:"evidence"` // Numbers of the passages that decided the verdict, as cited with [n]
+	Explanation string              `json:"explanation,omitempty"`
+}
+
+// Validation is the outcome of checking a draft answer
+type Validation struct {
+	Claims []VerifiedClaim
+	Answer string // The draft revised or flagged to match the verdicts
+}
+
+// ResearchValidator checks the claims of a draft answer against the passages it
+// was written from, where [n] cites evidence[n-1]
+type ResearchValidator interface {
+	Validate(ctx context.Context, session *models.ResearchSession, query, draft string, evidence []models.Document) (*Validation, error)
+}
+
+// claimPattern matches a sentence with the citation markers that follow it
+var claimPattern = regexp.MustCompile(`[^.!?\n]+[.!?]*((?:[ \t]*\[\d+(?:\s*,\s*\d+)*\])*)`)
+
+// spaceBeforePunctuation matches the gap left where a marker preceded punctuation
+var spaceBeforePunctuation = regexp.MustCompile(`\s+([.!?,;:])`)
+
+// numberPattern matches the figures of a claim
+var numberPattern = regexp.MustCompile(`\d+(?:[.,]\d+)*`)
+
+// negationCues mark sentences that deny rather than assert
+var negationCues = []string{"not", "no", "never", "none", "neither", "nor", "cannot", "without", "fails", "failed"}
+
+// draftClaim is a sentence of a draft answer and the markers it cites
+type draftClaim struct {
+	text    string
+	markers []int
+}
+
+// LexicalValidator checks claims by comparing their words and figures with the
+// sentences of the cited passages. It flags the draft rather than rewriting it.
+type LexicalValidator struct {
+	MinOverlap float64 // Share of a claim's terms a passage sentence must contain to support it
+}
+
+// Validate labels every claim supported when a passage sentence shares most of its
+// terms, contradicted when that sentence disagrees on negation or figures, and
+// unsupported otherwise
+func (v LexicalValidator) Validate(ctx context.Context, session *models.ResearchSession, query, draft string, evidence []models.Document) (*Validation, error) {
+	minOverlap := v.MinOverlap
+	if minOverlap <= 0 {
+		minOverlap = 0.6
+	}
+
+	var claims []VerifiedClaim
+	for _, claim := range draftClaims(draft) {
+		terms := queryTerms(claim.text)
+		if len(terms) == 0 {
+			continue
+		}
+
+		// Check the cited passages, or every passage when the claim cites none
+		candidates := claim.markers
+		if len(candidates) == 0 {
+			for i := range evidence {
+				candidates = append(candidates, i+1)
+			}
+		}
+
+		verified := VerifiedClaim{Claim: claim.text, Verdict: models.ClaimUnsupported, Evidence: []int{}}
+		bestOverlap, bestSentence, bestMarker := 0.0, "", 0
+		for _, marker := range candidates {
+			if marker < 1 || marker > len(evidence) {
+				continue
+			}
+			for _, sentence := range sentencePattern.FindAllString(evidence[marker-1].Content, -1) {
+				lower := strings.ToLower(sentence)
+				matched := 0
+				for _, term := range terms {
+					if strings.Contains(lower, term) {
+						matched++
+					}
+				}
+				if overlap := float64(matched) / float64(len(terms)); overlap > bestOverlap {
+					bestOverlap, bestSentence, bestMarker = overlap, strings.TrimSpace(sentence), marker
+				}
+			}
+		}
+
+		if bestOverlap >= minOverlap {
+			verified.Evidence = []int{bestMarker}
+			verified.Verdict = models.ClaimSupported
+			verified.Explanation = fmt.Sprintf("[%d] says: %s", bestMarker, bestSentence)
+			if reason := disagreement(claim.text, bestSentence); reason != "" {
+				verified.Verdict = models.ClaimContradicted
+				verified.Explanation = fmt.Sprintf("[%d] %s: %s", bestMarker, reason, bestSentence)
+			}
+		} else {
+			verified.Explanation = "No passage states this claim"
+		}
+		claims = append(claims, verified)
+	}
+
+	return &Validation{Claims: claims, Answer: flagClaims(draft, claims)}, nil
+}
+
+// disagreement explains how a passage sentence that shares a claim's terms
+// disagrees with it, or returns an empty string when it does not
+func disagreement(claim, sentence string) string {
+	if hasNegation(claim) != hasNegation(sentence) {
+		return "negates the claim"
+	}
+
+	claimNumbers := numberPattern.FindAllString(claim, -1)
+	sentenceNumbers := numberPattern.FindAllString(sentence, -1)
+	if len(claimNumbers) == 0 || len(sentenceNumbers) == 0 {
+		return ""
+	}
+	for _, number := range claimNumbers {
+		for _, other := range sentenceNumbers {
+			if number == other {
+				return ""
+			}
+		}
+	}
+	return "gives different figures"
+}
+
+func hasNegation(text string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r == '\'')
+	}) {
+		if strings.HasSuffix(word, "n't") {
+			return true
+		}
+		for _, cue := range negationCues {
+			if word == cue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// draftClaims splits a draft answer into sentences with the markers they cite.
+// A trailing source list is not part of the answer and is skipped.
+func draftClaims(draft string) []draftClaim {
+	var claims []draftClaim
+	for _, line := range strings.Split(draft, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.EqualFold(trimmed, "Sources:") || strings.EqualFold(trimmed, "References:") {
+			break
+		}
+		if loc := citationPattern.FindStringIndex(trimmed); loc != nil && loc[0] == 0 {
+			continue
+		}
+
+		for _, sentence := range claimPattern.FindAllString(line, -1) {
+			var markers []int
+			for _, match := range citationPattern.FindAllStringSubmatch(sentence, -1) {
+				for _, number := range strings.Split(match[1], ",") {
+					if marker, err := strconv.Atoi(strings.TrimSpace(number)); err == nil {
+						markers = append(markers, marker)
+					}
+				}
+			}
+			text := citationPattern.ReplaceAllString(sentence, "")
+			text = strings.TrimSpace(spaceBeforePunctuation.ReplaceAllString(text, "$1"))
+			text = strings.TrimLeft(text, "-*• ")
+			if len(strings.Fields(text)) < 3 {
+				continue
+			}
+			claims = append(claims, draftClaim{text: text, markers: markers})
+		}
+	}
+	return claims
+}
+
+// flagClaims marks the claims that are not supported in the draft, after the
+// claim and its citation markers. Claims are located in draft order, each after
+// the previous one, so that a sentence repeated in the draft is flagged where
+// the claim was made; a claim found only earlier is located from the start.
+func flagClaims(draft string, claims []VerifiedClaim) string {
+	pos := 0
+	for _, claim := range claims {
+		text := strings.TrimRight(claim.Claim, ".!?")
+		start := strings.Index(draft[pos:], text)
+		if start >= 0 {
+			start += pos
+		} else if start = strings.Index(draft, text); start < 0 {
+			continue
+		}
+		end := start + len(text)
+		for {
+			loc := citationPattern.FindStringIndex(draft[end:])
+			if loc == nil || strings.TrimSpace(draft[end:end+loc[0]]) != "" {
+				break
+			}
+			end += loc[1]
+		}
+
+		var flag string
+		switch claim.Verdict {
+		case models.ClaimContradicted:
+			flag = " (contradicted by the sources)"
+		case models.ClaimUnsupported:
+			flag = " (unverified)"
+		}
+		draft = draft[:end] + flag + draft[end:]
+		if end > pos {
+			pos = end + len(flag)
+		} else {
+			pos += len(flag) // The flag went in before pos
+		}
+	}
+	return draft
+}
+
+// LLMValidator checks claims with a language model, which also revises the draft
+type LLMValidator struct {
+	Provider         llm.Provider
+	MaxDocumentChars int // Per-passage context budget
+}
+
+// Validate asks the model to list the draft's factual claims with a verdict
+// against the numbered passages, and to rewrite the draft to match them
+func (v LLMValidator) Validate(ctx context.Context, session *models.ResearchSession, query, draft string, evidence []models.Document) (*Validation, error) {
+	limit := v.MaxDocumentChars
+	if limit <= 0 {
+		limit = 3000
+	}
+
+	resp, err := v.Provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{
+				Role: llm.RoleSystem,
+				Content: "You fact-check a draft answer against numbered source passages. " +
+					"List every factual claim of the draft and label it supported (a passage states it), " +
+					"contradicted (a passage states otherwise) or unsupported (no passage states it). " +
+					"Then revise the draft: correct or remove contradicted claims, mark unsupported claims with \"(unverified)\", " +
+					"and keep the [n] citation markers of everything that stays. " +
+					`Reply with a JSON object: {"claims": [{"claim": string, "verdict": "supported"|"contradicted"|"unsupported", ` +
+					`"evidence": [passage numbers], "explanation": string}], "revised_answer": string}.`,
+			},
+			{
+				Role:    llm.RoleUser,
+				Content: fmt.Sprintf("Passages:\n\n%s\nQuestion: %s\n\nDraft answer:\n%s", documentContext(evidence, limit), query, draft),
+			},
+		},
+		Temperature: 0,
+		JSONMode:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed struct {
+		Claims        []VerifiedClaim `json:"claims"`
+		RevisedAnswer string          `json:"revised_answer"`
+	}
+	if err := json.Unmarshal([]byte(jsonObject(resp.Content)), &parsed); err != nil {
+		return nil, fmt.Errorf("language model returned an invalid verification: %w", err)
+	}
+
+	claims := make([]VerifiedClaim, 0, len(parsed.Claims))
+	for _, claim := range parsed.Claims {
+		claim.Claim = strings.TrimSpace(claim.Claim)
+		if claim.Claim == "" {
+			continue
+		}
+		if !claim.Verdict.IsValid() {
+			claim.Verdict = models.ClaimUnsupported
+		}
+		var cited []int
+		for _, marker := range claim.Evidence {
+			if marker >= 1 && marker <= len(evidence) {
+				cited = append(cited, marker)
+			}
+		}
+		if cited == nil {
+			cited = []int{}
+		}
+		claim.Evidence = cited
+		claims = append(claims, claim)
+	}
+
+	answer := strings.TrimSpace(parsed.RevisedAnswer)
+	if answer == "" {
+		answer = flagClaims(draft, claims)
+	}
+	return &Validation{Claims: claims, Answer: answer}, nil
+}
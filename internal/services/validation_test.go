@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/lolzone13/DeepResearch/internal/models"
+)
+
+func TestFlagClaims(t *testing.T) {
+	tests := []struct {
+		name   string
+		draft  string
+		claims []VerifiedClaim
+		want   string
+	}{
+		{
+			name:  "flag goes after the citation markers",
+			draft: "Go was released in 2009 [1][2]. It has a mascot [3].",
+			claims: []VerifiedClaim{
+				{Claim: "Go was released in 2009.", Verdict: models.ClaimUnsupported},
+				{Claim: "It has a mascot.", Verdict: models.ClaimSupported},
+			},
+			want: "Go was released in 2009 [1][2] (unverified). It has a mascot [3].",
+		},
+		{
+			name:  "repeated sentence is flagged where the claim was made",
+			draft: "Go is fast [1]. Rust is safe [2]. Go is fast [3].",
+			claims: []VerifiedClaim{
+				{Claim: "Go is fast", Verdict: models.ClaimSupported},
+				{Claim: "Rust is safe", Verdict: models.ClaimSupported},
+				{Claim: "Go is fast", Verdict: models.ClaimContradicted},
+			},
+			want: "Go is fast [1]. Rust is safe [2]. Go is fast [3] (contradicted by the sources).",
+		},
+		{
+			name:  "every occurrence of a repeated claim is flagged",
+			draft: "Go is fast [1]. Go is fast [2].",
+			claims: []VerifiedClaim{
+				{Claim: "Go is fast", Verdict: models.ClaimUnsupported},
+				{Claim: "Go is fast", Verdict: models.ClaimUnsupported},
+			},
+			want: "Go is fast [1] (unverified). Go is fast [2] (unverified).",
+		},
+		{
+			name:  "claims out of order are still flagged",
+			draft: "Rust is safe [2]. Go is fast [1].",
+			claims: []VerifiedClaim{
+				{Claim: "Go is fast", Verdict: models.ClaimSupported},
+				{Claim: "Rust is safe", Verdict: models.ClaimUnsupported},
+			},
+			want: "Rust is safe [2] (unverified). Go is fast [1].",
+		},
+		{
+			name:   "claim missing from the draft is skipped",
+			draft:  "Go is fast [1].",
+			claims: []VerifiedClaim{{Claim: "Java is verbose", Verdict: models.ClaimUnsupported}},
+			want:   "Go is fast [1].",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagClaims(tt.draft, tt.claims); got != tt.want {
+				t.Errorf("flagClaims =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lolzone13/DeepResearch/internal/oidc"
+)
+
+// minRSABits is the smallest RSA modulus accepted for signing keys
+const minRSABits = 2048
+
+// Key is a token signing key. Keys without a private half only verify tokens
+// signed before they were rotated out.
+type Key struct {
+	ID      string
+	Alg     string // RS256, EdDSA or, for the shared secret fallback, HS256
+	private interface{}
+	public  interface{}
+}
+
+// CanSign reports whether the key holds its private half
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+// NewPrivateKey creates a signing key from a PEM encoded RSA or Ed25519 private key
+func NewPrivateKey(id string, pemData []byte) (*Key, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("key %q: RSA keys need at least %d bits", id, minRSABits)
+		}
+		return &Key{ID: id, Alg: jwt.SigningMethodRS256.Alg(), private: private, public: &private.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Alg: jwt.SigningMethodEdDSA.Alg(), private: private, public: private.Public()}, nil
+	default:
+		return nil, fmt.Errorf("key %q: only RSA and Ed25519 keys are supported, got %T", id, parsed)
+	}
+}
+
+// NewPublicKey creates a verification-only key from a PEM encoded RSA or Ed25519 public key
+func NewPublicKey(id string, pemData []byte) (*Key, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM data found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch public := parsed.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Alg: jwt.SigningMethodRS256.Alg(), public: public}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Alg: jwt.SigningMethodEdDSA.Alg(), public: public}, nil
+	default:
+		return nil, fmt.Errorf("key %q: only RSA and Ed25519 keys are supported, got %T", id, parsed)
+	}
+}
+
+// Keyring signs tokens with one key and verifies them with any of its keys,
+// found by the kid header. Each key only accepts its own algorithm.
+type Keyring struct {
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewKeyring creates a keyring that signs with the key named signingID
+func NewKeyring(signingID string, keys ...*Key) (*Keyring, error) {
+	ring := &Keyring{keys: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("signing keys need an ID")
+		}
+		if _, exists := ring.keys[key.ID]; exists {
+			return nil, fmt.Errorf("signing key %q is configured twice", key.ID)
+		}
+		ring.keys[key.ID] = key
+	}
+
+	ring.signing = ring.keys[signingID]
+	if ring.signing == nil {
+		return nil, fmt.Errorf("signing key %q is not configured", signingID)
+	}
+	if !ring.signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private key", signingID)
+	}
+	return ring, nil
+}
+
+// NewHMACKeyring creates a keyring that signs and verifies HS256 tokens with
+// a shared secret, for deployments without asymmetric keys
+func NewHMACKeyring(secret string) *Keyring {
+	key := &Key{Alg: jwt.SigningMethodHS256.Alg(), private: []byte(secret), public: []byte(secret)}
+	return &Keyring{signing: key, keys: map[string]*Key{"": key}}
+}
+
+// Sign signs the claims with the signing key, naming it in the kid header
+func (r *Keyring) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(r.signing.Alg), claims)
+	if r.signing.ID != "" {
+		token.Header["kid"] = r.signing.ID
+	}
+	return token.SignedString(r.signing.private)
+}
+
+// Parse verifies a token against the key named by its kid header and decodes its claims
+func (r *Keyring) Parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) (*jwt.Token, error) {
+	opts = append(opts, jwt.WithValidMethods(r.Algorithms()))
+	return jwt.ParseWithClaims(tokenString, claims, r.keyfunc, opts...)
+}
+
+// keyfunc returns the verification key of a token, refusing tokens signed
+// with another algorithm than their key's
+func (r *Keyring) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Alg {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// Algorithms returns the algorithms of the keyring's keys
+func (r *Keyring) Algorithms() []string {
+	seen := make(map[string]bool)
+	var algs []string
+	for _, key := range r.keys {
+		if !seen[key.Alg] {
+			seen[key.Alg] = true
+			algs = append(algs, key.Alg)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// JWKS returns the public keys of the keyring for other services to verify
+// tokens with. A shared secret is never published.
+func (r *Keyring) JWKS() (oidc.JWKS, error) {
+	set := oidc.JWKS{Keys: []oidc.JWK{}}
+	ids := make([]string, 0, len(r.keys))
+	for id, key := range r.keys {
+		if key.Alg != jwt.SigningMethodHS256.Alg() {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		key := r.keys[id]
+		jwk, err := oidc.NewJWK(key.ID, key.Alg, key.public)
+		if err != nil {
+			return oidc.JWKS{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
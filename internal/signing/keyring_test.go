@@ -0,0 +1,197 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testKeys returns an RSA and an Ed25519 private key
+func testKeys(t *testing.T) (*rsa.PrivateKey, ed25519.PrivateKey) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rsaKey, edKey
+}
+
+// privatePEM loads a private key the way it is configured
+func privatePEM(t *testing.T, id string, private crypto.PrivateKey) *Key {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := NewPrivateKey(id, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	return key
+}
+
+// publicPEM returns the PEM encoding of a public key
+func publicPEM(t *testing.T, public crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// signWith signs a token with any method and key, naming kid unless it is empty
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, jwt.RegisteredClaims{Subject: "user"})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestKeyringParse(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	ring, err := NewKeyring("rsa", privatePEM(t, "rsa", rsaKey), privatePEM(t, "ed", edKey))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	signed, err := ring.Sign(jwt.RegisteredClaims{Subject: "user"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "signed by the keyring", token: signed},
+		{name: "other key of the keyring", token: signWith(t, jwt.SigningMethodEdDSA, "ed", edKey)},
+		{
+			// The classic confusion attack: the RSA public key used as an HMAC secret
+			name:    "HS256 against an RSA kid",
+			token:   signWith(t, jwt.SigningMethodHS256, "rsa", publicPEM(t, &rsaKey.PublicKey)),
+			wantErr: true,
+		},
+		{name: "RS256 against an Ed25519 kid", token: signWith(t, jwt.SigningMethodRS256, "ed", rsaKey), wantErr: true},
+		{name: "unknown kid", token: signWith(t, jwt.SigningMethodRS256, "other", rsaKey), wantErr: true},
+		{name: "missing kid", token: signWith(t, jwt.SigningMethodRS256, "", rsaKey), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ring.Parse(tt.token, &jwt.RegisteredClaims{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	oldPrivate, newPrivate := testKeys(t)
+	before, err := NewKeyring("2025-01", privatePEM(t, "2025-01", oldPrivate))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	old, err := before.Sign(jwt.RegisteredClaims{Subject: "user"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	// The old key is kept, public half only, while its tokens are still valid
+	retired, err := NewPublicKey("2025-01", publicPEM(t, &oldPrivate.PublicKey))
+	if err != nil {
+		t.Fatalf("NewPublicKey: %v", err)
+	}
+	after, err := NewKeyring("2025-06", privatePEM(t, "2025-06", newPrivate), retired)
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if _, err := after.Parse(old, &jwt.RegisteredClaims{}); err != nil {
+		t.Errorf("token of the retired key: %v", err)
+	}
+	fresh, err := after.Sign(jwt.RegisteredClaims{Subject: "user"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	token, err := after.Parse(fresh, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	if kid := token.Header["kid"]; kid != "2025-06" {
+		t.Errorf("new token kid = %v, want 2025-06", kid)
+	}
+
+	// A retired key cannot sign, and once dropped its tokens stop working
+	if _, err := NewKeyring("2025-01", retired); err == nil {
+		t.Error("NewKeyring signs with a public key")
+	}
+	dropped, err := NewKeyring("2025-06", privatePEM(t, "2025-06", newPrivate))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	if _, err := dropped.Parse(old, &jwt.RegisteredClaims{}); err == nil {
+		t.Error("token of a dropped key still verifies")
+	}
+}
+
+func TestKeyringJWKS(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	ring, err := NewKeyring("rsa", privatePEM(t, "rsa", rsaKey), privatePEM(t, "ed", edKey))
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	set, err := ring.JWKS()
+	if err != nil {
+		t.Fatalf("JWKS: %v", err)
+	}
+
+	want := []struct {
+		kid, kty, alg string
+		public        crypto.PublicKey
+	}{
+		{kid: "ed", kty: "OKP", alg: "EdDSA", public: edKey.Public()},
+		{kid: "rsa", kty: "RSA", alg: "RS256", public: &rsaKey.PublicKey},
+	}
+	if len(set.Keys) != len(want) {
+		t.Fatalf("JWKS has %d keys, want %d", len(set.Keys), len(want))
+	}
+	for i, w := range want {
+		jwk := set.Keys[i]
+		if jwk.Kid != w.kid || jwk.Kty != w.kty || jwk.Alg != w.alg || jwk.Use != "sig" {
+			t.Errorf("key %d = %s %s %s %s, want %s %s %s sig", i, jwk.Kid, jwk.Kty, jwk.Alg, jwk.Use, w.kid, w.kty, w.alg)
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: PublicKey: %v", jwk.Kid, err)
+		}
+		if !w.public.(interface{ Equal(crypto.PublicKey) bool }).Equal(public) {
+			t.Errorf("%s: published key does not match", jwk.Kid)
+		}
+	}
+
+	// The shared secret is never published
+	set, err = NewHMACKeyring("a-shared-secret-of-at-least-32-bytes").JWKS()
+	if err != nil {
+		t.Fatalf("JWKS: %v", err)
+	}
+	if set.Keys == nil || len(set.Keys) != 0 {
+		t.Errorf("HMAC keyring JWKS = %+v, want an empty key set", set.Keys)
+	}
+}
@@ -0,0 +1,105 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how many writes pass between sweeps of forgotten entries
+const sweepEvery = 1024
+
+// memoryEntry is the state of one key
+type memoryEntry struct {
+	attempts    int64
+	forgetAt    time.Time
+	lockedUntil time.Time
+	lockedBy    int64 // Attempt that started the lock
+}
+
+// Memory keeps counters in process memory, for single-node deployments
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+// NewMemory creates an empty in-memory store
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]*memoryEntry), now: time.Now}
+}
+
+// Reserve counts an attempt for key unless it is locked
+func (m *Memory) Reserve(ctx context.Context, key string, policy Policy) (int64, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry := m.entry(key, now)
+	if remaining := entry.lockedUntil.Sub(now); remaining > 0 {
+		return 0, remaining, nil
+	}
+
+	entry.attempts++
+	lockout := policy.Lockout(entry.attempts)
+	if lockout > 0 {
+		entry.lockedUntil = now.Add(lockout)
+		entry.lockedBy = entry.attempts
+	}
+	if forgetAt := now.Add(max(policy.Window, lockout)); forgetAt.After(entry.forgetAt) {
+		entry.forgetAt = forgetAt
+	}
+	m.sweep(now)
+	return entry.attempts, 0, nil
+}
+
+// Release uncounts an attempt for key
+func (m *Memory) Release(ctx context.Context, key string, attempt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok || !m.now().Before(entry.forgetAt) {
+		return nil
+	}
+	if entry.lockedBy == attempt {
+		entry.lockedUntil, entry.lockedBy = time.Time{}, 0
+	}
+	if entry.attempts > 0 {
+		entry.attempts--
+	}
+	return nil
+}
+
+// Reset forgets key
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// entry returns the live entry of key, starting over when it was forgotten
+func (m *Memory) entry(key string, now time.Time) *memoryEntry {
+	entry, ok := m.entries[key]
+	if !ok || !now.Before(entry.forgetAt) {
+		entry = &memoryEntry{forgetAt: now}
+		m.entries[key] = entry
+	}
+	return entry
+}
+
+// sweep drops forgotten entries every sweepEvery writes so memory stays bounded
+func (m *Memory) sweep(now time.Time) {
+	m.writes++
+	if m.writes < sweepEvery {
+		return
+	}
+	m.writes = 0
+	for key, entry := range m.entries {
+		if !now.Before(entry.forgetAt) {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keeps counters in Redis so that every node shares them
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis creates a store that namespaces its keys with "throttle:"
+func NewRedis(client redis.UniversalClient) *Redis {
+	return &Redis{client: client, prefix: "throttle:"}
+}
+
+// reserveScript counts an attempt unless the key is locked, and locks the key
+// when the attempt is past the free ones. It mirrors Policy.Lockout.
+// KEYS: counter, lock. ARGV: free attempts, base and max lockout and window in ms.
+var reserveScript = redis.NewScript(`
+local wait = redis.call('PTTL', KEYS[2])
+if wait > 0 then
+	return {0, wait}
+end
+
+local attempt = redis.call('INCR', KEYS[1])
+local over = attempt - tonumber(ARGV[1])
+local lockout = 0
+if over > 0 then
+	local maxLockout = tonumber(ARGV[3])
+	lockout = tonumber(ARGV[2])
+	for i = 1, over - 1 do
+		if lockout >= maxLockout then
+			break
+		end
+		lockout = lockout * 2
+	end
+	lockout = math.min(lockout, maxLockout)
+	redis.call('SET', KEYS[2], attempt, 'PX', lockout)
+end
+redis.call('PEXPIRE', KEYS[1], math.max(tonumber(ARGV[4]), lockout))
+return {attempt, 0}
+`)
+
+// releaseScript uncounts an attempt and lifts the lock it started.
+// KEYS: counter, lock. ARGV: attempt.
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[2]) == ARGV[1] then
+	redis.call('DEL', KEYS[2])
+end
+if tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+	redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// Reserve counts an attempt for key unless it is locked
+func (r *Redis) Reserve(ctx context.Context, key string, policy Policy) (int64, time.Duration, error) {
+	result, err := reserveScript.Run(ctx, r.client, r.keys(key),
+		policy.FreeAttempts, policy.BaseLockout.Milliseconds(), policy.MaxLockout.Milliseconds(), policy.Window.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return result[0], time.Duration(result[1]) * time.Millisecond, nil
+}
+
+// Release uncounts an attempt for key
+func (r *Redis) Release(ctx context.Context, key string, attempt int64) error {
+	return releaseScript.Run(ctx, r.client, r.keys(key), attempt).Err()
+}
+
+// Reset forgets key
+func (r *Redis) Reset(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.keys(key)...).Err()
+}
+
+// keys returns the counter and lock keys of key. The hash tag keeps both in one
+// cluster slot so that scripts may use them together.
+func (r *Redis) keys(key string) []string {
+	counter := r.prefix + "{" + key + "}"
+	return []string{counter, counter + ":lock"}
+}
@@ -0,0 +1,102 @@
+package throttle
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Store keeps attempt counters and lockouts. Implementations must be safe for
+// concurrent use and must count and lock atomically, so that concurrent
+// attempts cannot slip past a lockout; counters shared between nodes need a
+// shared store.
+type Store interface {
+	// Reserve counts an attempt for key and returns its number since the last
+	// reset, unless key is locked, in which case nothing is counted and it
+	// returns how long the lock lasts. An attempt past the policy's free
+	// attempts locks key for the policy's lockout right away. The counter is
+	// forgotten a window after the latest attempt.
+	Reserve(ctx context.Context, key string, policy Policy) (attempt int64, wait time.Duration, err error)
+
+	// Release uncounts an attempt and lifts the lock it started, if that lock is
+	// still the latest one
+	Release(ctx context.Context, key string, attempt int64) error
+
+	// Reset forgets the attempts and lockout of key
+	Reset(ctx context.Context, key string) error
+}
+
+// Policy sets when attempts start locking a key out and for how long
+type Policy struct {
+	FreeAttempts int           // Attempts allowed before lockouts start
+	BaseLockout  time.Duration // Lockout after the first attempt past FreeAttempts; doubled for every further attempt
+	MaxLockout   time.Duration
+	Window       time.Duration // Attempts are forgotten this long after the latest one
+}
+
+// Lockout returns how long a key is locked after its nth attempt
+func (p Policy) Lockout(attempt int64) time.Duration {
+	over := attempt - int64(p.FreeAttempts)
+	if over <= 0 {
+		return 0
+	}
+	lockout := p.BaseLockout
+	for i := int64(1); i < over && lockout < p.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if p.MaxLockout > 0 && lockout > p.MaxLockout {
+		lockout = p.MaxLockout
+	}
+	return lockout
+}
+
+// Limiter applies a policy per key in a store, with exponentially growing
+// lockouts for keys that keep failing. Attempts are reserved before they are
+// made and count as failures unless released.
+type Limiter struct {
+	store  Store
+	prefix string
+	policy Policy
+}
+
+// NewLimiter creates a limiter whose keys are namespaced by prefix
+func NewLimiter(store Store, prefix string, policy Policy) (*Limiter, error) {
+	if policy.BaseLockout <= 0 || policy.Window <= 0 {
+		return nil, errors.New("throttle policy needs a base lockout and a window")
+	}
+	if policy.MaxLockout < policy.BaseLockout {
+		policy.MaxLockout = policy.BaseLockout
+	}
+	return &Limiter{store: store, prefix: prefix + ":", policy: policy}, nil
+}
+
+// Reservation is an attempt counted by Reserve
+type Reservation struct {
+	Key     string
+	Attempt int64         // Number of the attempt since the key was last reset; 0 when nothing was counted
+	Lockout time.Duration // Lockout the attempt started, which stands unless it is released
+}
+
+// Reserve counts an attempt for key. When key is locked nothing is counted
+// and it returns how long to wait instead. Attempts that turn out not to be
+// failures are released, or the key reset after a success.
+func (l *Limiter) Reserve(ctx context.Context, key string) (Reservation, time.Duration, error) {
+	attempt, wait, err := l.store.Reserve(ctx, l.prefix+key, l.policy)
+	if err != nil || wait > 0 {
+		return Reservation{}, wait, err
+	}
+	return Reservation{Key: key, Attempt: attempt, Lockout: l.policy.Lockout(attempt)}, 0, nil
+}
+
+// Release uncounts a reserved attempt that did not fail
+func (l *Limiter) Release(ctx context.Context, r Reservation) error {
+	if r.Attempt == 0 {
+		return nil
+	}
+	return l.store.Release(ctx, l.prefix+r.Key, r.Attempt)
+}
+
+// Reset forgets the attempts of key, as after a success
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, l.prefix+key)
+}
@@ -0,0 +1,173 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// clock is a manually advanced time source for the memory store
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestLimiter returns a limiter on a memory store driven by a manual clock
+func newTestLimiter(t *testing.T, policy Policy) (*Limiter, *clock) {
+	t.Helper()
+	c := &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemory()
+	store.now = c.Now
+	limiter, err := NewLimiter(store, "test", policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return limiter, c
+}
+
+func TestPolicyLockout(t *testing.T) {
+	policy := Policy{FreeAttempts: 3, BaseLockout: time.Second, MaxLockout: 5 * time.Second}
+
+	tests := []struct {
+		attempt int64
+		want    time.Duration
+	}{
+		{attempt: 1, want: 0},
+		{attempt: 3, want: 0},
+		{attempt: 4, want: time.Second},
+		{attempt: 5, want: 2 * time.Second},
+		{attempt: 6, want: 4 * time.Second},
+		{attempt: 7, want: 5 * time.Second},
+		{attempt: 50, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.Lockout(tt.attempt); got != tt.want {
+			t.Errorf("Lockout(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	ctx := context.Background()
+	limiter, c := newTestLimiter(t, Policy{FreeAttempts: 2, BaseLockout: time.Minute, MaxLockout: time.Hour, Window: time.Hour})
+
+	for i := int64(1); i <= 2; i++ {
+		r, wait, err := limiter.Reserve(ctx, "key")
+		if err != nil || wait != 0 || r.Attempt != i || r.Lockout != 0 {
+			t.Fatalf("attempt %d: got %+v, wait %s, %v", i, r, wait, err)
+		}
+	}
+
+	// The first attempt past the free ones goes ahead and locks the key at once
+	third, wait, err := limiter.Reserve(ctx, "key")
+	if err != nil || wait != 0 || third.Lockout != time.Minute {
+		t.Fatalf("third attempt: got %+v, wait %s, %v", third, wait, err)
+	}
+	if _, wait, _ := limiter.Reserve(ctx, "key"); wait != time.Minute {
+		t.Fatalf("wait = %s while locked, want 1m", wait)
+	}
+	if _, wait, _ := limiter.Reserve(ctx, "other"); wait != 0 {
+		t.Fatalf("other key waits %s, want 0", wait)
+	}
+
+	// Releasing the attempt lifts its lock and uncounts it
+	if err := limiter.Release(ctx, third); err != nil {
+		t.Fatal(err)
+	}
+	again, wait, _ := limiter.Reserve(ctx, "key")
+	if wait != 0 || again.Attempt != 3 {
+		t.Fatalf("after release: got %+v, wait %s, want attempt 3", again, wait)
+	}
+
+	// Lockouts double while failures continue
+	c.Advance(time.Minute)
+	if r, _, _ := limiter.Reserve(ctx, "key"); r.Lockout != 2*time.Minute {
+		t.Fatalf("fourth attempt lockout = %s, want 2m", r.Lockout)
+	}
+
+	// Attempts are forgotten a window after the latest one
+	c.Advance(time.Hour)
+	if r, wait, _ := limiter.Reserve(ctx, "key"); wait != 0 || r.Attempt != 1 {
+		t.Fatalf("after the window: got %+v, wait %s, want attempt 1", r, wait)
+	}
+
+	if err := limiter.Reset(ctx, "key"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _ := limiter.Reserve(ctx, "key"); r.Attempt != 1 {
+		t.Fatalf("after reset: got attempt %d, want 1", r.Attempt)
+	}
+}
+
+func TestLimiterReleaseKeepsNewerLock(t *testing.T) {
+	ctx := context.Background()
+	limiter, c := newTestLimiter(t, Policy{FreeAttempts: 0, BaseLockout: time.Minute, Window: time.Hour})
+
+	first, _, _ := limiter.Reserve(ctx, "key")
+	c.Advance(time.Minute)
+	second, _, _ := limiter.Reserve(ctx, "key")
+	if second.Attempt != 2 {
+		t.Fatalf("second attempt = %d, want 2", second.Attempt)
+	}
+
+	// The first attempt's lock was replaced, so releasing it keeps the second one
+	if err := limiter.Release(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, wait, _ := limiter.Reserve(ctx, "key"); wait == 0 {
+		t.Fatal("releasing an older attempt lifted a newer lock")
+	}
+}
+
+func TestLimiterReserveConcurrent(t *testing.T) {
+	ctx := context.Background()
+	limiter, _ := newTestLimiter(t, Policy{FreeAttempts: 5, BaseLockout: time.Minute, Window: time.Hour})
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, wait, err := limiter.Reserve(ctx, "key")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if wait == 0 {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The free attempts and the one that started the lockout go ahead
+	if allowed != 6 {
+		t.Errorf("%d concurrent attempts went ahead, want 6", allowed)
+	}
+}
+
+func TestNewLimiterValidatesPolicy(t *testing.T) {
+	if _, err := NewLimiter(NewMemory(), "test", Policy{Window: time.Hour}); err == nil {
+		t.Error("policy without a base lockout was accepted")
+	}
+	if _, err := NewLimiter(NewMemory(), "test", Policy{BaseLockout: time.Second}); err == nil {
+		t.Error("policy without a window was accepted")
+	}
+}